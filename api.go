@@ -1,15 +1,16 @@
 package api2go
 
 import (
-	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"log"
 	"net/http"
 	"net/url"
 	"reflect"
 	"regexp"
+	"runtime/debug"
 	"strconv"
 	"strings"
 
@@ -19,6 +20,7 @@ import (
 
 const (
 	codeInvalidQueryFields  = "API2GO_INVALID_FIELD_QUERY_PARAM"
+	codeInvalidPayload      = "API2GO_INVALID_PAYLOAD"
 	defaultContentTypHeader = "application/vnd.api+json"
 )
 
@@ -178,7 +180,12 @@ func (n notAllowedHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		contentType = n.API.ContentType
 	}
 
-	handleError(err, w, r, contentType)
+	codec := jsonapi.GetCodec()
+	if n.API != nil {
+		codec = n.API.jsonCodec()
+	}
+
+	writeError(err, w, contentType, codec)
 }
 
 type resource struct {
@@ -186,6 +193,8 @@ type resource struct {
 	source       interface{}
 	name         string
 	api          *API
+	// schema is the JSON schema of the attributes, see validatePayload
+	schema openAPIObject
 }
 
 // middlewareChain executes the middleeware chain setup
@@ -195,17 +204,76 @@ func (api *API) middlewareChain(c APIContexter, w http.ResponseWriter, r *http.R
 	}
 }
 
+// handleRequest takes a context from the pool, runs the middleware chain and
+// the given handler and writes an error response if the handler fails.
+// Panics inside the middlewares or the handler are recovered and the context is
+// always released.
+func (api *API) handleRequest(w http.ResponseWriter, r *http.Request, context map[string]interface{}, handler func(APIContexter) error) {
+	c := api.contextPool.Get().(APIContexter)
+	c.Reset()
+	defer api.contextPool.Put(c)
+	defer api.recoverPanic(c, w, r)
+
+	if requestContexter, ok := c.(RequestContexter); ok {
+		requestContexter.SetRequestContext(r.Context())
+	}
+
+	for key, val := range context {
+		c.Set(key, val)
+	}
+
+	api.middlewareChain(c, w, r)
+
+	err := handler(c)
+	if err != nil {
+		api.handleError(err, w, r)
+	}
+}
+
+// recoverPanic must be deferred, it reports a recovered panic to the panic
+// handler and answers the request with an internal server error.
+func (api *API) recoverPanic(c APIContexter, w http.ResponseWriter, r *http.Request) {
+	recovered := recover()
+	if recovered == nil {
+		return
+	}
+
+	// http.ErrAbortHandler is used to abort a request on purpose
+	if recovered == http.ErrAbortHandler {
+		panic(recovered)
+	}
+
+	if api.panicHandler != nil {
+		api.panicHandler(c, r, recovered)
+	} else {
+		log.Printf("panic while handling %s %s: %v\n%s", r.Method, r.URL.Path, recovered, debug.Stack())
+	}
+
+	err := NewHTTPError(fmt.Errorf("panic: %v", recovered), http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+	writeResult(w, []byte(marshalHTTPError(err, api.jsonCodec())), http.StatusInternalServerError, api.ContentType)
+}
+
 // allocateContext creates a context for the api.contextPool, saving allocations
 func (api *API) allocateDefaultContext() APIContexter {
 	return &APIContext{}
 }
 
-func (api *API) addResource(prototype jsonapi.MarshalIdentifier, source interface{}) *resource {
+func (api *API) addResource(prototype interface{}, source interface{}) *resource {
 	resourceType := reflect.TypeOf(prototype)
-	if resourceType.Kind() != reflect.Struct && resourceType.Kind() != reflect.Ptr {
+	if resourceType == nil || (resourceType.Kind() != reflect.Struct && resourceType.Kind() != reflect.Ptr) {
 		panic("pass an empty resource struct or a struct pointer to AddResource!")
 	}
 
+	if err := jsonapi.CheckTags(prototype); err != nil {
+		panic(err)
+	}
+
+	// structs with jsonapi tags are used through an adapter
+	tagged := jsonapi.WrapTaggedWithOptions(prototype, api.jsonOptions())
+	if _, ok := tagged.(jsonapi.MarshalIdentifier); !ok {
+		panic("the resource passed to AddResource must implement jsonapi.MarshalIdentifier or use jsonapi struct tags!")
+	}
+
 	var ptrPrototype interface{}
 	var name string
 
@@ -218,11 +286,11 @@ func (api *API) addResource(prototype jsonapi.MarshalIdentifier, source interfac
 	}
 
 	// check if EntityNamer interface is implemented and use that as name
-	entityName, ok := prototype.(jsonapi.EntityNamer)
+	entityName, ok := tagged.(jsonapi.EntityNamer)
 	if ok {
 		name = entityName.GetName()
 	} else {
-		name = jsonapi.Jsonify(jsonapi.Pluralize(name))
+		name = api.typeName(name)
 	}
 
 	res := resource{
@@ -230,187 +298,111 @@ func (api *API) addResource(prototype jsonapi.MarshalIdentifier, source interfac
 		name:         name,
 		source:       source,
 		api:          api,
+		schema:       newSchemaGenerator().attributesSchema(resourceType),
 	}
 
-	requestInfo := func(r *http.Request, api *API) *information {
-		var info *information
-		if resolver, ok := api.info.resolver.(RequestAwareURLResolver); ok {
-			resolver.SetRequest(*r)
-			info = &information{prefix: api.info.prefix, resolver: resolver}
-		} else {
-			info = &api.info
-		}
-
-		return info
-	}
+	baseURL := api.resourcePath(name)
 
-	prefix := strings.Trim(api.info.prefix, "/")
-	baseURL := "/" + name
-	if prefix != "" {
-		baseURL = "/" + prefix + baseURL
+	// handle registers a route of the resource, requests with an invalid id
+	// never reach the source
+	handle := func(route Route, handler routing.HandlerFunc) {
+		api.handle(route, res.validateID(handler))
 	}
 
-	api.router.Handle("OPTIONS", baseURL, func(w http.ResponseWriter, r *http.Request, _ map[string]string, context map[string]interface{}) {
-		c := api.contextPool.Get().(APIContexter)
-		c.Reset()
-
-		for key, val := range context {
-			c.Set(key, val)
-		}
-
-		api.middlewareChain(c, w, r)
-		w.Header().Set("Allow", strings.Join(getAllowedMethods(source, true), ","))
-		w.WriteHeader(http.StatusNoContent)
-		api.contextPool.Put(c)
+	handle(Route{Method: http.MethodOptions, Path: baseURL, Resource: name, Operation: OperationOptions}, func(w http.ResponseWriter, r *http.Request, _ map[string]string, context map[string]interface{}) {
+		api.handleRequest(w, r, context, func(c APIContexter) error {
+			w.Header().Set("Allow", strings.Join(getAllowedMethods(source, true), ","))
+			w.WriteHeader(http.StatusNoContent)
+			return nil
+		})
 	})
 
-	api.router.Handle("GET", baseURL, func(w http.ResponseWriter, r *http.Request, _ map[string]string, context map[string]interface{}) {
-		info := requestInfo(r, api)
-		c := api.contextPool.Get().(APIContexter)
-		c.Reset()
-
-		for key, val := range context {
-			c.Set(key, val)
-		}
-
-		api.middlewareChain(c, w, r)
-
-		err := res.handleIndex(c, w, r, *info)
-		api.contextPool.Put(c)
-		if err != nil {
-			handleError(err, w, r, api.ContentType)
-		}
+	handle(Route{Method: http.MethodGet, Path: baseURL, Resource: name, Operation: OperationList}, func(w http.ResponseWriter, r *http.Request, _ map[string]string, context map[string]interface{}) {
+		api.handleRequest(w, r, context, func(c APIContexter) error {
+			info := api.requestInfo(r)
+			return res.handleIndex(c, w, r, *info)
+		})
 	})
 
 	if _, ok := source.(ResourceGetter); ok {
-		api.router.Handle("OPTIONS", baseURL+"/:id", func(w http.ResponseWriter, r *http.Request, _ map[string]string, context map[string]interface{}) {
-			c := api.contextPool.Get().(APIContexter)
-			c.Reset()
-
-			for key, val := range context {
-				c.Set(key, val)
-			}
-
-			api.middlewareChain(c, w, r)
-			w.Header().Set("Allow", strings.Join(getAllowedMethods(source, false), ","))
-			w.WriteHeader(http.StatusNoContent)
-			api.contextPool.Put(c)
+		handle(Route{Method: http.MethodOptions, Path: baseURL + "/:id", Resource: name, Operation: OperationOptions}, func(w http.ResponseWriter, r *http.Request, _ map[string]string, context map[string]interface{}) {
+			api.handleRequest(w, r, context, func(c APIContexter) error {
+				w.Header().Set("Allow", strings.Join(getAllowedMethods(source, false), ","))
+				w.WriteHeader(http.StatusNoContent)
+				return nil
+			})
 		})
 
-		api.router.Handle("GET", baseURL+"/:id", func(w http.ResponseWriter, r *http.Request, params map[string]string, context map[string]interface{}) {
-			info := requestInfo(r, api)
-			c := api.contextPool.Get().(APIContexter)
-			c.Reset()
-
-			for key, val := range context {
-				c.Set(key, val)
-			}
-
-			api.middlewareChain(c, w, r)
-			err := res.handleRead(c, w, r, params, *info)
-			api.contextPool.Put(c)
-			if err != nil {
-				handleError(err, w, r, api.ContentType)
-			}
+		handle(Route{Method: http.MethodGet, Path: baseURL + "/:id", Resource: name, Operation: OperationGet}, func(w http.ResponseWriter, r *http.Request, params map[string]string, context map[string]interface{}) {
+			api.handleRequest(w, r, context, func(c APIContexter) error {
+				info := api.requestInfo(r)
+				return res.handleRead(c, w, r, params, *info)
+			})
 		})
 	}
 
 	// generate all routes for linked relations if there are relations
-	casted, ok := prototype.(jsonapi.MarshalReferences)
+	casted, ok := tagged.(jsonapi.MarshalReferences)
 	if ok {
 		relations := casted.GetReferences()
 		for _, relation := range relations {
-			api.router.Handle("GET", baseURL+"/:id/relationships/"+relation.Name, func(relation jsonapi.Reference) routing.HandlerFunc {
-				return func(w http.ResponseWriter, r *http.Request, params map[string]string, context map[string]interface{}) {
-					info := requestInfo(r, api)
-					c := api.contextPool.Get().(APIContexter)
-					c.Reset()
-
-					for key, val := range context {
-						c.Set(key, val)
-					}
+			member := jsonapi.MemberName(relation.Name)
+			relationshipRoute := func(method string, operation Operation) Route {
+				return Route{
+					Method:       method,
+					Path:         baseURL + "/:id/relationships/" + member,
+					Resource:     name,
+					Operation:    operation,
+					Relationship: member,
+				}
+			}
 
-					api.middlewareChain(c, w, r)
-					err := res.handleReadRelation(c, w, r, params, *info, relation)
-					api.contextPool.Put(c)
-					if err != nil {
-						handleError(err, w, r, api.ContentType)
-					}
+			handle(relationshipRoute(http.MethodGet, OperationGetRelationship), func(relation jsonapi.Reference) routing.HandlerFunc {
+				return func(w http.ResponseWriter, r *http.Request, params map[string]string, context map[string]interface{}) {
+					api.handleRequest(w, r, context, func(c APIContexter) error {
+						info := api.requestInfo(r)
+						return res.handleReadRelation(c, w, r, params, *info, relation)
+					})
 				}
 			}(relation))
 
-			api.router.Handle("GET", baseURL+"/:id/"+relation.Name, func(relation jsonapi.Reference) routing.HandlerFunc {
+			handle(Route{Method: http.MethodGet, Path: baseURL + "/:id/" + member, Resource: name, Operation: OperationGetRelated, Relationship: member}, func(relation jsonapi.Reference) routing.HandlerFunc {
 				return func(w http.ResponseWriter, r *http.Request, params map[string]string, context map[string]interface{}) {
-					info := requestInfo(r, api)
-					c := api.contextPool.Get().(APIContexter)
-					c.Reset()
-
-					for key, val := range context {
-						c.Set(key, val)
-					}
-
-					api.middlewareChain(c, w, r)
-					err := res.handleLinked(c, api, w, r, params, relation, *info)
-					api.contextPool.Put(c)
-					if err != nil {
-						handleError(err, w, r, api.ContentType)
-					}
+					api.handleRequest(w, r, context, func(c APIContexter) error {
+						info := api.requestInfo(r)
+						return res.handleLinked(c, api, w, r, params, relation, *info)
+					})
 				}
 			}(relation))
 
-			api.router.Handle("PATCH", baseURL+"/:id/relationships/"+relation.Name, func(relation jsonapi.Reference) routing.HandlerFunc {
+			handle(relationshipRoute(http.MethodPatch, OperationReplaceRelationship), func(relation jsonapi.Reference) routing.HandlerFunc {
 				return func(w http.ResponseWriter, r *http.Request, params map[string]string, context map[string]interface{}) {
-					c := api.contextPool.Get().(APIContexter)
-					c.Reset()
-
-					for key, val := range context {
-						c.Set(key, val)
-					}
-
-					api.middlewareChain(c, w, r)
-					err := res.handleReplaceRelation(c, w, r, params, relation)
-					api.contextPool.Put(c)
-					if err != nil {
-						handleError(err, w, r, api.ContentType)
-					}
+					api.handleRequest(w, r, context, func(c APIContexter) error {
+						info := api.requestInfo(r)
+						return res.handleReplaceRelation(c, w, r, params, *info, relation)
+					})
 				}
 			}(relation))
 
-			if _, ok := ptrPrototype.(jsonapi.EditToManyRelations); ok && relation.Name == jsonapi.Pluralize(relation.Name) {
+			if _, ok := jsonapi.WrapTaggedWithOptions(ptrPrototype, api.jsonOptions()).(jsonapi.EditToManyRelations); ok && relation.IsToMany() {
 				// generate additional routes to manipulate to-many relationships
-				api.router.Handle("POST", baseURL+"/:id/relationships/"+relation.Name, func(relation jsonapi.Reference) routing.HandlerFunc {
+				handle(relationshipRoute(http.MethodPost, OperationAddToRelationship), func(relation jsonapi.Reference) routing.HandlerFunc {
 					return func(w http.ResponseWriter, r *http.Request, params map[string]string, context map[string]interface{}) {
-						c := api.contextPool.Get().(APIContexter)
-						c.Reset()
-
-						for key, val := range context {
-							c.Set(key, val)
-						}
-
-						api.middlewareChain(c, w, r)
-						err := res.handleAddToManyRelation(c, w, r, params, relation)
-						api.contextPool.Put(c)
-						if err != nil {
-							handleError(err, w, r, api.ContentType)
-						}
+						api.handleRequest(w, r, context, func(c APIContexter) error {
+							return api.handleIdempotent(w, r, func(w http.ResponseWriter) error {
+								info := api.requestInfo(r)
+								return res.handleAddToManyRelation(c, w, r, params, *info, relation)
+							})
+						})
 					}
 				}(relation))
 
-				api.router.Handle("DELETE", baseURL+"/:id/relationships/"+relation.Name, func(relation jsonapi.Reference) routing.HandlerFunc {
+				handle(relationshipRoute(http.MethodDelete, OperationRemoveFromRelationship), func(relation jsonapi.Reference) routing.HandlerFunc {
 					return func(w http.ResponseWriter, r *http.Request, params map[string]string, context map[string]interface{}) {
-						c := api.contextPool.Get().(APIContexter)
-						c.Reset()
-
-						for key, val := range context {
-							c.Set(key, val)
-						}
-
-						api.middlewareChain(c, w, r)
-						err := res.handleDeleteToManyRelation(c, w, r, params, relation)
-						api.contextPool.Put(c)
-						if err != nil {
-							handleError(err, w, r, api.ContentType)
-						}
+						api.handleRequest(w, r, context, func(c APIContexter) error {
+							info := api.requestInfo(r)
+							return res.handleDeleteToManyRelation(c, w, r, params, *info, relation)
+						})
 					}
 				}(relation))
 			}
@@ -418,64 +410,115 @@ func (api *API) addResource(prototype jsonapi.MarshalIdentifier, source interfac
 	}
 
 	if _, ok := source.(ResourceCreator); ok {
-		api.router.Handle("POST", baseURL, func(w http.ResponseWriter, r *http.Request, params map[string]string, context map[string]interface{}) {
-			info := requestInfo(r, api)
-			c := api.contextPool.Get().(APIContexter)
-			c.Reset()
+		handle(Route{Method: http.MethodPost, Path: baseURL, Resource: name, Operation: OperationCreate}, func(w http.ResponseWriter, r *http.Request, params map[string]string, context map[string]interface{}) {
+			api.handleRequest(w, r, context, func(c APIContexter) error {
+				return api.handleIdempotent(w, r, func(w http.ResponseWriter) error {
+					info := api.requestInfo(r)
+					return res.handleCreate(c, w, r, info.prefix, *info)
+				})
+			})
+		})
+	}
 
-			for key, val := range context {
-				c.Set(key, val)
-			}
+	if _, ok := source.(ResourceDeleter); ok {
+		handle(Route{Method: http.MethodDelete, Path: baseURL + "/:id", Resource: name, Operation: OperationDelete}, func(w http.ResponseWriter, r *http.Request, params map[string]string, context map[string]interface{}) {
+			api.handleRequest(w, r, context, func(c APIContexter) error {
+				info := api.requestInfo(r)
+				return res.handleDelete(c, w, r, params, *info)
+			})
+		})
+	}
 
-			api.middlewareChain(c, w, r)
-			err := res.handleCreate(c, w, r, info.prefix, *info)
-			api.contextPool.Put(c)
-			if err != nil {
-				handleError(err, w, r, api.ContentType)
-			}
+	if _, ok := source.(ResourceUpdater); ok {
+		handle(Route{Method: http.MethodPatch, Path: baseURL + "/:id", Resource: name, Operation: OperationUpdate}, func(w http.ResponseWriter, r *http.Request, params map[string]string, context map[string]interface{}) {
+			api.handleRequest(w, r, context, func(c APIContexter) error {
+				info := api.requestInfo(r)
+				return res.handleUpdate(c, w, r, params, *info)
+			})
 		})
 	}
 
-	if _, ok := source.(ResourceDeleter); ok {
-		api.router.Handle("DELETE", baseURL+"/:id", func(w http.ResponseWriter, r *http.Request, params map[string]string, context map[string]interface{}) {
-			c := api.contextPool.Get().(APIContexter)
-			c.Reset()
+	api.resources = append(api.resources, res)
+	api.addCreateRelatedRoutes()
+
+	return &res
+}
 
-			for key, val := range context {
-				c.Set(key, val)
+// addCreateRelatedRoutes adds the routes to create resources in the related
+// collections of all resources. A route is only added once the related
+// resource is registered and can create resources, so the order of AddResource
+// does not matter.
+func (api *API) addCreateRelatedRoutes() {
+	for i := range api.resources {
+		res := api.resources[i]
+		if _, ok := res.source.(ResourceUpdater); !ok {
+			continue
+		}
+
+		references, ok := jsonapi.WrapTaggedWithOptions(newPrototype(res.resourceType), api.jsonOptions()).(jsonapi.MarshalReferences)
+		if !ok {
+			continue
+		}
+
+		for _, relation := range references.GetReferences() {
+			related := api.resource(relation.Type)
+			if related == nil {
+				continue
 			}
 
-			api.middlewareChain(c, w, r)
-			err := res.handleDelete(c, w, r, params)
-			api.contextPool.Put(c)
-			if err != nil {
-				handleError(err, w, r, api.ContentType)
+			if _, ok := related.source.(ResourceCreator); !ok {
+				continue
 			}
-		})
+
+			member := jsonapi.MemberName(relation.Name)
+			route := Route{Method: http.MethodPost, Path: api.resourcePath(res.name) + "/:id/" + member, Resource: res.name, Operation: OperationCreateRelated, Relationship: member}
+			if api.hasRoute(route) {
+				continue
+			}
+
+			api.handle(route, res.validateID(func(relation jsonapi.Reference) routing.HandlerFunc {
+				return func(w http.ResponseWriter, r *http.Request, params map[string]string, context map[string]interface{}) {
+					api.handleRequest(w, r, context, func(c APIContexter) error {
+						return api.handleIdempotent(w, r, func(w http.ResponseWriter) error {
+							info := api.requestInfo(r)
+							return res.handleCreateRelated(c, w, r, params, *info, relation)
+						})
+					})
+				}
+			}(relation)))
+		}
 	}
+}
 
-	if _, ok := source.(ResourceUpdater); ok {
-		api.router.Handle("PATCH", baseURL+"/:id", func(w http.ResponseWriter, r *http.Request, params map[string]string, context map[string]interface{}) {
-			info := requestInfo(r, api)
-			c := api.contextPool.Get().(APIContexter)
-			c.Reset()
+// resourcePath returns the path of the collection of the resource name
+func (api *API) resourcePath(name string) string {
+	prefix := strings.Trim(api.info.prefix, "/")
+	if prefix == "" {
+		return "/" + name
+	}
 
-			for key, val := range context {
-				c.Set(key, val)
-			}
+	return "/" + prefix + "/" + name
+}
 
-			api.middlewareChain(c, w, r)
-			err := res.handleUpdate(c, w, r, params, *info)
-			api.contextPool.Put(c)
-			if err != nil {
-				handleError(err, w, r, api.ContentType)
-			}
-		})
+// requestInfo returns the information for the urls of a response to r
+func (api *API) requestInfo(r *http.Request) *information {
+	if resolver, ok := api.info.resolver.(RequestAwareURLResolver); ok {
+		resolver.SetRequest(*r)
+		return &information{prefix: api.info.prefix, resolver: resolver}
 	}
 
-	api.resources = append(api.resources, res)
+	return &api.info
+}
 
-	return &res
+// resource returns the registered resource with the given name or nil
+func (api *API) resource(name string) *resource {
+	for i := range api.resources {
+		if api.resources[i].name == name {
+			return &api.resources[i]
+		}
+	}
+
+	return nil
 }
 
 func getAllowedMethods(source interface{}, collection bool) []string {
@@ -518,15 +561,47 @@ func buildRequest(c APIContexter, r *http.Request) Request {
 	return req
 }
 
-func (res *resource) marshalResponse(resp interface{}, w http.ResponseWriter, status int, r *http.Request) error {
-	filtered, err := filterSparseFields(resp, r)
+// marshalResponse writes resp as json. The Responder obj is optional and used
+// for caching headers and conditional requests of successful GET requests.
+// The ETag is computed from etagResp, or from resp if it is nil.
+func (res *resource) marshalResponse(resp, etagResp interface{}, obj Responder, w http.ResponseWriter, status int, r *http.Request) error {
+	cacheable := obj != nil && status == http.StatusOK && r.Method == http.MethodGet
+
+	// the ETag is computed before the sparse fieldsets are applied, so that it
+	// can be used in the If-Match header of a PATCH or DELETE request
+	var complete []byte
+	if cacheable && res.api.etags && (etagResp != nil || hasSparseFields(r)) {
+		if etagResp == nil {
+			etagResp = resp
+		}
+
+		var err error
+		complete, err = res.api.jsonCodec().Marshal(etagResp)
+		if err != nil {
+			return err
+		}
+	}
+
+	filtered, err := filterSparseFields(resp, r, res.api.jsonCodec())
 	if err != nil {
 		return err
 	}
-	result, err := json.Marshal(filtered)
+	result, err := res.api.jsonCodec().Marshal(filtered)
 	if err != nil {
 		return err
 	}
+
+	if cacheable {
+		if complete == nil {
+			complete = result
+		}
+
+		if res.api.writeCacheHeaders(w, r, obj, complete) {
+			w.WriteHeader(http.StatusNotModified)
+			return nil
+		}
+	}
+
 	writeResult(w, result, status, res.api.ContentType)
 	return nil
 }
@@ -550,6 +625,10 @@ func (res *resource) handleIndex(c APIContexter, w http.ResponseWriter, r *http.
 		}
 	}
 
+	if source, ok := res.source.(StreamingFindAll); ok {
+		return res.respondWithStream(source, buildRequest(c, r), info, w, r)
+	}
+
 	source, ok := res.source.(FindAll)
 	if !ok {
 		return NewHTTPError(nil, "Resource does not implement the FindAll interface", http.StatusNotFound)
@@ -595,14 +674,24 @@ func (res *resource) handleReadRelation(c APIContexter, w http.ResponseWriter, r
 		return err
 	}
 
-	document, err := jsonapi.MarshalToStruct(obj.Result(), info)
+	rel, err := buildRelationship(obj, info, relation, res.api.jsonOptions())
 	if err != nil {
 		return err
 	}
 
-	rel, ok := document.Data.DataObject.Relationships[relation.Name]
+	return res.marshalResponse(rel, nil, obj, w, http.StatusOK, r)
+}
+
+// buildRelationship returns the relationship object of a single resource
+func buildRelationship(obj Responder, info information, relation jsonapi.Reference, options jsonapi.Options) (*jsonapi.Relationship, error) {
+	document, err := jsonapi.MarshalToStructWithOptions(obj.Result(), info, options)
+	if err != nil {
+		return nil, err
+	}
+
+	rel, ok := document.Data.DataObject.Relationships[jsonapi.MemberName(relation.Name)]
 	if !ok {
-		return NewHTTPError(nil, fmt.Sprintf("There is no relation with the name %s", relation.Name), http.StatusNotFound)
+		return nil, NewHTTPError(nil, fmt.Sprintf("There is no relation with the name %s", relation.Name), http.StatusNotFound)
 	}
 
 	meta := obj.Metadata()
@@ -610,7 +699,7 @@ func (res *resource) handleReadRelation(c APIContexter, w http.ResponseWriter, r
 		rel.Meta = meta
 	}
 
-	return res.marshalResponse(rel, w, http.StatusOK, r)
+	return &rel, nil
 }
 
 // try to find the referenced resource and call the findAll Method with referencing resource id as param
@@ -641,6 +730,10 @@ func (res *resource) handleLinked(c APIContexter, api *API, w http.ResponseWrite
 				}
 			}
 
+			if source, ok := resource.source.(StreamingFindAll); ok {
+				return res.respondWithStream(source, request, info, w, r)
+			}
+
 			source, ok := resource.source.(FindAll)
 			if !ok {
 				return NewHTTPError(nil, "Resource does not implement the FindAll interface", http.StatusNotFound)
@@ -668,11 +761,22 @@ func (res *resource) handleCreate(c APIContexter, w http.ResponseWriter, r *http
 		return fmt.Errorf("Resource %s does not implement the ResourceCreator interface", res.name)
 	}
 
-	ctx, err := unmarshalRequest(r)
+	response, id, err := res.create(c, r, source, buildRequest(c, r))
 	if err != nil {
 		return err
 	}
 
+	return res.respondCreated(response, id, prefix, info, w, r)
+}
+
+// create unmarshals the payload of r into a new object and passes it to the
+// Create method of source, it returns the response and the id of the new object
+func (res *resource) create(c APIContexter, r *http.Request, source ResourceCreator, req Request) (Responder, string, error) {
+	ctx, err := unmarshalRequest(r, res.api.maxBodySize)
+	if err != nil {
+		return nil, "", err
+	}
+
 	// Ok this is weird again, but reflect.New produces a pointer, so we need the pure type without pointer,
 	// otherwise we would have a pointer pointer type that we don't want.
 	resourceType := res.resourceType
@@ -681,39 +785,49 @@ func (res *resource) handleCreate(c APIContexter, w http.ResponseWriter, r *http
 	}
 	newObj := reflect.New(resourceType).Interface()
 
+	err = res.validatePayload(ctx, true)
+	if err != nil {
+		return nil, "", err
+	}
+
 	// Call InitializeObject if available to allow implementers change the object
 	// before calling Unmarshal.
 	if initSource, ok := source.(ObjectInitializer); ok {
 		initSource.InitializeObject(newObj)
 	}
 
-	err = jsonapi.Unmarshal(ctx, newObj)
+	err = res.unmarshalPayload(ctx, newObj)
 	if err != nil {
-		return NewHTTPError(nil, err.Error(), http.StatusNotAcceptable)
+		return nil, "", err
 	}
 
 	var response Responder
 
 	if res.resourceType.Kind() == reflect.Struct {
 		// we have to dereference the pointer if user wants to use non pointer values
-		response, err = source.Create(reflect.ValueOf(newObj).Elem().Interface(), buildRequest(c, r))
+		response, err = source.Create(reflect.ValueOf(newObj).Elem().Interface(), req)
 	} else {
-		response, err = source.Create(newObj, buildRequest(c, r))
+		response, err = source.Create(newObj, req)
 	}
 	if err != nil {
-		return err
+		return nil, "", err
 	}
 
-	result, ok := response.Result().(jsonapi.MarshalIdentifier)
+	result, ok := jsonapi.WrapTaggedWithOptions(response.Result(), res.api.jsonOptions()).(jsonapi.MarshalIdentifier)
 
 	if !ok {
-		return fmt.Errorf("Expected one newly created object by resource %s", res.name)
+		return nil, "", fmt.Errorf("Expected one newly created object by resource %s", res.name)
 	}
 
+	return response, result.GetID(), nil
+}
+
+// respondCreated writes the response of Create for the new object with id
+func (res *resource) respondCreated(response Responder, id, prefix string, info information, w http.ResponseWriter, r *http.Request) error {
 	if len(prefix) > 0 {
-		w.Header().Set("Location", "/"+prefix+"/"+res.name+"/"+result.GetID())
+		w.Header().Set("Location", "/"+prefix+"/"+res.name+"/"+id)
 	} else {
-		w.Header().Set("Location", "/"+res.name+"/"+result.GetID())
+		w.Header().Set("Location", "/"+res.name+"/"+id)
 	}
 
 	// handle 200 status codes
@@ -744,7 +858,19 @@ func (res *resource) handleUpdate(c APIContexter, w http.ResponseWriter, r *http
 		return err
 	}
 
-	ctx, err := unmarshalRequest(r)
+	err = res.api.checkPreconditions(r, func() (string, error) {
+		return res.api.resourceETag(obj, info, r)
+	})
+	if err != nil {
+		return err
+	}
+
+	ctx, err := unmarshalRequest(r, res.api.maxBodySize)
+	if err != nil {
+		return err
+	}
+
+	err = res.validatePayload(ctx, false)
 	if err != nil {
 		return err
 	}
@@ -754,16 +880,16 @@ func (res *resource) handleUpdate(c APIContexter, w http.ResponseWriter, r *http
 	if updatingObj.Kind() == reflect.Struct {
 		updatingObjPtr := reflect.New(reflect.TypeOf(obj.Result()))
 		updatingObjPtr.Elem().Set(updatingObj)
-		err = jsonapi.Unmarshal(ctx, updatingObjPtr.Interface())
+		err = res.unmarshalPayload(ctx, updatingObjPtr.Interface())
 		updatingObj = updatingObjPtr.Elem()
 	} else {
-		err = jsonapi.Unmarshal(ctx, updatingObj.Interface())
+		err = res.unmarshalPayload(ctx, updatingObj.Interface())
 	}
 	if err != nil {
-		return NewHTTPError(nil, err.Error(), http.StatusNotAcceptable)
+		return err
 	}
 
-	identifiable, ok := updatingObj.Interface().(jsonapi.MarshalIdentifier)
+	identifiable, ok := jsonapi.WrapTaggedWithOptions(updatingObj.Interface(), res.api.jsonOptions()).(jsonapi.MarshalIdentifier)
 	if !ok || identifiable.GetID() != id {
 		conflictError := errors.New("id in the resource does not match servers endpoint")
 		return NewHTTPError(conflictError, conflictError.Error(), http.StatusConflict)
@@ -803,7 +929,7 @@ func (res *resource) handleUpdate(c APIContexter, w http.ResponseWriter, r *http
 	}
 }
 
-func (res *resource) handleReplaceRelation(c APIContexter, w http.ResponseWriter, r *http.Request, params map[string]string, relation jsonapi.Reference) error {
+func (res *resource) handleReplaceRelation(c APIContexter, w http.ResponseWriter, r *http.Request, params map[string]string, info information, relation jsonapi.Reference) error {
 	source, ok := res.source.(ResourceUpdater)
 
 	if !ok {
@@ -822,13 +948,20 @@ func (res *resource) handleReplaceRelation(c APIContexter, w http.ResponseWriter
 		return err
 	}
 
-	body, err := unmarshalRequest(r)
+	err = res.api.checkPreconditions(r, func() (string, error) {
+		return res.api.relationshipETag(response, info, r, relation)
+	})
+	if err != nil {
+		return err
+	}
+
+	body, err := unmarshalRequest(r, res.api.maxBodySize)
 	if err != nil {
 		return err
 	}
 
 	inc := map[string]interface{}{}
-	err = json.Unmarshal(body, &inc)
+	err = res.api.jsonCodec().Unmarshal(body, &inc)
 	if err != nil {
 		return err
 	}
@@ -844,7 +977,7 @@ func (res *resource) handleReplaceRelation(c APIContexter, w http.ResponseWriter
 		editObj = response.Result()
 	}
 
-	err = processRelationshipsData(data, relation.Name, editObj)
+	err = processRelationshipsData(data, relation.Name, jsonapi.WrapTaggedWithOptions(editObj, res.api.jsonOptions()))
 	if err != nil {
 		return err
 	}
@@ -859,18 +992,13 @@ func (res *resource) handleReplaceRelation(c APIContexter, w http.ResponseWriter
 	return err
 }
 
-func (res *resource) handleAddToManyRelation(c APIContexter, w http.ResponseWriter, r *http.Request, params map[string]string, relation jsonapi.Reference) error {
+func (res *resource) handleAddToManyRelation(c APIContexter, w http.ResponseWriter, r *http.Request, params map[string]string, info information, relation jsonapi.Reference) error {
 	source, ok := res.source.(ResourceUpdater)
 
 	if !ok {
 		return fmt.Errorf("Resource %s does not implement the ResourceUpdater interface", res.name)
 	}
 
-	var (
-		err     error
-		editObj interface{}
-	)
-
 	id := params["id"]
 
 	response, err := source.FindOne(id, buildRequest(c, r))
@@ -878,12 +1006,19 @@ func (res *resource) handleAddToManyRelation(c APIContexter, w http.ResponseWrit
 		return err
 	}
 
-	body, err := unmarshalRequest(r)
+	err = res.api.checkPreconditions(r, func() (string, error) {
+		return res.api.relationshipETag(response, info, r, relation)
+	})
+	if err != nil {
+		return err
+	}
+
+	body, err := unmarshalRequest(r, res.api.maxBodySize)
 	if err != nil {
 		return err
 	}
 	inc := map[string]interface{}{}
-	err = json.Unmarshal(body, &inc)
+	err = res.api.jsonCodec().Unmarshal(body, &inc)
 	if err != nil {
 		return err
 	}
@@ -913,6 +1048,20 @@ func (res *resource) handleAddToManyRelation(c APIContexter, w http.ResponseWrit
 		newIDs = append(newIDs, newID)
 	}
 
+	err = res.addToManyIDs(c, r, source, response, relation, newIDs)
+
+	w.WriteHeader(http.StatusNoContent)
+
+	return err
+}
+
+// addToManyIDs adds ids to the relation of the object in response and saves it
+func (res *resource) addToManyIDs(c APIContexter, r *http.Request, source ResourceUpdater, response Responder, relation jsonapi.Reference, ids []string) error {
+	var (
+		err     error
+		editObj interface{}
+	)
+
 	resType := reflect.TypeOf(response.Result()).Kind()
 	if resType == reflect.Struct {
 		editObj = getPointerToStruct(response.Result())
@@ -920,24 +1069,139 @@ func (res *resource) handleAddToManyRelation(c APIContexter, w http.ResponseWrit
 		editObj = response.Result()
 	}
 
-	targetObj, ok := editObj.(jsonapi.EditToManyRelations)
+	targetObj, ok := jsonapi.WrapTaggedWithOptions(editObj, res.api.jsonOptions()).(jsonapi.EditToManyRelations)
 	if !ok {
 		return errors.New("target struct must implement jsonapi.EditToManyRelations")
 	}
-	targetObj.AddToManyIDs(relation.Name, newIDs)
+	targetObj.AddToManyIDs(relation.Name, ids)
 
 	if resType == reflect.Struct {
-		_, err = source.Update(reflect.ValueOf(targetObj).Elem().Interface(), buildRequest(c, r))
+		_, err = source.Update(reflect.ValueOf(editObj).Elem().Interface(), buildRequest(c, r))
 	} else {
-		_, err = source.Update(targetObj, buildRequest(c, r))
+		_, err = source.Update(editObj, buildRequest(c, r))
 	}
 
-	w.WriteHeader(http.StatusNoContent)
+	return err
+}
+
+// linkRelated adds id to the relation of the object in response and saves it.
+// To-many relations are extended with AddToManyIDs if possible, otherwise
+// they are replaced with the old ids and id.
+func (res *resource) linkRelated(c APIContexter, r *http.Request, source ResourceUpdater, response Responder, relation jsonapi.Reference, id string) error {
+	var (
+		err     error
+		editObj interface{}
+	)
+
+	resType := reflect.TypeOf(response.Result()).Kind()
+	if resType == reflect.Struct {
+		editObj = getPointerToStruct(response.Result())
+	} else {
+		editObj = response.Result()
+	}
+
+	targetObj := jsonapi.WrapTaggedWithOptions(editObj, res.api.jsonOptions())
+	if relation.IsToMany() {
+		if editToMany, ok := targetObj.(jsonapi.EditToManyRelations); ok {
+			err = editToMany.AddToManyIDs(relation.Name, []string{id})
+		} else if toMany, ok := targetObj.(jsonapi.UnmarshalToManyRelations); ok {
+			IDs := []string{}
+			if linked, ok := targetObj.(jsonapi.MarshalLinkedRelations); ok {
+				for _, referenceID := range linked.GetReferencedIDs() {
+					if referenceID.Name == relation.Name {
+						IDs = append(IDs, referenceID.ID)
+					}
+				}
+			}
+			err = toMany.SetToManyReferenceIDs(relation.Name, append(IDs, id))
+		} else {
+			err = errors.New("target struct must implement jsonapi.UnmarshalToManyRelations")
+		}
+	} else if toOne, ok := targetObj.(jsonapi.UnmarshalToOneRelations); ok {
+		err = toOne.SetToOneReferenceID(relation.Name, id)
+	} else {
+		err = errors.New("target struct must implement jsonapi.UnmarshalToOneRelations")
+	}
+	if err != nil {
+		return err
+	}
+
+	if resType == reflect.Struct {
+		_, err = source.Update(reflect.ValueOf(editObj).Elem().Interface(), buildRequest(c, r))
+	} else {
+		_, err = source.Update(editObj, buildRequest(c, r))
+	}
 
 	return err
 }
 
-func (res *resource) handleDeleteToManyRelation(c APIContexter, w http.ResponseWriter, r *http.Request, params map[string]string, relation jsonapi.Reference) error {
+// handleCreateRelated creates a resource in the related collection of relation
+// and adds it to the relationship of the parent with the given id
+func (res *resource) handleCreateRelated(c APIContexter, w http.ResponseWriter, r *http.Request, params map[string]string, info information, relation jsonapi.Reference) error {
+	source, ok := res.source.(ResourceUpdater)
+
+	if !ok {
+		return fmt.Errorf("Resource %s does not implement the ResourceUpdater interface", res.name)
+	}
+
+	// the route is only registered if the related resource can create
+	related := res.api.resource(relation.Type)
+	if related == nil {
+		return fmt.Errorf("No resource is registered for the linked resource %s", relation.Name)
+	}
+
+	relatedSource, ok := related.source.(ResourceCreator)
+	if !ok {
+		return fmt.Errorf("Resource %s does not implement the ResourceCreator interface", related.name)
+	}
+
+	var (
+		response Responder
+		newID    string
+	)
+
+	id := params["id"]
+
+	err := res.api.transaction(c, func() error {
+		parent, err := source.FindOne(id, buildRequest(c, r))
+		if err != nil {
+			return err
+		}
+
+		err = res.api.checkPreconditions(r, func() (string, error) {
+			return res.api.relationshipETag(parent, info, r, relation)
+		})
+		if err != nil {
+			return err
+		}
+
+		req := buildRequest(c, r)
+		req.Parent = &ParentReference{Type: res.name, ID: id, Relationship: relation.Name}
+		response, newID, err = related.create(c, r, relatedSource, req)
+		if err != nil {
+			return err
+		}
+
+		err = res.linkRelated(c, r, source, parent, relation, newID)
+		if err != nil && res.api.transactionHook == nil {
+			// without a transaction the new resource would be orphaned
+			if deleter, ok := related.source.(ResourceDeleter); ok {
+				if _, deleteErr := deleter.Delete(newID, req); deleteErr != nil {
+					log.Printf("api2go: could not delete %s %s after linking it failed: %v", related.name, newID, deleteErr)
+				}
+			}
+		}
+
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	return related.respondCreated(response, newID, info.prefix, info, w, r)
+}
+
+func (res *resource) handleDeleteToManyRelation(c APIContexter, w http.ResponseWriter, r *http.Request, params map[string]string, info information, relation jsonapi.Reference) error {
 	source, ok := res.source.(ResourceUpdater)
 
 	if !ok {
@@ -956,13 +1220,20 @@ func (res *resource) handleDeleteToManyRelation(c APIContexter, w http.ResponseW
 		return err
 	}
 
-	body, err := unmarshalRequest(r)
+	err = res.api.checkPreconditions(r, func() (string, error) {
+		return res.api.relationshipETag(response, info, r, relation)
+	})
+	if err != nil {
+		return err
+	}
+
+	body, err := unmarshalRequest(r, res.api.maxBodySize)
 	if err != nil {
 		return err
 	}
 
 	inc := map[string]interface{}{}
-	err = json.Unmarshal(body, &inc)
+	err = res.api.jsonCodec().Unmarshal(body, &inc)
 	if err != nil {
 		return err
 	}
@@ -999,16 +1270,16 @@ func (res *resource) handleDeleteToManyRelation(c APIContexter, w http.ResponseW
 		editObj = response.Result()
 	}
 
-	targetObj, ok := editObj.(jsonapi.EditToManyRelations)
+	targetObj, ok := jsonapi.WrapTaggedWithOptions(editObj, res.api.jsonOptions()).(jsonapi.EditToManyRelations)
 	if !ok {
 		return errors.New("target struct must implement jsonapi.EditToManyRelations")
 	}
 	targetObj.DeleteToManyIDs(relation.Name, obsoleteIDs)
 
 	if resType == reflect.Struct {
-		_, err = source.Update(reflect.ValueOf(targetObj).Elem().Interface(), buildRequest(c, r))
+		_, err = source.Update(reflect.ValueOf(editObj).Elem().Interface(), buildRequest(c, r))
 	} else {
-		_, err = source.Update(targetObj, buildRequest(c, r))
+		_, err = source.Update(editObj, buildRequest(c, r))
 	}
 
 	w.WriteHeader(http.StatusNoContent)
@@ -1024,7 +1295,7 @@ func getPointerToStruct(oldObj interface{}) interface{} {
 	return ptr.Interface()
 }
 
-func (res *resource) handleDelete(c APIContexter, w http.ResponseWriter, r *http.Request, params map[string]string) error {
+func (res *resource) handleDelete(c APIContexter, w http.ResponseWriter, r *http.Request, params map[string]string, info information) error {
 	source, ok := res.source.(ResourceDeleter)
 
 	if !ok {
@@ -1032,6 +1303,27 @@ func (res *resource) handleDelete(c APIContexter, w http.ResponseWriter, r *http
 	}
 
 	id := params["id"]
+
+	if r.Header.Get("If-Match") != "" || res.api.requirePreconditions {
+		err := res.api.checkPreconditions(r, func() (string, error) {
+			// without a getter the current version is unknown and only * matches
+			getter, ok := res.source.(ResourceGetter)
+			if !ok {
+				return "", nil
+			}
+
+			obj, err := getter.FindOne(id, buildRequest(c, r))
+			if err != nil {
+				return "", err
+			}
+
+			return res.api.resourceETag(obj, info, r)
+		})
+		if err != nil {
+			return err
+		}
+	}
+
 	response, err := source.Delete(id, buildRequest(c, r))
 	if err != nil {
 		return err
@@ -1043,7 +1335,7 @@ func (res *resource) handleDelete(c APIContexter, w http.ResponseWriter, r *http
 			"meta": response.Metadata(),
 		}
 
-		return res.marshalResponse(data, w, http.StatusOK, r)
+		return res.marshalResponse(data, nil, nil, w, http.StatusOK, r)
 	case http.StatusAccepted:
 		w.WriteHeader(http.StatusAccepted)
 		return nil
@@ -1062,11 +1354,33 @@ func writeResult(w http.ResponseWriter, data []byte, status int, contentType str
 }
 
 func (res *resource) respondWith(obj Responder, info information, status int, w http.ResponseWriter, r *http.Request) error {
-	data, err := jsonapi.MarshalToStruct(obj.Result(), info)
+	data, err := buildDocument(obj, info, r, res.api.jsonOptions())
 	if err != nil {
 		return err
 	}
 
+	// links of a LinksResponder can depend on the query, the ETag must not,
+	// otherwise it would not match the If-Match check of a PATCH or DELETE
+	var complete interface{}
+	if res.api.etags && r.Method == http.MethodGet && r.URL.RawQuery != "" {
+		if _, ok := obj.(LinksResponder); ok {
+			complete, err = buildDocument(obj, info, withoutQuery(r), res.api.jsonOptions())
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return res.marshalResponse(data, complete, obj, w, status, r)
+}
+
+// buildDocument returns the document for a response including meta and links
+func buildDocument(obj Responder, info information, r *http.Request, options jsonapi.Options) (*jsonapi.Document, error) {
+	data, err := jsonapi.MarshalToStructWithOptions(obj.Result(), info, options)
+	if err != nil {
+		return nil, err
+	}
+
 	meta := obj.Metadata()
 	if len(meta) > 0 {
 		data.Meta = meta
@@ -1078,37 +1392,161 @@ func (res *resource) respondWith(obj Responder, info information, status int, w
 		links := objWithLinks.Links(r, requestURL)
 		if len(links) > 0 {
 			data.Links = links
+			data.SetCodec(options.Codec)
 		}
 	}
 
-	return res.marshalResponse(data, w, status, r)
+	return data, nil
 }
 
 func (res *resource) respondWithPagination(obj Responder, info information, status int, links jsonapi.Links, w http.ResponseWriter, r *http.Request) error {
-	data, err := jsonapi.MarshalToStruct(obj.Result(), info)
+	data, err := jsonapi.MarshalToStructWithOptions(obj.Result(), info, res.api.jsonOptions())
 	if err != nil {
 		return err
 	}
 
 	data.Links = links
+	data.SetCodec(res.api.codec)
 	meta := obj.Metadata()
 	if len(meta) > 0 {
 		data.Meta = meta
 	}
 
-	return res.marshalResponse(data, w, status, r)
+	return res.marshalResponse(data, nil, obj, w, status, r)
+}
+
+// streamingResponseWriter delays the header until the first bytes are written,
+// so errors that happen before can still be sent as error document
+type streamingResponseWriter struct {
+	http.ResponseWriter
+	contentType string
+	written     bool
+}
+
+func (s *streamingResponseWriter) Write(p []byte) (int, error) {
+	if !s.written {
+		s.written = true
+		s.ResponseWriter.Header().Set("Content-Type", s.contentType)
+		s.ResponseWriter.WriteHeader(http.StatusOK)
+	}
+
+	return s.ResponseWriter.Write(p)
+}
+
+func (res *resource) respondWithStream(source StreamingFindAll, req Request, info information, w http.ResponseWriter, r *http.Request) error {
+	iterator, err := source.StreamFindAll(req)
+	if err != nil {
+		return err
+	}
+	defer iterator.Close()
+
+	stream := &streamingResponseWriter{ResponseWriter: w, contentType: res.api.ContentType}
+	encoder := jsonapi.NewEncoder(stream, info)
+	encoder.SetCodec(res.api.jsonCodec())
+	encoder.SetTypeNamer(res.api.typeNamer)
+
+	query := r.URL.Query()
+	if queryParams := parseQueryFields(&query); len(queryParams) > 0 {
+		encoder.SetFilter(func(data *jsonapi.Data) error {
+			if wrongFields := replaceAttributes(&queryParams, data, res.api.jsonCodec()); len(wrongFields) > 0 {
+				return invalidFieldsError(wrongFields)
+			}
+
+			return nil
+		})
+	}
+
+	err = encodeStream(iterator, encoder)
+	if err != nil && stream.written {
+		// the status code has already been sent, abort the response so the
+		// client can not mistake it for a complete document
+		panic(http.ErrAbortHandler)
+	}
+
+	return err
+}
+
+func encodeStream(iterator ResultIterator, encoder *jsonapi.Encoder) error {
+	for {
+		element, ok, err := iterator.Next()
+		if err != nil {
+			return err
+		}
+
+		if !ok {
+			return encoder.Close()
+		}
+
+		if err := encoder.Encode(element); err != nil {
+			return err
+		}
+	}
 }
 
-func unmarshalRequest(r *http.Request) ([]byte, error) {
+// unmarshalRequest reads the request body, a maxSize greater than 0 limits the
+// number of bytes that are accepted
+func unmarshalRequest(r *http.Request, maxSize int64) ([]byte, error) {
 	defer r.Body.Close()
-	data, err := ioutil.ReadAll(r.Body)
+
+	if maxSize <= 0 {
+		return ioutil.ReadAll(r.Body)
+	}
+
+	data, err := ioutil.ReadAll(io.LimitReader(r.Body, maxSize+1))
 	if err != nil {
 		return nil, err
 	}
+
+	if int64(len(data)) > maxSize {
+		return nil, NewHTTPError(
+			fmt.Errorf("request body exceeds %d bytes", maxSize),
+			"Request body too large",
+			http.StatusRequestEntityTooLarge,
+		)
+	}
+
 	return data, nil
 }
 
-func filterSparseFields(resp interface{}, r *http.Request) (interface{}, error) {
+// unmarshalPayload unmarshals a request body into target. In strict mode all
+// invalid members of the payload are reported with a pointer to the member.
+func (res *resource) unmarshalPayload(body []byte, target interface{}) error {
+	if !res.api.strictDecoding {
+		err := jsonapi.UnmarshalWithOptions(body, target, res.api.jsonOptions())
+		if err != nil {
+			return NewHTTPError(nil, err.Error(), http.StatusNotAcceptable)
+		}
+
+		return nil
+	}
+
+	err := jsonapi.UnmarshalStrictWithOptions(body, target, res.api.jsonOptions())
+	if err == nil {
+		return nil
+	}
+
+	var payloadErrors jsonapi.PayloadErrors
+	if !errors.As(err, &payloadErrors) {
+		return NewHTTPError(nil, err.Error(), http.StatusNotAcceptable)
+	}
+
+	httpError := NewHTTPError(err, "Invalid payload", http.StatusBadRequest)
+	for _, payloadError := range payloadErrors {
+		httpError.Errors = append(httpError.Errors, Error{
+			Status: strconv.Itoa(http.StatusBadRequest),
+			Code:   codeInvalidPayload,
+			Title:  "Invalid member in payload",
+			Detail: payloadError.Detail,
+			Source: &ErrorSource{
+				Pointer: payloadError.Pointer,
+			},
+		})
+	}
+
+	return httpError
+}
+
+func filterSparseFields(resp interface{}, r *http.Request, codec jsonapi.Codec) (interface{}, error) {
 	query := r.URL.Query()
 	queryParams := parseQueryFields(&query)
 	if len(queryParams) < 1 {
@@ -1121,7 +1559,7 @@ func filterSparseFields(resp interface{}, r *http.Request) (interface{}, error)
 		// single entry in data
 		data := document.Data.DataObject
 		if data != nil {
-			errors := replaceAttributes(&queryParams, data)
+			errors := replaceAttributes(&queryParams, data, codec)
 			for t, v := range errors {
 				wrongFields[t] = v
 			}
@@ -1130,7 +1568,7 @@ func filterSparseFields(resp interface{}, r *http.Request) (interface{}, error)
 		// data can be a slice too
 		datas := document.Data.DataArray
 		for index, data := range datas {
-			errors := replaceAttributes(&queryParams, &data)
+			errors := replaceAttributes(&queryParams, &data, codec)
 			for t, v := range errors {
 				wrongFields[t] = v
 			}
@@ -1139,7 +1577,7 @@ func filterSparseFields(resp interface{}, r *http.Request) (interface{}, error)
 
 		// included slice
 		for index, include := range document.Included {
-			errors := replaceAttributes(&queryParams, &include)
+			errors := replaceAttributes(&queryParams, &include, codec)
 			for t, v := range errors {
 				wrongFields[t] = v
 			}
@@ -1147,26 +1585,37 @@ func filterSparseFields(resp interface{}, r *http.Request) (interface{}, error)
 		}
 
 		if len(wrongFields) > 0 {
-			httpError := NewHTTPError(nil, "Some requested fields were invalid", http.StatusBadRequest)
-			for k, v := range wrongFields {
-				for _, field := range v {
-					httpError.Errors = append(httpError.Errors, Error{
-						Status: "Bad Request",
-						Code:   codeInvalidQueryFields,
-						Title:  fmt.Sprintf(`Field "%s" does not exist for type "%s"`, field, k),
-						Detail: "Please make sure you do only request existing fields",
-						Source: &ErrorSource{
-							Parameter: fmt.Sprintf("fields[%s]", k),
-						},
-					})
-				}
-			}
-			return nil, httpError
+			return nil, invalidFieldsError(wrongFields)
 		}
 	}
 	return resp, nil
 }
 
+func invalidFieldsError(wrongFields map[string][]string) error {
+	httpError := NewHTTPError(nil, "Some requested fields were invalid", http.StatusBadRequest)
+	for k, v := range wrongFields {
+		for _, field := range v {
+			httpError.Errors = append(httpError.Errors, Error{
+				Status: "Bad Request",
+				Code:   codeInvalidQueryFields,
+				Title:  fmt.Sprintf(`Field "%s" does not exist for type "%s"`, field, k),
+				Detail: "Please make sure you do only request existing fields",
+				Source: &ErrorSource{
+					Parameter: fmt.Sprintf("fields[%s]", k),
+				},
+			})
+		}
+	}
+
+	return httpError
+}
+
+// hasSparseFields checks if the request contains a fields[type] parameter
+func hasSparseFields(r *http.Request) bool {
+	query := r.URL.Query()
+	return len(parseQueryFields(&query)) > 0
+}
+
 func parseQueryFields(query *url.Values) (result map[string][]string) {
 	result = map[string][]string{}
 	for name, param := range *query {
@@ -1195,10 +1644,10 @@ func filterAttributes(attributes map[string]interface{}, fields []string) (filte
 	return
 }
 
-func replaceAttributes(query *map[string][]string, entry *jsonapi.Data) map[string][]string {
+func replaceAttributes(query *map[string][]string, entry *jsonapi.Data, codec jsonapi.Codec) map[string][]string {
 	fieldType := entry.Type
 	attributes := map[string]interface{}{}
-	_ = json.Unmarshal(entry.Attributes, &attributes)
+	_ = codec.Unmarshal(entry.Attributes, &attributes)
 	fields := (*query)[fieldType]
 	if len(fields) > 0 {
 		var wrongFields []string
@@ -1208,23 +1657,44 @@ func replaceAttributes(query *map[string][]string, entry *jsonapi.Data) map[stri
 				fieldType: wrongFields,
 			}
 		}
-		bytes, _ := json.Marshal(attributes)
+		bytes, _ := codec.Marshal(attributes)
 		entry.Attributes = bytes
 	}
 
 	return nil
 }
 
+// handleError applies the registered error mappers and writes the error response
+func (api *API) handleError(err error, w http.ResponseWriter, r *http.Request) {
+	if _, ok := asHTTPError(err); !ok {
+		for _, mapper := range api.errorMappers {
+			if mapped := mapper(err); mapped != nil {
+				httpErr := *mapped
+				if httpErr.err == nil {
+					httpErr.err = err
+				}
+				err = httpErr
+				break
+			}
+		}
+	}
+
+	writeError(err, w, api.ContentType, api.jsonCodec())
+}
+
 func handleError(err error, w http.ResponseWriter, r *http.Request, contentType string) {
+	writeError(err, w, contentType, jsonapi.GetCodec())
+}
+
+func writeError(err error, w http.ResponseWriter, contentType string, codec jsonapi.Codec) {
 	log.Println(err)
-	if e, ok := err.(HTTPError); ok {
-		writeResult(w, []byte(marshalHTTPError(e)), e.status, contentType)
+	if e, ok := asHTTPError(err); ok {
+		writeResult(w, []byte(marshalHTTPError(e, codec)), e.status, contentType)
 		return
-
 	}
 
 	e := NewHTTPError(err, err.Error(), http.StatusInternalServerError)
-	writeResult(w, []byte(marshalHTTPError(e)), http.StatusInternalServerError, contentType)
+	writeResult(w, []byte(marshalHTTPError(e, codec)), http.StatusInternalServerError, contentType)
 }
 
 // TODO: this can also be replaced with a struct into that we directly json.Unmarshal
@@ -0,0 +1,96 @@
+package api2go
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// unescapedCodec does not escape HTML characters
+type unescapedCodec struct {
+	unmarshalled int
+}
+
+func (c *unescapedCodec) Marshal(v interface{}) ([]byte, error) {
+	buffer := &bytes.Buffer{}
+	encoder := json.NewEncoder(buffer)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(v); err != nil {
+		return nil, err
+	}
+
+	return bytes.TrimSuffix(buffer.Bytes(), []byte("\n")), nil
+}
+
+func (c *unescapedCodec) Unmarshal(data []byte, v interface{}) error {
+	c.unmarshalled++
+	return json.Unmarshal(data, v)
+}
+
+var _ = Describe("Test API with a custom codec", func() {
+	var (
+		api    *API
+		codec  *unescapedCodec
+		source *fixtureSource
+		rec    *httptest.ResponseRecorder
+	)
+
+	BeforeEach(func() {
+		codec = &unescapedCodec{}
+		source = &fixtureSource{map[string]*Post{
+			"1": {ID: "1", Title: "<b>Hello</b>"},
+		}, false}
+		api = NewAPIWithRouting(testPrefix, NewStaticResolver(""), newTestRouter())
+		api.AddResource(Post{}, source)
+		rec = httptest.NewRecorder()
+	})
+
+	It("escapes HTML with the default codec", func() {
+		req, err := http.NewRequest("GET", "/v1/posts/1", nil)
+		Expect(err).ToNot(HaveOccurred())
+		api.Handler().ServeHTTP(rec, req)
+		Expect(rec.Body.String()).To(ContainSubstring(`\u003cb\u003eHello`))
+	})
+
+	It("encodes responses with the codec", func() {
+		api.SetCodec(codec)
+		req, err := http.NewRequest("GET", "/v1/posts?fields[posts]=title", nil)
+		Expect(err).ToNot(HaveOccurred())
+		api.Handler().ServeHTTP(rec, req)
+		Expect(rec.Code).To(Equal(http.StatusOK))
+		Expect(rec.Body.String()).To(ContainSubstring(`"title":"<b>Hello</b>"`))
+	})
+
+	It("encodes links with the codec", func() {
+		api.SetCodec(codec)
+		req, err := http.NewRequest("GET", "/v1/posts?page[custom]=test", nil)
+		Expect(err).ToNot(HaveOccurred())
+		api.Handler().ServeHTTP(rec, req)
+		Expect(rec.Code).To(Equal(http.StatusOK))
+		Expect(rec.Body.String()).To(ContainSubstring(`"next":"/v1/posts?page[custom]=test&page[type]=next"`))
+		Expect(rec.Body.String()).ToNot(ContainSubstring(`\u0026`))
+	})
+
+	It("encodes errors with the codec", func() {
+		api.SetCodec(codec)
+		req, err := http.NewRequest("GET", "/v1/posts?fields[posts]=<nope>", nil)
+		Expect(err).ToNot(HaveOccurred())
+		api.Handler().ServeHTTP(rec, req)
+		Expect(rec.Code).To(Equal(http.StatusBadRequest))
+		Expect(rec.Body.String()).To(ContainSubstring(`Field \"<nope>\" does not exist`))
+	})
+
+	It("decodes payloads with the codec", func() {
+		api.SetCodec(codec)
+		req, err := http.NewRequest("POST", "/v1/posts", strings.NewReader(`{"data": {"type": "posts", "attributes": {"title": "New"}}}`))
+		Expect(err).ToNot(HaveOccurred())
+		api.Handler().ServeHTTP(rec, req)
+		Expect(rec.Code).To(Equal(http.StatusCreated))
+		Expect(codec.unmarshalled).To(BeNumerically(">", 0))
+	})
+})
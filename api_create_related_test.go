@@ -0,0 +1,361 @@
+package api2go
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+
+	"github.com/manyminds/api2go/jsonapi"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type Playlist struct {
+	ID      string   `json:"-"`
+	Name    string   `json:"name"`
+	SongIDs []string `json:"-"`
+}
+
+func (p Playlist) GetID() string {
+	return p.ID
+}
+
+func (p *Playlist) SetID(ID string) error {
+	p.ID = ID
+	return nil
+}
+
+func (p Playlist) GetReferences() []jsonapi.Reference {
+	return []jsonapi.Reference{{Name: "songs", Type: "songs"}}
+}
+
+func (p Playlist) GetReferencedIDs() []jsonapi.ReferenceID {
+	result := []jsonapi.ReferenceID{}
+	for _, ID := range p.SongIDs {
+		result = append(result, jsonapi.ReferenceID{ID: ID, Name: "songs", Type: "songs"})
+	}
+
+	return result
+}
+
+func (p *Playlist) SetToManyReferenceIDs(name string, IDs []string) error {
+	p.SongIDs = IDs
+	return nil
+}
+
+func (p *Playlist) AddToManyIDs(name string, IDs []string) error {
+	p.SongIDs = append(p.SongIDs, IDs...)
+	return nil
+}
+
+func (p *Playlist) DeleteToManyIDs(name string, IDs []string) error {
+	return nil
+}
+
+// Album can not edit to-many relations, so songs are linked by replacing
+// the relationship
+type Album struct {
+	ID      string   `json:"-"`
+	SongIDs []string `json:"-"`
+	CoverID string   `json:"-"`
+}
+
+func (a Album) GetID() string {
+	return a.ID
+}
+
+func (a *Album) SetID(ID string) error {
+	a.ID = ID
+	return nil
+}
+
+func (a Album) GetReferences() []jsonapi.Reference {
+	return []jsonapi.Reference{{Name: "songs", Type: "songs"}, {Name: "cover", Type: "songs"}}
+}
+
+func (a Album) GetReferencedIDs() []jsonapi.ReferenceID {
+	result := []jsonapi.ReferenceID{}
+	for _, ID := range a.SongIDs {
+		result = append(result, jsonapi.ReferenceID{ID: ID, Name: "songs", Type: "songs"})
+	}
+	if a.CoverID != "" {
+		result = append(result, jsonapi.ReferenceID{ID: a.CoverID, Name: "cover", Type: "songs"})
+	}
+
+	return result
+}
+
+func (a *Album) SetToManyReferenceIDs(name string, IDs []string) error {
+	a.SongIDs = IDs
+	return nil
+}
+
+func (a *Album) SetToOneReferenceID(name, ID string) error {
+	a.CoverID = ID
+	return nil
+}
+
+type albumSource struct {
+	albums map[string]*Album
+}
+
+func (s *albumSource) FindOne(ID string, req Request) (Responder, error) {
+	album, ok := s.albums[ID]
+	if !ok {
+		return nil, NewHTTPError(nil, "album not found", http.StatusNotFound)
+	}
+
+	return &Response{Res: *album}, nil
+}
+
+func (s *albumSource) Update(obj interface{}, req Request) (Responder, error) {
+	album := obj.(Album)
+	s.albums[album.ID] = &album
+	return &Response{Code: http.StatusNoContent}, nil
+}
+
+type Song struct {
+	ID    string `json:"-"`
+	Title string `json:"title"`
+}
+
+func (s Song) GetID() string {
+	return s.ID
+}
+
+func (s *Song) SetID(ID string) error {
+	s.ID = ID
+	return nil
+}
+
+type playlistSource struct {
+	playlists  map[string]*Playlist
+	failUpdate bool
+}
+
+func (s *playlistSource) FindOne(ID string, req Request) (Responder, error) {
+	playlist, ok := s.playlists[ID]
+	if !ok {
+		return nil, NewHTTPError(nil, "playlist not found", http.StatusNotFound)
+	}
+
+	return &Response{Res: *playlist}, nil
+}
+
+func (s *playlistSource) Create(obj interface{}, req Request) (Responder, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (s *playlistSource) Delete(ID string, req Request) (Responder, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (s *playlistSource) Update(obj interface{}, req Request) (Responder, error) {
+	if s.failUpdate {
+		return nil, errors.New("update failed")
+	}
+
+	playlist := obj.(Playlist)
+	s.playlists[playlist.ID] = &playlist
+	return &Response{Code: http.StatusNoContent}, nil
+}
+
+type songSource struct {
+	songs  map[string]*Song
+	parent *ParentReference
+}
+
+func (s *songSource) FindAll(req Request) (Responder, error) {
+	return &Response{Res: []Song{}}, nil
+}
+
+func (s *songSource) Create(obj interface{}, req Request) (Responder, error) {
+	song := obj.(Song)
+	if song.Title == "" {
+		return nil, NewHTTPError(nil, "missing title", http.StatusUnprocessableEntity)
+	}
+
+	s.parent = req.Parent
+	song.ID = strconv.Itoa(len(s.songs) + 1)
+	s.songs[song.ID] = &song
+	return &Response{Res: song, Code: http.StatusCreated}, nil
+}
+
+func (s *songSource) Delete(ID string, req Request) (Responder, error) {
+	delete(s.songs, ID)
+	return &Response{Code: http.StatusNoContent}, nil
+}
+
+// songListSource can not create songs
+type songListSource struct{}
+
+func (s songListSource) FindAll(req Request) (Responder, error) {
+	return &Response{Res: []Song{}}, nil
+}
+
+var _ = Describe("Test creating related resources", func() {
+	var (
+		api       *API
+		playlists *playlistSource
+		songs     *songSource
+		rec       *httptest.ResponseRecorder
+	)
+
+	BeforeEach(func() {
+		playlists = &playlistSource{playlists: map[string]*Playlist{
+			"1": {ID: "1", Name: "Favorites"},
+		}}
+		songs = &songSource{songs: map[string]*Song{}}
+		api = NewAPIWithRouting(testPrefix, NewStaticResolver(""), newTestRouter())
+		api.AddResource(Playlist{}, playlists)
+		api.AddResource(Song{}, songs)
+	})
+
+	send := func(method, url, body string) {
+		rec = httptest.NewRecorder()
+		req, err := http.NewRequest(method, url, strings.NewReader(body))
+		Expect(err).ToNot(HaveOccurred())
+		api.Handler().ServeHTTP(rec, req)
+	}
+
+	It("creates the resource and adds it to the relationship", func() {
+		send("POST", "/v1/playlists/1/songs", `{"data": {"type": "songs", "attributes": {"title": "Blue Monday"}}}`)
+		Expect(rec.Code).To(Equal(http.StatusCreated))
+		Expect(rec.Header().Get("Location")).To(Equal("/v1/songs/1"))
+		Expect(rec.Body.String()).To(MatchJSON(`{"data": {"type": "songs", "id": "1", "attributes": {"title": "Blue Monday"}}}`))
+		Expect(songs.parent).To(Equal(&ParentReference{Type: "playlists", ID: "1", Relationship: "songs"}))
+		Expect(playlists.playlists["1"].SongIDs).To(Equal([]string{"1"}))
+	})
+
+	It("does not create the resource if the parent does not exist", func() {
+		send("POST", "/v1/playlists/2/songs", `{"data": {"type": "songs", "attributes": {"title": "Blue Monday"}}}`)
+		Expect(rec.Code).To(Equal(http.StatusNotFound))
+		Expect(songs.songs).To(BeEmpty())
+	})
+
+	It("does not change the parent if the creation fails", func() {
+		send("POST", "/v1/playlists/1/songs", `{"data": {"type": "songs", "attributes": {"title": ""}}}`)
+		Expect(rec.Code).To(Equal(http.StatusUnprocessableEntity))
+		Expect(playlists.playlists["1"].SongIDs).To(BeEmpty())
+	})
+
+	It("registers the route", func() {
+		Expect(api.Routes()).To(ContainElement(Route{
+			Method:       "POST",
+			Path:         "/v1/playlists/:id/songs",
+			Resource:     "playlists",
+			Operation:    OperationCreateRelated,
+			Relationship: "songs",
+		}))
+	})
+
+	It("does not register the route if the related resource can not be created", func() {
+		api = NewAPIWithRouting(testPrefix, NewStaticResolver(""), newTestRouter())
+		api.AddResource(Song{}, songListSource{})
+		api.AddResource(Playlist{}, playlists)
+
+		for _, route := range api.Routes() {
+			Expect(route.Operation).ToNot(Equal(OperationCreateRelated))
+		}
+
+		send("POST", "/v1/playlists/1/songs", `{"data": {"type": "songs", "attributes": {"title": "Blue Monday"}}}`)
+		Expect(rec.Code).ToNot(Equal(http.StatusCreated))
+		Expect(playlists.playlists["1"].SongIDs).To(BeEmpty())
+	})
+
+	It("deletes the resource if the parent can not be updated", func() {
+		playlists.failUpdate = true
+		send("POST", "/v1/playlists/1/songs", `{"data": {"type": "songs", "attributes": {"title": "Blue Monday"}}}`)
+		Expect(rec.Code).To(Equal(http.StatusInternalServerError))
+		Expect(songs.songs).To(BeEmpty())
+	})
+
+	Context("with a parent that can not edit to-many relations", func() {
+		var albums *albumSource
+
+		BeforeEach(func() {
+			albums = &albumSource{albums: map[string]*Album{
+				"1": {ID: "1", SongIDs: []string{"7"}},
+			}}
+			api.AddResource(Album{}, albums)
+		})
+
+		It("replaces to-many relations", func() {
+			send("POST", "/v1/albums/1/songs", `{"data": {"type": "songs", "attributes": {"title": "Blue Monday"}}}`)
+			Expect(rec.Code).To(Equal(http.StatusCreated))
+			Expect(albums.albums["1"].SongIDs).To(Equal([]string{"7", "1"}))
+		})
+
+		It("sets to-one relations", func() {
+			send("POST", "/v1/albums/1/cover", `{"data": {"type": "songs", "attributes": {"title": "Blue Monday"}}}`)
+			Expect(rec.Code).To(Equal(http.StatusCreated))
+			Expect(songs.parent).To(Equal(&ParentReference{Type: "albums", ID: "1", Relationship: "cover"}))
+			Expect(albums.albums["1"].CoverID).To(Equal("1"))
+			Expect(albums.albums["1"].SongIDs).To(Equal([]string{"7"}))
+		})
+
+		It("registers the routes", func() {
+			Expect(api.Routes()).To(ContainElement(Route{
+				Method:       "POST",
+				Path:         "/v1/albums/:id/songs",
+				Resource:     "albums",
+				Operation:    OperationCreateRelated,
+				Relationship: "songs",
+			}))
+			Expect(api.Routes()).To(ContainElement(Route{
+				Method:       "POST",
+				Path:         "/v1/albums/:id/cover",
+				Resource:     "albums",
+				Operation:    OperationCreateRelated,
+				Relationship: "cover",
+			}))
+		})
+	})
+
+	Context("with a transaction hook", func() {
+		var (
+			calls int
+			err   error
+		)
+
+		BeforeEach(func() {
+			calls = 0
+			err = nil
+			api.SetTransactionHook(func(c APIContexter, run func() error) error {
+				calls++
+				err = run()
+				return err
+			})
+		})
+
+		It("runs the creation inside of the hook", func() {
+			send("POST", "/v1/playlists/1/songs", `{"data": {"type": "songs", "attributes": {"title": "Blue Monday"}}}`)
+			Expect(rec.Code).To(Equal(http.StatusCreated))
+			Expect(calls).To(Equal(1))
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("passes errors to the hook", func() {
+			send("POST", "/v1/playlists/1/songs", `{"data": {"type": "songs", "attributes": {"title": ""}}}`)
+			Expect(rec.Code).To(Equal(http.StatusUnprocessableEntity))
+			Expect(calls).To(Equal(1))
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("does not delete the resource if the parent can not be updated", func() {
+			playlists.failUpdate = true
+			send("POST", "/v1/playlists/1/songs", `{"data": {"type": "songs", "attributes": {"title": "Blue Monday"}}}`)
+			Expect(rec.Code).To(Equal(http.StatusInternalServerError))
+			Expect(err).To(MatchError("update failed"))
+			Expect(songs.songs).To(HaveLen(1))
+		})
+
+		It("is not used for other requests", func() {
+			send("GET", "/v1/playlists/1", "")
+			Expect(rec.Code).To(Equal(http.StatusOK))
+			Expect(calls).To(Equal(0))
+		})
+	})
+})
@@ -0,0 +1,120 @@
+package api2go
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type errorSource struct {
+	err error
+}
+
+func (s errorSource) FindOne(ID string, req Request) (Responder, error) {
+	return nil, s.err
+}
+
+func (s errorSource) Create(obj interface{}, req Request) (Responder, error) {
+	return nil, s.err
+}
+
+func (s errorSource) Delete(ID string, req Request) (Responder, error) {
+	return nil, s.err
+}
+
+func (s errorSource) Update(obj interface{}, req Request) (Responder, error) {
+	return nil, s.err
+}
+
+var _ = Describe("Test error mapping", func() {
+	var (
+		api    *API
+		rec    *httptest.ResponseRecorder
+		source *errorSource
+	)
+
+	BeforeEach(func() {
+		source = &errorSource{}
+		api = NewAPIWithRouting(testPrefix, NewStaticResolver(""), newTestRouter())
+		api.AddResource(Post{}, source)
+		rec = httptest.NewRecorder()
+	})
+
+	doRequest := func() {
+		req, err := http.NewRequest("GET", "/v1/posts/1", nil)
+		Expect(err).ToNot(HaveOccurred())
+		api.Handler().ServeHTTP(rec, req)
+	}
+
+	It("detects a wrapped HTTPError", func() {
+		source.err = fmt.Errorf("loading post: %w", NewHTTPError(nil, "post not found", http.StatusNotFound))
+		doRequest()
+		Expect(rec.Code).To(Equal(http.StatusNotFound))
+		Expect(rec.Body.String()).To(MatchJSON(`{"errors":[{"status":"404","title":"post not found"}]}`))
+	})
+
+	It("detects a HTTPError pointer", func() {
+		httpErr := NewHTTPError(nil, "forbidden", http.StatusForbidden)
+		source.err = &httpErr
+		doRequest()
+		Expect(rec.Code).To(Equal(http.StatusForbidden))
+		Expect(rec.Body.String()).To(MatchJSON(`{"errors":[{"status":"403","title":"forbidden"}]}`))
+	})
+
+	It("uses a registered error mapper", func() {
+		api.MapError(func(err error) *HTTPError {
+			if errors.Is(err, sql.ErrNoRows) {
+				httpErr := NewHTTPError(nil, "not found", http.StatusNotFound)
+				return &httpErr
+			}
+			return nil
+		})
+		source.err = fmt.Errorf("query failed: %w", sql.ErrNoRows)
+		doRequest()
+		Expect(rec.Code).To(Equal(http.StatusNotFound))
+		Expect(rec.Body.String()).To(MatchJSON(`{"errors":[{"status":"404","title":"not found"}]}`))
+	})
+
+	It("uses the first mapper that handles an error", func() {
+		api.MapError(func(err error) *HTTPError {
+			return nil
+		})
+		api.MapError(func(err error) *HTTPError {
+			httpErr := NewHTTPError(nil, "teapot", http.StatusTeapot)
+			return &httpErr
+		})
+		api.MapError(func(err error) *HTTPError {
+			httpErr := NewHTTPError(nil, "never", http.StatusBadGateway)
+			return &httpErr
+		})
+		source.err = errors.New("something")
+		doRequest()
+		Expect(rec.Code).To(Equal(http.StatusTeapot))
+	})
+
+	It("does not call mappers for HTTPErrors", func() {
+		called := false
+		api.MapError(func(err error) *HTTPError {
+			called = true
+			return nil
+		})
+		source.err = NewHTTPError(nil, "post not found", http.StatusNotFound)
+		doRequest()
+		Expect(called).To(BeFalse())
+		Expect(rec.Code).To(Equal(http.StatusNotFound))
+	})
+
+	It("falls back to 500 for unmapped errors", func() {
+		api.MapError(func(err error) *HTTPError {
+			return nil
+		})
+		source.err = errors.New("database is gone")
+		doRequest()
+		Expect(rec.Code).To(Equal(http.StatusInternalServerError))
+	})
+})
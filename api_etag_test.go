@@ -0,0 +1,208 @@
+package api2go
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type VersionedPost struct {
+	ID      string `json:"-"`
+	Title   string `json:"title"`
+	Version int    `json:"-"`
+}
+
+func (p VersionedPost) GetID() string {
+	return p.ID
+}
+
+func (p *VersionedPost) SetID(ID string) error {
+	p.ID = ID
+	return nil
+}
+
+func (p VersionedPost) GetVersion() string {
+	return "v" + strconv.Itoa(p.Version)
+}
+
+type rawVersion string
+
+func (v rawVersion) GetVersion() string {
+	return string(v)
+}
+
+type cacheResponse struct {
+	Response
+	cacheControl string
+	lastModified time.Time
+}
+
+func (r cacheResponse) CacheControl() string {
+	return r.cacheControl
+}
+
+func (r cacheResponse) LastModified() time.Time {
+	return r.lastModified
+}
+
+type versionedPostSource struct {
+	posts        map[string]*VersionedPost
+	lastModified time.Time
+}
+
+func (s *versionedPostSource) FindAll(req Request) (Responder, error) {
+	result := []VersionedPost{}
+	for _, id := range []string{"1", "2"} {
+		result = append(result, *s.posts[id])
+	}
+	return &Response{Res: result}, nil
+}
+
+func (s *versionedPostSource) FindOne(ID string, req Request) (Responder, error) {
+	post, ok := s.posts[ID]
+	if !ok {
+		return nil, NewHTTPError(nil, "post not found", http.StatusNotFound)
+	}
+
+	return &cacheResponse{
+		Response:     Response{Res: *post},
+		cacheControl: "private, max-age=60",
+		lastModified: s.lastModified,
+	}, nil
+}
+
+func (s *versionedPostSource) Update(obj interface{}, req Request) (Responder, error) {
+	post := obj.(VersionedPost)
+	post.Version++
+	s.posts[post.ID] = &post
+	return &Response{Code: http.StatusNoContent}, nil
+}
+
+func (s *versionedPostSource) Delete(ID string, req Request) (Responder, error) {
+	delete(s.posts, ID)
+	return &Response{Code: http.StatusNoContent}, nil
+}
+
+var _ = Describe("Test conditional GET requests", func() {
+	var (
+		api       *API
+		rec       *httptest.ResponseRecorder
+		source    *fixtureSource
+		versioned *versionedPostSource
+	)
+
+	BeforeEach(func() {
+		source = &fixtureSource{map[string]*Post{
+			"1": {ID: "1", Title: "Hello, World!"},
+		}, false}
+		versioned = &versionedPostSource{
+			posts: map[string]*VersionedPost{
+				"1": {ID: "1", Title: "one", Version: 1},
+				"2": {ID: "2", Title: "two", Version: 3},
+			},
+			lastModified: time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC),
+		}
+		api = NewAPIWithRouting(testPrefix, NewStaticResolver(""), newTestRouter())
+		api.AddResource(Post{}, source)
+		api.AddResource(Comment{}, &commentSource{false})
+		api.AddResource(VersionedPost{}, versioned)
+		rec = httptest.NewRecorder()
+	})
+
+	doRequest := func(path string, header http.Header) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		req, err := http.NewRequest("GET", path, nil)
+		Expect(err).ToNot(HaveOccurred())
+		for k, v := range header {
+			req.Header[k] = v
+		}
+		api.Handler().ServeHTTP(rec, req)
+		return rec
+	}
+
+	It("does not send ETags by default", func() {
+		rec = doRequest("/v1/posts/1", nil)
+		Expect(rec.Code).To(Equal(http.StatusOK))
+		Expect(rec.Header().Get("ETag")).To(BeEmpty())
+	})
+
+	Context("with enabled ETags", func() {
+		BeforeEach(func() {
+			api.SetETags(true)
+		})
+
+		for _, path := range []string{"/v1/posts", "/v1/posts/1", "/v1/posts/1/relationships/comments", "/v1/posts/1/comments"} {
+			path := path
+			It("answers with 304 for a matching If-None-Match on "+path, func() {
+				rec = doRequest(path, nil)
+				Expect(rec.Code).To(Equal(http.StatusOK))
+				etag := rec.Header().Get("ETag")
+				Expect(etag).To(MatchRegexp(`^"[0-9a-f]{40}"$`))
+
+				rec = doRequest(path, http.Header{"If-None-Match": {`"other", ` + etag}})
+				Expect(rec.Code).To(Equal(http.StatusNotModified))
+				Expect(rec.Body.Len()).To(Equal(0))
+				Expect(rec.Header().Get("ETag")).To(Equal(etag))
+			})
+		}
+
+		It("answers with 200 if the resource changed", func() {
+			rec = doRequest("/v1/posts/1", nil)
+			etag := rec.Header().Get("ETag")
+
+			source.posts["1"].Title = "Changed"
+			rec = doRequest("/v1/posts/1", http.Header{"If-None-Match": {etag}})
+			Expect(rec.Code).To(Equal(http.StatusOK))
+			Expect(rec.Header().Get("ETag")).ToNot(Equal(etag))
+		})
+
+		It("uses the version of Versioned models", func() {
+			rec = doRequest("/v1/versionedPosts/1", nil)
+			Expect(rec.Header().Get("ETag")).To(Equal(`"v1"`))
+
+			rec = doRequest("/v1/versionedPosts/1", http.Header{"If-None-Match": {`W/"v1"`}})
+			Expect(rec.Code).To(Equal(http.StatusNotModified))
+		})
+
+		It("hashes versions that are not allowed in an ETag", func() {
+			etag := computeETag(rawVersion(`v"1`), nil)
+			Expect(etag).To(MatchRegexp(`^"[0-9a-f]{40}"$`))
+			Expect(computeETag(rawVersion("v 1"), nil)).To(MatchRegexp(`^"[0-9a-f]{40}"$`))
+			Expect(computeETag(rawVersion("v 1"), nil)).ToNot(Equal(etag))
+			Expect(computeETag(rawVersion("v1"), nil)).To(Equal(`"v1"`))
+		})
+
+		It("combines the versions of collections", func() {
+			rec = doRequest("/v1/versionedPosts", nil)
+			etag := rec.Header().Get("ETag")
+			Expect(etag).ToNot(BeEmpty())
+
+			versioned.posts["2"].Version = 4
+			rec = doRequest("/v1/versionedPosts", nil)
+			Expect(rec.Header().Get("ETag")).ToNot(Equal(etag))
+		})
+
+		It("sets the caching headers of the responder", func() {
+			rec = doRequest("/v1/versionedPosts/1", nil)
+			Expect(rec.Header().Get("Cache-Control")).To(Equal("private, max-age=60"))
+			Expect(rec.Header().Get("Last-Modified")).To(Equal("Thu, 02 Jan 2020 03:04:05 GMT"))
+		})
+	})
+
+	Context("If-Modified-Since", func() {
+		It("answers with 304 if not modified", func() {
+			rec = doRequest("/v1/versionedPosts/1", http.Header{"If-Modified-Since": {"Thu, 02 Jan 2020 03:04:05 GMT"}})
+			Expect(rec.Code).To(Equal(http.StatusNotModified))
+			Expect(rec.Header().Get("Cache-Control")).To(Equal("private, max-age=60"))
+		})
+
+		It("answers with 200 if modified", func() {
+			rec = doRequest("/v1/versionedPosts/1", http.Header{"If-Modified-Since": {"Thu, 02 Jan 2020 03:04:04 GMT"}})
+			Expect(rec.Code).To(Equal(http.StatusOK))
+		})
+	})
+})
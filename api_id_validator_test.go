@@ -0,0 +1,113 @@
+package api2go
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type numericPostSource struct {
+	NumericIDs
+	*fixtureSource
+}
+
+type validatedTypedBookSource struct {
+	RegexpIDs
+	*typedBookSource
+}
+
+var _ = Describe("Test ID validation", func() {
+	var (
+		api    *API
+		source *fixtureSource
+		rec    *httptest.ResponseRecorder
+	)
+
+	BeforeEach(func() {
+		source = &fixtureSource{posts: map[string]*Post{
+			"1": {ID: "1", Title: "Hello, World!"},
+		}}
+		api = NewAPIWithRouting(testPrefix, NewStaticResolver(""), newTestRouter())
+		api.AddResource(Post{}, numericPostSource{fixtureSource: source})
+	})
+
+	send := func(method, url, body string) {
+		rec = httptest.NewRecorder()
+		req, err := http.NewRequest(method, url, strings.NewReader(body))
+		Expect(err).ToNot(HaveOccurred())
+		api.Handler().ServeHTTP(rec, req)
+	}
+
+	It("passes valid ids to the source", func() {
+		send("GET", "/v1/posts/1", "")
+		Expect(rec.Code).To(Equal(http.StatusOK))
+		Expect(rec.Body.String()).To(ContainSubstring(`"title":"Hello, World!"`))
+	})
+
+	It("answers invalid ids with 404 Not Found", func() {
+		send("GET", "/v1/posts/abc", "")
+		Expect(rec.Code).To(Equal(http.StatusNotFound))
+		Expect(rec.Header().Get("Content-Type")).To(Equal(defaultContentTypHeader))
+		Expect(rec.Body.String()).To(MatchJSON(`{"errors":[{"status":"404","title":"Not Found"}]}`))
+	})
+
+	It("does not call the source for invalid ids", func() {
+		source.posts["abc"] = &Post{ID: "abc", Title: "Invalid"}
+
+		send("PATCH", "/v1/posts/abc", `{"data": {"id": "abc", "type": "posts", "attributes": {"title": "Changed"}}}`)
+		Expect(rec.Code).To(Equal(http.StatusNotFound))
+
+		send("DELETE", "/v1/posts/abc", "")
+		Expect(rec.Code).To(Equal(http.StatusNotFound))
+		Expect(source.posts["abc"].Title).To(Equal("Invalid"))
+	})
+
+	It("validates the ids of relationship routes", func() {
+		send("GET", "/v1/posts/abc/author", "")
+		Expect(rec.Code).To(Equal(http.StatusNotFound))
+
+		send("GET", "/v1/posts/abc/relationships/comments", "")
+		Expect(rec.Code).To(Equal(http.StatusNotFound))
+
+		send("GET", "/v1/posts/1/relationships/comments", "")
+		Expect(rec.Code).To(Equal(http.StatusOK))
+	})
+
+	It("does not affect collection routes", func() {
+		send("GET", "/v1/posts", "")
+		Expect(rec.Code).To(Equal(http.StatusOK))
+	})
+
+	It("uses the validator of typed sources", func() {
+		books := &typedBookSource{books: map[string]*TaggedBook{
+			"1": {ID: "1", Title: "Go"},
+		}}
+		AddTypedResource[*TaggedBook](api, validatedTypedBookSource{
+			RegexpIDs:       RegexpIDs{Pattern: regexp.MustCompile(`^[0-9]$`)},
+			typedBookSource: books,
+		})
+
+		send("GET", "/v1/books/1", "")
+		Expect(rec.Code).To(Equal(http.StatusOK))
+
+		send("GET", "/v1/books/12", "")
+		Expect(rec.Code).To(Equal(http.StatusNotFound))
+	})
+
+	Context("the bundled validators", func() {
+		It("accepts only UUIDs", func() {
+			Expect(UUIDIDs{}.ValidID("0b0f5a9e-5a43-4c3c-9c1e-3f0a3c0f2a11")).To(BeTrue())
+			Expect(UUIDIDs{}.ValidID("0b0f5a9e")).To(BeFalse())
+		})
+
+		It("accepts only decimal numbers", func() {
+			Expect(NumericIDs{}.ValidID("42")).To(BeTrue())
+			Expect(NumericIDs{}.ValidID("")).To(BeFalse())
+			Expect(NumericIDs{}.ValidID("-1")).To(BeFalse())
+		})
+	})
+})
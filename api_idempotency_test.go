@@ -0,0 +1,156 @@
+package api2go
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type failingIdempotencyStore struct {
+	IdempotencyStore
+}
+
+func (s failingIdempotencyStore) Set(key string, response IdempotentResponse) error {
+	return errors.New("store is not available")
+}
+
+var _ = Describe("Test Idempotency-Key handling", func() {
+	var (
+		api    *API
+		source *fixtureSource
+	)
+
+	BeforeEach(func() {
+		source = &fixtureSource{map[string]*Post{
+			"1": {ID: "1", Title: "Hello, World!"},
+		}, false}
+		api = NewAPIWithRouting(testPrefix, NewStaticResolver(""), newTestRouter())
+		api.AddResource(Post{}, source)
+	})
+
+	doRequest := func(method, path, body, key string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		req, err := http.NewRequest(method, path, strings.NewReader(body))
+		Expect(err).ToNot(HaveOccurred())
+		if key != "" {
+			req.Header.Set("Idempotency-Key", key)
+		}
+		api.Handler().ServeHTTP(rec, req)
+		return rec
+	}
+
+	createPost := `{"data": {"type": "posts", "attributes": {"title": "New Post"}}}`
+
+	It("ignores the header if no store is set", func() {
+		doRequest("POST", "/v1/posts", createPost, "abc")
+		doRequest("POST", "/v1/posts", createPost, "abc")
+		Expect(source.posts).To(HaveLen(3))
+	})
+
+	Context("with a store", func() {
+		BeforeEach(func() {
+			api.SetIdempotencyStore(NewMemoryIdempotencyStore(time.Minute))
+		})
+
+		It("creates only once and replays the response", func() {
+			first := doRequest("POST", "/v1/posts", createPost, "abc")
+			Expect(first.Code).To(Equal(http.StatusCreated))
+
+			second := doRequest("POST", "/v1/posts", createPost, "abc")
+			Expect(second.Code).To(Equal(http.StatusCreated))
+			Expect(second.Body.String()).To(Equal(first.Body.String()))
+			Expect(second.Header().Get("Location")).To(Equal("/v1/posts/2"))
+			Expect(second.Header().Get("Content-Type")).To(Equal(defaultContentTypHeader))
+			Expect(source.posts).To(HaveLen(2))
+		})
+
+		It("creates again for different keys or without a key", func() {
+			doRequest("POST", "/v1/posts", createPost, "abc")
+			doRequest("POST", "/v1/posts", createPost, "def")
+			doRequest("POST", "/v1/posts", createPost, "")
+			Expect(source.posts).To(HaveLen(4))
+		})
+
+		It("rejects a different payload for the same key with 422", func() {
+			doRequest("POST", "/v1/posts", createPost, "abc")
+			rec := doRequest("POST", "/v1/posts", `{"data": {"type": "posts", "attributes": {"title": "Other"}}}`, "abc")
+			Expect(rec.Code).To(Equal(http.StatusUnprocessableEntity))
+			Expect(source.posts).To(HaveLen(2))
+		})
+
+		It("replays error responses", func() {
+			first := doRequest("POST", "/v1/posts", `{"data": {"type": "posts", "attributes": {"title": ""}}}`, "abc")
+			Expect(first.Code).To(Equal(http.StatusBadRequest))
+			second := doRequest("POST", "/v1/posts", `{"data": {"type": "posts", "attributes": {"title": ""}}}`, "abc")
+			Expect(second.Code).To(Equal(http.StatusBadRequest))
+			Expect(second.Body.String()).To(Equal(first.Body.String()))
+		})
+
+		It("rejects concurrent requests with the same key", func() {
+			Expect(api.lockIdempotencyKey("POST /v1/posts abc")).To(BeTrue())
+			rec := doRequest("POST", "/v1/posts", createPost, "abc")
+			Expect(rec.Code).To(Equal(http.StatusConflict))
+			api.unlockIdempotencyKey("POST /v1/posts abc")
+
+			rec = doRequest("POST", "/v1/posts", createPost, "abc")
+			Expect(rec.Code).To(Equal(http.StatusCreated))
+		})
+
+		It("does not replay headers of middlewares", func() {
+			requests := 0
+			api.UseMiddleware(func(c APIContexter, w http.ResponseWriter, r *http.Request) {
+				requests++
+				w.Header().Set("X-Request-Number", strconv.Itoa(requests))
+			})
+
+			first := doRequest("POST", "/v1/posts", createPost, "abc")
+			Expect(first.Header().Get("X-Request-Number")).To(Equal("1"))
+
+			second := doRequest("POST", "/v1/posts", createPost, "abc")
+			Expect(second.Code).To(Equal(http.StatusCreated))
+			Expect(second.Header().Get("Location")).To(Equal("/v1/posts/2"))
+			Expect(second.Header()["X-Request-Number"]).To(Equal([]string{"2"}))
+		})
+
+		It("keeps the response if it can not be stored", func() {
+			api.SetIdempotencyStore(failingIdempotencyStore{NewMemoryIdempotencyStore(time.Minute)})
+
+			rec := doRequest("POST", "/v1/posts", createPost, "abc")
+			Expect(rec.Code).To(Equal(http.StatusCreated))
+			Expect(rec.Body.String()).ToNot(ContainSubstring("errors"))
+			Expect(source.posts).To(HaveLen(2))
+		})
+
+		It("handles relationship POST routes", func() {
+			body := `{"data": [{"type": "comments", "id": "2"}]}`
+			rec := doRequest("POST", "/v1/posts/1/relationships/comments", body, "abc")
+			Expect(rec.Code).To(Equal(http.StatusNoContent))
+			rec = doRequest("POST", "/v1/posts/1/relationships/comments", body, "abc")
+			Expect(rec.Code).To(Equal(http.StatusNoContent))
+			Expect(source.posts["1"].Comments).To(Equal([]Comment{{ID: "2"}}))
+		})
+	})
+
+	Context("memory store", func() {
+		It("forgets responses after the ttl", func() {
+			store := NewMemoryIdempotencyStore(10 * time.Millisecond)
+			Expect(store.Set("key", IdempotentResponse{StatusCode: http.StatusCreated})).To(Succeed())
+
+			response, ok, err := store.Get("key")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(ok).To(BeTrue())
+			Expect(response.StatusCode).To(Equal(http.StatusCreated))
+
+			time.Sleep(20 * time.Millisecond)
+			_, ok, err = store.Get("key")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(ok).To(BeFalse())
+		})
+	})
+})
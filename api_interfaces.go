@@ -2,6 +2,7 @@ package api2go
 
 import (
 	"net/http"
+	"time"
 
 	"github.com/manyminds/api2go/jsonapi"
 )
@@ -76,6 +77,22 @@ type FindAll interface {
 	FindAll(req Request) (Responder, error)
 }
 
+// The StreamingFindAll interface can be optionally implemented to stream large
+// collections. Every element returned by the iterator is written to the response
+// right away, instead of building the whole document in memory. It is used for
+// all unpaginated requests and takes precedence over FindAll.
+type StreamingFindAll interface {
+	StreamFindAll(req Request) (ResultIterator, error)
+}
+
+// A ResultIterator returns the elements of a streamed collection one by one.
+type ResultIterator interface {
+	// Next returns the next element, ok is false if there are no more elements
+	Next() (element jsonapi.MarshalIdentifier, ok bool, err error)
+	// Close is always called after the response has been written
+	Close() error
+}
+
 // The ObjectInitializer interface can be implemented to have the ability to change
 // a created object before Unmarshal is called. This is currently only called on
 // Create as the other actions go through FindOne or FindAll which are already
@@ -123,3 +140,27 @@ type LinksResponder interface {
 	Responder
 	Links(*http.Request, string) jsonapi.Links
 }
+
+// The CacheResponder interface may be used when the response of a GET request
+// should carry caching headers. An empty CacheControl or a zero LastModified
+// omits the corresponding header.
+type CacheResponder interface {
+	Responder
+	CacheControl() string
+	LastModified() time.Time
+}
+
+// The Versioned interface can be optionally implemented by a model to provide
+// its current version, e.g. a revision number or an update timestamp. If ETags
+// are enabled, the version is used as ETag instead of a hash of the response.
+type Versioned interface {
+	GetVersion() string
+}
+
+// The IDValidator interface can be optionally implemented by a source to
+// restrict the format of its IDs. It is checked for every generated route with
+// an `:id` before the source is called, invalid IDs are answered with 404 Not
+// Found. UUIDIDs, NumericIDs and RegexpIDs can be embedded into a source.
+type IDValidator interface {
+	ValidID(id string) bool
+}
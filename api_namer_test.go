@@ -0,0 +1,318 @@
+package api2go
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/manyminds/api2go/jsonapi"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type TaggedShelf struct {
+	ID       string   `jsonapi:"primary,shelves"`
+	Name     string   `jsonapi:"attr,shelf_name"`
+	Location string   `jsonapi:"attr,location"`
+	BookIDs  []string `jsonapi:"relation,favorite_books,books"`
+}
+
+type taggedShelfSource struct {
+	shelves map[string]*TaggedShelf
+}
+
+func (s *taggedShelfSource) FindAll(req Request) ([]*TaggedShelf, error) {
+	var result []*TaggedShelf
+	for _, shelf := range s.shelves {
+		result = append(result, shelf)
+	}
+
+	return result, nil
+}
+
+func (s *taggedShelfSource) FindOne(ID string, req Request) (*TaggedShelf, error) {
+	shelf, ok := s.shelves[ID]
+	if !ok {
+		return nil, NewHTTPError(nil, "shelf not found", http.StatusNotFound)
+	}
+
+	return shelf, nil
+}
+
+func (s *taggedShelfSource) Create(obj *TaggedShelf, req Request) (*TaggedShelf, error) {
+	obj.ID = "2"
+	s.shelves[obj.ID] = obj
+
+	return obj, nil
+}
+
+func (s *taggedShelfSource) Update(obj *TaggedShelf, req Request) (*TaggedShelf, error) {
+	s.shelves[obj.ID] = obj
+
+	return obj, nil
+}
+
+func (s *taggedShelfSource) Delete(ID string, req Request) error {
+	delete(s.shelves, ID)
+	return nil
+}
+
+type Shrub struct {
+	ID   string `json:"-"`
+	Name string `json:"name"`
+}
+
+func (s Shrub) GetID() string {
+	return s.ID
+}
+
+func (s *Shrub) SetID(ID string) error {
+	s.ID = ID
+	return nil
+}
+
+type shrubSource struct {
+	shrubs map[string]Shrub
+}
+
+func (s *shrubSource) FindAll(req Request) ([]Shrub, error) {
+	var result []Shrub
+	for _, shrub := range s.shrubs {
+		result = append(result, shrub)
+	}
+
+	return result, nil
+}
+
+func (s *shrubSource) FindOne(ID string, req Request) (Shrub, error) {
+	shrub, ok := s.shrubs[ID]
+	if !ok {
+		return shrub, NewHTTPError(nil, "shrub not found", http.StatusNotFound)
+	}
+
+	return shrub, nil
+}
+
+func (s *shrubSource) Create(obj Shrub, req Request) (Shrub, error) {
+	obj.ID = "2"
+	s.shrubs[obj.ID] = obj
+
+	return obj, nil
+}
+
+func (s *shrubSource) Update(obj Shrub, req Request) (Shrub, error) {
+	s.shrubs[obj.ID] = obj
+
+	return obj, nil
+}
+
+func (s *shrubSource) Delete(ID string, req Request) error {
+	delete(s.shrubs, ID)
+	return nil
+}
+
+type Hedge struct {
+	ID       string   `jsonapi:"primary,hedges"`
+	ShrubIDs []string `jsonapi:"relation,shrubs"`
+}
+
+type hedgeSource struct{}
+
+func (s hedgeSource) FindOne(ID string, req Request) (Responder, error) {
+	return &Response{Res: Hedge{ID: ID, ShrubIDs: []string{"1"}}}, nil
+}
+
+var _ = Describe("Test API with its own type namer", func() {
+	var (
+		api    *API
+		source *shrubSource
+		rec    *httptest.ResponseRecorder
+	)
+
+	BeforeEach(func() {
+		source = &shrubSource{shrubs: map[string]Shrub{"1": {ID: "1", Name: "Boxwood"}}}
+		api = NewAPIWithRouting(testPrefix, NewStaticResolver(""), newTestRouter())
+		api.SetTypeNamer(jsonapi.TypeNamerFunc(func(name string) string {
+			return "garden-" + jsonapi.PluralTypeNames.TypeName(name)
+		}))
+		AddTypedResource[Shrub](api, source)
+		api.AddResource(Hedge{}, hedgeSource{})
+		rec = httptest.NewRecorder()
+	})
+
+	send := func(method, url, body string) {
+		req, err := http.NewRequest(method, url, strings.NewReader(body))
+		Expect(err).ToNot(HaveOccurred())
+		api.Handler().ServeHTTP(rec, req)
+	}
+
+	It("uses the type name for routes and documents", func() {
+		send("GET", "/v1/garden-shrubs/1", "")
+		Expect(rec.Code).To(Equal(http.StatusOK))
+		Expect(rec.Body.String()).To(MatchJSON(`{
+			"data": {"type": "garden-shrubs", "id": "1", "attributes": {"name": "Boxwood"}}
+		}`))
+	})
+
+	It("uses the type name for relations of struct tags", func() {
+		send("GET", "/v1/hedges/1", "")
+		Expect(rec.Code).To(Equal(http.StatusOK))
+		Expect(rec.Body.String()).To(MatchJSON(`{
+			"data": {
+				"type": "hedges",
+				"id": "1",
+				"attributes": {},
+				"relationships": {
+					"shrubs": {
+						"links": {
+							"self": "/v1/hedges/1/relationships/shrubs",
+							"related": "/v1/hedges/1/shrubs"
+						},
+						"data": [{"type": "garden-shrubs", "id": "1"}]
+					}
+				}
+			}
+		}`))
+	})
+
+	It("checks the type name of payloads", func() {
+		send("POST", "/v1/garden-shrubs", `{"data": {"type": "shrubs", "attributes": {"name": "Holly"}}}`)
+		Expect(rec.Code).ToNot(Equal(http.StatusCreated))
+
+		rec = httptest.NewRecorder()
+		send("POST", "/v1/garden-shrubs", `{"data": {"type": "garden-shrubs", "attributes": {"name": "Holly"}}}`)
+		Expect(rec.Code).To(Equal(http.StatusCreated))
+		Expect(source.shrubs["2"]).To(Equal(Shrub{ID: "2", Name: "Holly"}))
+	})
+
+	It("keeps the type namer if a codec is set afterwards", func() {
+		api.SetCodec(&unescapedCodec{})
+		send("GET", "/v1/garden-shrubs/1", "")
+		Expect(rec.Code).To(Equal(http.StatusOK))
+		Expect(rec.Body.String()).To(ContainSubstring(`"type":"garden-shrubs"`))
+
+		rec = httptest.NewRecorder()
+		send("POST", "/v1/garden-shrubs", `{"data": {"type": "garden-shrubs", "attributes": {"name": "Holly"}}}`)
+		Expect(rec.Code).To(Equal(http.StatusCreated))
+	})
+
+	It("does not change the type names of other APIs", func() {
+		other := NewAPIWithRouting(testPrefix, NewStaticResolver(""), newTestRouter())
+		AddTypedResource[Shrub](other, source)
+
+		req, err := http.NewRequest("GET", "/v1/shrubs/1", nil)
+		Expect(err).ToNot(HaveOccurred())
+		other.Handler().ServeHTTP(rec, req)
+		Expect(rec.Code).To(Equal(http.StatusOK))
+		Expect(rec.Body.String()).To(ContainSubstring(`"type":"shrubs"`))
+	})
+})
+
+var _ = Describe("Test API with a type namer", func() {
+	var (
+		api    *API
+		source *shrubSource
+		rec    *httptest.ResponseRecorder
+	)
+
+	BeforeEach(func() {
+		jsonapi.SetTypeNamer(jsonapi.SingularTypeNames)
+		source = &shrubSource{shrubs: map[string]Shrub{"1": {ID: "1", Name: "Boxwood"}}}
+		api = NewAPIWithRouting(testPrefix, NewStaticResolver(""), newTestRouter())
+		AddTypedResource[Shrub](api, source)
+		rec = httptest.NewRecorder()
+	})
+
+	AfterEach(func() {
+		jsonapi.SetTypeNamer(nil)
+	})
+
+	It("uses the type name for routes and documents", func() {
+		req, err := http.NewRequest("GET", "/v1/shrub/1", nil)
+		Expect(err).ToNot(HaveOccurred())
+		api.Handler().ServeHTTP(rec, req)
+		Expect(rec.Code).To(Equal(http.StatusOK))
+		Expect(rec.Body.String()).To(MatchJSON(`{
+			"data": {"type": "shrub", "id": "1", "attributes": {"name": "Boxwood"}}
+		}`))
+	})
+
+	It("checks the type name of payloads", func() {
+		req, err := http.NewRequest("POST", "/v1/shrub", strings.NewReader(`{"data": {"type": "shrub", "attributes": {"name": "Holly"}}}`))
+		Expect(err).ToNot(HaveOccurred())
+		api.Handler().ServeHTTP(rec, req)
+		Expect(rec.Code).To(Equal(http.StatusCreated))
+		Expect(source.shrubs["2"]).To(Equal(Shrub{ID: "2", Name: "Holly"}))
+
+		rec = httptest.NewRecorder()
+		req, err = http.NewRequest("POST", "/v1/shrub", strings.NewReader(`{"data": {"type": "shrubs", "attributes": {"name": "Holly"}}}`))
+		Expect(err).ToNot(HaveOccurred())
+		api.Handler().ServeHTTP(rec, req)
+		Expect(rec.Code).ToNot(Equal(http.StatusCreated))
+	})
+})
+
+var _ = Describe("Test API with a member namer", func() {
+	var (
+		api    *API
+		source *taggedShelfSource
+		rec    *httptest.ResponseRecorder
+	)
+
+	BeforeEach(func() {
+		jsonapi.SetMemberNamer(jsonapi.KebabCase)
+		source = &taggedShelfSource{shelves: map[string]*TaggedShelf{
+			"1": {ID: "1", Name: "Fiction", Location: "Hall", BookIDs: []string{"1"}},
+		}}
+		api = NewAPIWithRouting(testPrefix, NewStaticResolver(""), newTestRouter())
+		AddTypedResource[*TaggedShelf](api, source)
+		rec = httptest.NewRecorder()
+	})
+
+	AfterEach(func() {
+		jsonapi.SetMemberNamer(nil)
+	})
+
+	It("uses the member names for sparse fieldsets", func() {
+		req, err := http.NewRequest("GET", "/v1/shelves/1?fields[shelves]=shelf-name", nil)
+		Expect(err).ToNot(HaveOccurred())
+		api.Handler().ServeHTTP(rec, req)
+		Expect(rec.Code).To(Equal(http.StatusOK))
+		Expect(rec.Body.String()).To(MatchJSON(`{
+			"data": {
+				"type": "shelves",
+				"id": "1",
+				"attributes": {"shelf-name": "Fiction"},
+				"relationships": {
+					"favorite-books": {
+						"links": {
+							"self": "/v1/shelves/1/relationships/favorite-books",
+							"related": "/v1/shelves/1/favorite-books"
+						},
+						"data": [{"type": "books", "id": "1"}]
+					}
+				}
+			}
+		}`))
+	})
+
+	It("unmarshals the member names", func() {
+		req, err := http.NewRequest("POST", "/v1/shelves", strings.NewReader(`{"data": {
+			"type": "shelves",
+			"attributes": {"shelf-name": "New"},
+			"relationships": {"favorite-books": {"data": [{"type": "books", "id": "3"}]}}
+		}}`))
+		Expect(err).ToNot(HaveOccurred())
+		api.Handler().ServeHTTP(rec, req)
+		Expect(rec.Code).To(Equal(http.StatusCreated))
+		Expect(source.shelves["2"]).To(Equal(&TaggedShelf{ID: "2", Name: "New", BookIDs: []string{"3"}}))
+	})
+
+	It("uses the member names for relationship routes", func() {
+		req, err := http.NewRequest("POST", "/v1/shelves/1/relationships/favorite-books", strings.NewReader(`{"data": [{"type": "books", "id": "2"}]}`))
+		Expect(err).ToNot(HaveOccurred())
+		api.Handler().ServeHTTP(rec, req)
+		Expect(rec.Code).To(Equal(http.StatusNoContent))
+		Expect(source.shelves["1"].BookIDs).To(Equal([]string{"1", "2"}))
+	})
+})
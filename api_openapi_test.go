@@ -0,0 +1,234 @@
+package api2go
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type OpenAPIEvent struct {
+	ID        string         `json:"-"`
+	Name      string         `json:"name"`
+	StartsAt  time.Time      `json:"starts_at"`
+	Note      *string        `json:"note,omitempty"`
+	Seats     uint           `json:"seats"`
+	Tags      []string       `json:"tags"`
+	Counts    map[string]int `json:"counts"`
+	Location  OpenAPIPlace   `json:"location"`
+	Signature []byte         `json:"signature"`
+}
+
+type OpenAPIPlace struct {
+	City   string        `json:"city"`
+	Parent *OpenAPIPlace `json:"parent"`
+}
+
+func (e OpenAPIEvent) GetID() string {
+	return e.ID
+}
+
+func (e *OpenAPIEvent) SetID(ID string) error {
+	e.ID = ID
+	return nil
+}
+
+type openAPIEventSource struct{}
+
+func (s openAPIEventSource) FindOne(ID string, req Request) (Responder, error) {
+	return &Response{Res: OpenAPIEvent{ID: ID}}, nil
+}
+
+// openAPIJSON returns the JSON encoding of a part of an OpenAPI document
+func openAPIJSON(document map[string]interface{}, path ...string) string {
+	var value interface{} = document
+	for _, key := range path {
+		object, ok := value.(map[string]interface{})
+		Expect(ok).To(BeTrue(), "%s is not an object", key)
+		Expect(object).To(HaveKey(key))
+		value = object[key]
+	}
+
+	result, err := json.Marshal(value)
+	Expect(err).ToNot(HaveOccurred())
+	return string(result)
+}
+
+var _ = Describe("Test OpenAPI generation", func() {
+	var (
+		api      *API
+		document map[string]interface{}
+	)
+
+	BeforeEach(func() {
+		api = NewAPIWithRouting(testPrefix, NewStaticResolver("http://localhost:8080"), newTestRouter())
+		api.AddResource(Post{}, &fixtureSource{posts: map[string]*Post{}})
+		api.AddResource(User{}, &userSource{})
+		api.AddResource(OpenAPIEvent{}, openAPIEventSource{})
+		document = api.OpenAPI()
+	})
+
+	It("describes the API", func() {
+		Expect(document).To(HaveKeyWithValue("openapi", "3.1.0"))
+		Expect(openAPIJSON(document, "servers")).To(MatchJSON(`[{"url": "http://localhost:8080"}]`))
+		Expect(openAPIJSON(document, "components", "responses", "Error")).To(MatchJSON(`{
+			"description": "The errors that occurred",
+			"content": {"application/vnd.api+json": {"schema": {"$ref": "#/components/schemas/ErrorDocument"}}}
+		}`))
+	})
+
+	It("generates the routes of the implemented interfaces", func() {
+		paths := document["paths"].(map[string]interface{})
+		Expect(paths).To(HaveKey("/v1/posts"))
+		Expect(paths["/v1/posts"]).To(HaveKey("get"))
+		Expect(paths["/v1/posts"]).To(HaveKey("post"))
+		Expect(paths["/v1/posts/{id}"]).To(HaveKey("get"))
+		Expect(paths["/v1/posts/{id}"]).To(HaveKey("patch"))
+		Expect(paths["/v1/posts/{id}"]).To(HaveKey("delete"))
+
+		Expect(paths).ToNot(HaveKey("/v1/openAPIEvents"))
+		Expect(paths["/v1/openAPIEvents/{id}"]).To(HaveKey("get"))
+		Expect(paths["/v1/openAPIEvents/{id}"]).ToNot(HaveKey("patch"))
+
+		Expect(openAPIJSON(document, "paths", "/v1/openAPIEvents/{id}", "get")).To(MatchJSON(`{
+			"operationId": "openAPIEvents.get",
+			"summary": "Get a resource of openAPIEvents",
+			"tags": ["openAPIEvents"],
+			"parameters": [{"$ref": "#/components/parameters/fields"}],
+			"responses": {
+				"200": {
+					"description": "The resource",
+					"content": {"application/vnd.api+json": {"schema": {"$ref": "#/components/schemas/openAPIEvents.Document"}}}
+				},
+				"default": {"$ref": "#/components/responses/Error"}
+			}
+		}`))
+	})
+
+	It("adds the pagination parameters for PaginatedFindAll", func() {
+		Expect(openAPIJSON(document, "paths", "/v1/posts", "get", "parameters")).To(MatchJSON(`[
+			{"$ref": "#/components/parameters/fields"},
+			{"$ref": "#/components/parameters/page.number"},
+			{"$ref": "#/components/parameters/page.size"},
+			{"$ref": "#/components/parameters/page.offset"},
+			{"$ref": "#/components/parameters/page.limit"}
+		]`))
+		Expect(openAPIJSON(document, "components", "parameters", "page.size")).To(MatchJSON(`{
+			"name": "page[size]",
+			"in": "query",
+			"schema": {"type": "integer", "minimum": 0}
+		}`))
+	})
+
+	It("describes the creation of related resources", func() {
+		api := NewAPIWithRouting(testPrefix, NewStaticResolver(""), newTestRouter())
+		api.AddResource(Playlist{}, &playlistSource{})
+		api.AddResource(Song{}, &songSource{})
+
+		songs := openAPIJSON(api.OpenAPI(), "paths", "/v1/playlists/{id}/songs")
+		Expect(songs).To(ContainSubstring(`"get"`))
+		Expect(openAPIJSON(api.OpenAPI(), "paths", "/v1/playlists/{id}/songs", "post", "requestBody")).To(MatchJSON(`{
+			"required": true,
+			"content": {"application/vnd.api+json": {"schema": {"$ref": "#/components/schemas/songs.CreateRequest"}}}
+		}`))
+	})
+
+	It("generates the relationship routes", func() {
+		paths := document["paths"].(map[string]interface{})
+		Expect(paths["/v1/posts/{id}/relationships/author"]).To(HaveKey("get"))
+		Expect(paths["/v1/posts/{id}/relationships/author"]).To(HaveKey("patch"))
+		Expect(paths["/v1/posts/{id}/relationships/author"]).ToNot(HaveKey("post"))
+		Expect(paths["/v1/posts/{id}/relationships/comments"]).To(HaveKey("post"))
+		Expect(paths["/v1/posts/{id}/relationships/comments"]).To(HaveKey("delete"))
+
+		// only related resources that are registered can be fetched
+		Expect(paths).To(HaveKey("/v1/posts/{id}/author"))
+		Expect(paths).ToNot(HaveKey("/v1/posts/{id}/comments"))
+
+		Expect(openAPIJSON(document, "paths", "/v1/posts/{id}/relationships/comments", "post", "requestBody")).To(MatchJSON(`{
+			"required": true,
+			"content": {"application/vnd.api+json": {"schema": {"$ref": "#/components/schemas/ToManyRelationship"}}}
+		}`))
+		Expect(openAPIJSON(document, "components", "schemas", "posts.Relationships")).To(MatchJSON(`{
+			"type": "object",
+			"properties": {
+				"author": {"$ref": "#/components/schemas/ToOneRelationship"},
+				"comments": {"$ref": "#/components/schemas/ToManyRelationship"},
+				"bananas": {"$ref": "#/components/schemas/ToManyRelationship"}
+			}
+		}`))
+	})
+
+	It("derives the attributes from the struct fields", func() {
+		Expect(openAPIJSON(document, "components", "schemas", "openAPIEvents.Attributes")).To(MatchJSON(`{
+			"type": "object",
+			"properties": {
+				"name": {"type": "string"},
+				"starts_at": {"type": "string", "format": "date-time"},
+				"note": {"type": ["string", "null"]},
+				"seats": {"type": "integer", "format": "int64", "minimum": 0},
+				"tags": {"type": ["array", "null"], "items": {"type": "string"}},
+				"counts": {"type": ["object", "null"], "additionalProperties": {"type": "integer", "format": "int64"}},
+				"location": {
+					"type": "object",
+					"properties": {
+						"city": {"type": "string"},
+						"parent": {}
+					}
+				},
+				"signature": {"type": "string", "contentEncoding": "base64"}
+			}
+		}`))
+	})
+
+	It("describes the resource and request documents", func() {
+		Expect(openAPIJSON(document, "components", "schemas", "users.Resource")).To(MatchJSON(`{
+			"type": "object",
+			"required": ["type", "id"],
+			"properties": {
+				"type": {"type": "string", "const": "users"},
+				"id": {"type": "string"},
+				"attributes": {"$ref": "#/components/schemas/users.Attributes"},
+				"links": {"$ref": "#/components/schemas/Links"},
+				"meta": {"$ref": "#/components/schemas/Meta"}
+			}
+		}`))
+		Expect(openAPIJSON(document, "components", "schemas", "users.CreateRequest")).To(MatchJSON(`{
+			"type": "object",
+			"required": ["data"],
+			"properties": {
+				"data": {
+					"type": "object",
+					"required": ["type"],
+					"properties": {
+						"type": {"type": "string", "const": "users"},
+						"id": {"type": "string"},
+						"attributes": {"$ref": "#/components/schemas/users.Attributes"}
+					}
+				}
+			}
+		}`))
+		Expect(openAPIJSON(document, "components", "schemas", "users.CollectionDocument", "properties", "data")).To(MatchJSON(`{
+			"type": "array",
+			"items": {"$ref": "#/components/schemas/users.Resource"}
+		}`))
+	})
+
+	It("serves the document", func() {
+		api.AddOpenAPIRoute("openapi.json")
+		rec := httptest.NewRecorder()
+		req, err := http.NewRequest("GET", "/v1/openapi.json", nil)
+		Expect(err).ToNot(HaveOccurred())
+		api.Handler().ServeHTTP(rec, req)
+		Expect(rec.Code).To(Equal(http.StatusOK))
+		Expect(rec.Header().Get("Content-Type")).To(Equal("application/json"))
+
+		var served map[string]interface{}
+		Expect(json.Unmarshal(rec.Body.Bytes(), &served)).To(Succeed())
+		Expect(served).To(HaveKeyWithValue("openapi", "3.1.0"))
+		Expect(served["paths"]).To(HaveKey("/v1/users/{id}"))
+	})
+})
@@ -0,0 +1,96 @@
+package api2go
+
+import (
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type panicSource struct{}
+
+func (s panicSource) FindOne(ID string, req Request) (Responder, error) {
+	if ID == "abort" {
+		panic(http.ErrAbortHandler)
+	}
+
+	panic("FindOne exploded")
+}
+
+var _ = Describe("Test panic recovery", func() {
+	var (
+		api *API
+		rec *httptest.ResponseRecorder
+	)
+
+	BeforeEach(func() {
+		api = NewAPIWithRouting(testPrefix, NewStaticResolver(""), newTestRouter())
+		api.ContentType = "application/test+json"
+		api.AddResource(Post{}, panicSource{})
+		rec = httptest.NewRecorder()
+	})
+
+	It("answers with a jsonapi error document", func() {
+		req, err := http.NewRequest("GET", "/v1/posts/1", nil)
+		Expect(err).ToNot(HaveOccurred())
+		api.Handler().ServeHTTP(rec, req)
+		Expect(rec.Code).To(Equal(http.StatusInternalServerError))
+		Expect(rec.Header().Get("Content-Type")).To(Equal("application/test+json"))
+		Expect(rec.Body.String()).To(MatchJSON(`{"errors":[{"status":"500","title":"Internal Server Error"}]}`))
+	})
+
+	It("reports the panic to the panic handler", func() {
+		var (
+			recovered interface{}
+			context   APIContexter
+		)
+		api.SetPanicHandler(func(c APIContexter, r *http.Request, v interface{}) {
+			context = c
+			recovered = v
+		})
+		api.UseMiddleware(func(c APIContexter, w http.ResponseWriter, r *http.Request) {
+			c.Set("user", "dieter")
+		})
+
+		req, err := http.NewRequest("GET", "/v1/posts/1", nil)
+		Expect(err).ToNot(HaveOccurred())
+		api.Handler().ServeHTTP(rec, req)
+		Expect(recovered).To(Equal("FindOne exploded"))
+		Expect(context).ToNot(BeNil())
+	})
+
+	It("recovers panics inside middlewares", func() {
+		var recovered interface{}
+		api.SetPanicHandler(func(c APIContexter, r *http.Request, v interface{}) {
+			recovered = v
+		})
+		api.UseMiddleware(func(c APIContexter, w http.ResponseWriter, r *http.Request) {
+			panic("middleware exploded")
+		})
+
+		req, err := http.NewRequest("OPTIONS", "/v1/posts", nil)
+		Expect(err).ToNot(HaveOccurred())
+		api.Handler().ServeHTTP(rec, req)
+		Expect(rec.Code).To(Equal(http.StatusInternalServerError))
+		Expect(recovered).To(Equal("middleware exploded"))
+	})
+
+	It("does not swallow http.ErrAbortHandler", func() {
+		called := false
+		api.SetPanicHandler(func(c APIContexter, r *http.Request, v interface{}) {
+			called = true
+		})
+
+		req, err := http.NewRequest("GET", "/v1/posts/abort", nil)
+		Expect(err).ToNot(HaveOccurred())
+		func() {
+			// some routers recover panics on their own
+			defer func() {
+				Expect(recover()).To(Or(BeNil(), Equal(http.ErrAbortHandler)))
+			}()
+			api.Handler().ServeHTTP(rec, req)
+		}()
+		Expect(called).To(BeFalse())
+	})
+})
@@ -0,0 +1,125 @@
+package api2go
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Test request payload handling", func() {
+	var (
+		api    *API
+		rec    *httptest.ResponseRecorder
+		source *fixtureSource
+	)
+
+	BeforeEach(func() {
+		source = &fixtureSource{map[string]*Post{
+			"1": {ID: "1", Title: "Hello, World!"},
+		}, false}
+		api = NewAPIWithRouting(testPrefix, NewStaticResolver(""), newTestRouter())
+		api.AddResource(Post{}, source)
+		rec = httptest.NewRecorder()
+	})
+
+	Context("body size limit", func() {
+		BeforeEach(func() {
+			api.SetMaxBodySize(64)
+		})
+
+		It("rejects too large bodies", func() {
+			reqBody := strings.NewReader(`{"data": {"type": "posts", "attributes": {"title": "` + strings.Repeat("a", 64) + `"}}}`)
+			req, err := http.NewRequest("POST", "/v1/posts", reqBody)
+			Expect(err).ToNot(HaveOccurred())
+			api.Handler().ServeHTTP(rec, req)
+			Expect(rec.Code).To(Equal(http.StatusRequestEntityTooLarge))
+			Expect(rec.Body.String()).To(MatchJSON(`{"errors":[{"status":"413","title":"Request body too large"}]}`))
+			Expect(source.posts).To(HaveLen(1))
+		})
+
+		It("accepts bodies within the limit", func() {
+			reqBody := strings.NewReader(`{"data": {"type": "posts", "attributes": {"title": "a"}}}`)
+			req, err := http.NewRequest("POST", "/v1/posts", reqBody)
+			Expect(err).ToNot(HaveOccurred())
+			api.Handler().ServeHTTP(rec, req)
+			Expect(rec.Code).To(Equal(http.StatusCreated))
+		})
+
+		It("limits relationship routes too", func() {
+			reqBody := strings.NewReader(`{"data": [{"type": "comments", "id": "` + strings.Repeat("1", 64) + `"}]}`)
+			req, err := http.NewRequest("PATCH", "/v1/posts/1/relationships/comments", reqBody)
+			Expect(err).ToNot(HaveOccurred())
+			api.Handler().ServeHTTP(rec, req)
+			Expect(rec.Code).To(Equal(http.StatusRequestEntityTooLarge))
+		})
+	})
+
+	Context("strict decoding", func() {
+		It("ignores unknown attributes by default", func() {
+			reqBody := strings.NewReader(`{"data": {"type": "posts", "attributes": {"title": "a", "unknown": 1}}}`)
+			req, err := http.NewRequest("POST", "/v1/posts", reqBody)
+			Expect(err).ToNot(HaveOccurred())
+			api.Handler().ServeHTTP(rec, req)
+			Expect(rec.Code).To(Equal(http.StatusCreated))
+		})
+
+		It("rejects unknown attributes and members", func() {
+			api.SetStrictDecoding(true)
+			reqBody := strings.NewReader(`{"data": {"type": "posts", "attributes": {"title": "a", "unknown": 1}}, "foo": 1}`)
+			req, err := http.NewRequest("POST", "/v1/posts", reqBody)
+			Expect(err).ToNot(HaveOccurred())
+			api.Handler().ServeHTTP(rec, req)
+			Expect(rec.Code).To(Equal(http.StatusBadRequest))
+			Expect(rec.Body.String()).To(MatchJSON(`{"errors":[
+				{
+					"status": "400",
+					"code": "API2GO_INVALID_PAYLOAD",
+					"title": "Invalid member in payload",
+					"detail": "unknown attribute \"unknown\"",
+					"source": {"pointer": "/data/attributes/unknown"}
+				},
+				{
+					"status": "400",
+					"code": "API2GO_INVALID_PAYLOAD",
+					"title": "Invalid member in payload",
+					"detail": "unknown top-level member \"foo\"",
+					"source": {"pointer": "/foo"}
+				}
+			]}`))
+			Expect(source.posts).To(HaveLen(1))
+		})
+
+		It("rejects unknown relationships on update", func() {
+			api.SetStrictDecoding(true)
+			reqBody := strings.NewReader(`{"data": {"type": "posts", "id": "1", "attributes": {"title": "b"}, "relationships": {"likes": {"data": []}}}}`)
+			req, err := http.NewRequest("PATCH", "/v1/posts/1", reqBody)
+			Expect(err).ToNot(HaveOccurred())
+			api.Handler().ServeHTTP(rec, req)
+			Expect(rec.Code).To(Equal(http.StatusBadRequest))
+			Expect(source.posts["1"].Title).To(Equal("Hello, World!"))
+		})
+
+		It("accepts valid payloads", func() {
+			api.SetStrictDecoding(true)
+			reqBody := strings.NewReader(`{"data": {"type": "posts", "id": "1", "attributes": {"title": "b"}}}`)
+			req, err := http.NewRequest("PATCH", "/v1/posts/1", reqBody)
+			Expect(err).ToNot(HaveOccurred())
+			api.Handler().ServeHTTP(rec, req)
+			Expect(rec.Code).To(Equal(http.StatusNoContent))
+			Expect(source.posts["1"].Title).To(Equal("b"))
+		})
+
+		It("accepts attributes in another case like the default decoding", func() {
+			api.SetStrictDecoding(true)
+			reqBody := strings.NewReader(`{"data": {"type": "posts", "id": "1", "attributes": {"Title": "c"}}}`)
+			req, err := http.NewRequest("PATCH", "/v1/posts/1", reqBody)
+			Expect(err).ToNot(HaveOccurred())
+			api.Handler().ServeHTTP(rec, req)
+			Expect(rec.Code).To(Equal(http.StatusNoContent))
+			Expect(source.posts["1"].Title).To(Equal("c"))
+		})
+	})
+})
@@ -0,0 +1,214 @@
+package api2go
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	"github.com/manyminds/api2go/jsonapi"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// deleteOnlySource can not load the current version of a comment
+type deleteOnlySource struct {
+	deleted []string
+}
+
+func (s *deleteOnlySource) Delete(id string, req Request) (Responder, error) {
+	s.deleted = append(s.deleted, id)
+	return &Response{Code: http.StatusNoContent}, nil
+}
+
+// selfLinkResponse links to the requested url including the query
+type selfLinkResponse struct {
+	Response
+}
+
+func (r selfLinkResponse) Links(req *http.Request, requestURL string) jsonapi.Links {
+	return jsonapi.Links{"self": jsonapi.Link{Href: requestURL + "?" + req.URL.RawQuery}}
+}
+
+// linkedPostSource returns posts with a self link
+type linkedPostSource struct {
+	*fixtureSource
+}
+
+func (s linkedPostSource) FindOne(id string, req Request) (Responder, error) {
+	response, err := s.fixtureSource.FindOne(id, req)
+	if err != nil {
+		return nil, err
+	}
+
+	return selfLinkResponse{*response.(*Response)}, nil
+}
+
+var _ = Describe("Test If-Match preconditions", func() {
+	var (
+		api       *API
+		source    *fixtureSource
+		versioned *versionedPostSource
+	)
+
+	BeforeEach(func() {
+		source = &fixtureSource{map[string]*Post{
+			"1": {ID: "1", Title: "Hello, World!", Comments: []Comment{{ID: "1"}}},
+		}, false}
+		versioned = &versionedPostSource{
+			posts: map[string]*VersionedPost{
+				"1": {ID: "1", Title: "one", Version: 1},
+			},
+			lastModified: time.Now(),
+		}
+		api = NewAPIWithRouting(testPrefix, NewStaticResolver(""), newTestRouter())
+		api.SetETags(true)
+		api.AddResource(Post{}, source)
+		api.AddResource(VersionedPost{}, versioned)
+	})
+
+	doRequest := func(method, path, body string, header http.Header) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		req, err := http.NewRequest(method, path, strings.NewReader(body))
+		Expect(err).ToNot(HaveOccurred())
+		for k, v := range header {
+			req.Header[k] = v
+		}
+		api.Handler().ServeHTTP(rec, req)
+		return rec
+	}
+
+	getETag := func(path string) string {
+		rec := doRequest("GET", path, "", nil)
+		Expect(rec.Code).To(Equal(http.StatusOK))
+		return rec.Header().Get("ETag")
+	}
+
+	updateVersioned := `{"data": {"type": "versionedPosts", "id": "1", "attributes": {"title": "new"}}}`
+	updatePost := `{"data": {"type": "posts", "id": "1", "attributes": {"title": "new"}}}`
+
+	It("updates if the version matches", func() {
+		rec := doRequest("PATCH", "/v1/versionedPosts/1", updateVersioned, http.Header{"If-Match": {`"v1"`}})
+		Expect(rec.Code).To(Equal(http.StatusNoContent))
+		Expect(versioned.posts["1"].Title).To(Equal("new"))
+		Expect(versioned.posts["1"].Version).To(Equal(2))
+	})
+
+	It("rejects a lost update with 412", func() {
+		rec := doRequest("PATCH", "/v1/versionedPosts/1", updateVersioned, http.Header{"If-Match": {`"v1"`}})
+		Expect(rec.Code).To(Equal(http.StatusNoContent))
+
+		rec = doRequest("PATCH", "/v1/versionedPosts/1", updateVersioned, http.Header{"If-Match": {`"v1"`}})
+		Expect(rec.Code).To(Equal(http.StatusPreconditionFailed))
+		Expect(rec.Body.String()).To(MatchJSON(`{"errors":[{"status":"412","title":"Precondition Failed","detail":"The resource has been modified in the meantime"}]}`))
+		Expect(versioned.posts["1"].Version).To(Equal(2))
+	})
+
+	It("does not accept weak ETags", func() {
+		rec := doRequest("PATCH", "/v1/versionedPosts/1", updateVersioned, http.Header{"If-Match": {`W/"v1"`}})
+		Expect(rec.Code).To(Equal(http.StatusPreconditionFailed))
+	})
+
+	It("accepts *", func() {
+		rec := doRequest("PATCH", "/v1/versionedPosts/1", updateVersioned, http.Header{"If-Match": {`*`}})
+		Expect(rec.Code).To(Equal(http.StatusNoContent))
+	})
+
+	It("compares computed ETags", func() {
+		etag := getETag("/v1/posts/1")
+		rec := doRequest("PATCH", "/v1/posts/1", updatePost, http.Header{"If-Match": {etag}})
+		Expect(rec.Code).To(Equal(http.StatusNoContent))
+
+		rec = doRequest("PATCH", "/v1/posts/1", updatePost, http.Header{"If-Match": {etag}})
+		Expect(rec.Code).To(Equal(http.StatusPreconditionFailed))
+	})
+
+	It("ignores sparse fieldsets of the GET request", func() {
+		etag := getETag("/v1/posts/1?fields[posts]=title")
+		Expect(etag).To(Equal(getETag("/v1/posts/1")))
+
+		rec := doRequest("PATCH", "/v1/posts/1?fields[posts]=value", updatePost, http.Header{"If-Match": {etag}})
+		Expect(rec.Code).To(Equal(http.StatusNoContent))
+	})
+
+	It("ignores the query of the GET request", func() {
+		api = NewAPIWithRouting(testPrefix, NewStaticResolver(""), newTestRouter())
+		api.SetETags(true)
+		api.AddResource(Post{}, linkedPostSource{source})
+
+		etag := getETag("/v1/posts/1?include=comments")
+		Expect(etag).To(Equal(getETag("/v1/posts/1")))
+
+		rec := doRequest("GET", "/v1/posts/1?include=comments", "", http.Header{"If-None-Match": {etag}})
+		Expect(rec.Code).To(Equal(http.StatusNotModified))
+
+		rec = doRequest("PATCH", "/v1/posts/1", updatePost, http.Header{"If-Match": {etag}})
+		Expect(rec.Code).To(Equal(http.StatusNoContent))
+	})
+
+	It("checks DELETE requests", func() {
+		rec := doRequest("DELETE", "/v1/versionedPosts/1", "", http.Header{"If-Match": {`"v0"`}})
+		Expect(rec.Code).To(Equal(http.StatusPreconditionFailed))
+		Expect(versioned.posts).To(HaveKey("1"))
+
+		rec = doRequest("DELETE", "/v1/versionedPosts/1", "", http.Header{"If-Match": {`"v1"`}})
+		Expect(rec.Code).To(Equal(http.StatusNoContent))
+		Expect(versioned.posts).ToNot(HaveKey("1"))
+	})
+
+	It("checks relationship routes against the relationship ETag", func() {
+		body := `{"data": [{"type": "comments", "id": "2"}]}`
+		etag := getETag("/v1/posts/1/relationships/comments")
+
+		rec := doRequest("POST", "/v1/posts/1/relationships/comments", body, http.Header{"If-Match": {`"other"`}})
+		Expect(rec.Code).To(Equal(http.StatusPreconditionFailed))
+
+		rec = doRequest("PATCH", "/v1/posts/1/relationships/comments", body, http.Header{"If-Match": {etag}})
+		Expect(rec.Code).To(Equal(http.StatusNoContent))
+		Expect(source.posts["1"].Comments).To(Equal([]Comment{{ID: "2"}}))
+
+		rec = doRequest("DELETE", "/v1/posts/1/relationships/comments", body, http.Header{"If-Match": {etag}})
+		Expect(rec.Code).To(Equal(http.StatusPreconditionFailed))
+	})
+
+	Context("with required preconditions", func() {
+		BeforeEach(func() {
+			api.SetRequirePreconditions(true)
+		})
+
+		It("rejects requests without If-Match with 428", func() {
+			for _, request := range [][]string{
+				{"PATCH", "/v1/versionedPosts/1", updateVersioned},
+				{"DELETE", "/v1/versionedPosts/1", ""},
+				{"PATCH", "/v1/posts/1/relationships/comments", `{"data": []}`},
+				{"POST", "/v1/posts/1/relationships/comments", `{"data": []}`},
+				{"DELETE", "/v1/posts/1/relationships/comments", `{"data": []}`},
+			} {
+				rec := doRequest(request[0], request[1], request[2], nil)
+				Expect(rec.Code).To(Equal(http.StatusPreconditionRequired), request[0]+" "+request[1])
+			}
+			Expect(versioned.posts["1"].Version).To(Equal(1))
+		})
+
+		It("rejects DELETE requests without If-Match for sources without FindOne", func() {
+			deleter := &deleteOnlySource{}
+			api.AddResource(Comment{}, deleter)
+
+			rec := doRequest("DELETE", "/v1/comments/1", "", nil)
+			Expect(rec.Code).To(Equal(http.StatusPreconditionRequired))
+			Expect(deleter.deleted).To(BeEmpty())
+
+			rec = doRequest("DELETE", "/v1/comments/1", "", http.Header{"If-Match": {`"v1"`}})
+			Expect(rec.Code).To(Equal(http.StatusPreconditionFailed))
+
+			rec = doRequest("DELETE", "/v1/comments/1", "", http.Header{"If-Match": {`*`}})
+			Expect(rec.Code).To(Equal(http.StatusNoContent))
+			Expect(deleter.deleted).To(Equal([]string{"1"}))
+		})
+
+		It("does not affect GET requests", func() {
+			rec := doRequest("GET", "/v1/versionedPosts/1", "", nil)
+			Expect(rec.Code).To(Equal(http.StatusOK))
+		})
+	})
+})
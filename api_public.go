@@ -12,15 +12,37 @@ import (
 // HandlerFunc for api2go middlewares
 type HandlerFunc func(APIContexter, http.ResponseWriter, *http.Request)
 
+// PanicHandlerFunc is called with the recovered value if a middleware or a
+// resource panics while handling a request.
+type PanicHandlerFunc func(c APIContexter, r *http.Request, recovered interface{})
+
+// TransactionFunc must call run and commit the changes of the sources if it
+// returns nil, or roll them back otherwise. The transaction can be stored in c
+// to make it available to the sources via Request.Context.
+type TransactionFunc func(c APIContexter, run func() error) error
+
 // API is a REST JSONAPI.
 type API struct {
-	ContentType      string
-	router           routing.Routeable
-	info             information
-	resources        []resource
-	middlewares      []HandlerFunc
-	contextPool      sync.Pool
-	contextAllocator APIContextAllocatorFunc
+	ContentType          string
+	router               routing.Routeable
+	info                 information
+	resources            []resource
+	routes               []Route
+	middlewares          []HandlerFunc
+	contextPool          *sync.Pool
+	contextAllocator     APIContextAllocatorFunc
+	errorMappers         []ErrorMapperFunc
+	panicHandler         PanicHandlerFunc
+	maxBodySize          int64
+	strictDecoding       bool
+	schemaValidation     bool
+	etags                bool
+	requirePreconditions bool
+	idempotencyStore     IdempotencyStore
+	idempotencyLocks     *idempotencyLocks
+	codec                jsonapi.Codec
+	typeNamer            jsonapi.TypeNamer
+	transactionHook      TransactionFunc
 }
 
 // Handler returns the http.Handler instance for the API.
@@ -28,7 +50,7 @@ func (api API) Handler() http.Handler {
 	return api.router.Handler()
 }
 
-//Router returns the specified router on an api instance
+// Router returns the specified router on an api instance
 func (api API) Router() routing.Routeable {
 	return api.router
 }
@@ -42,10 +64,132 @@ func (api *API) SetContextAllocator(allocator APIContextAllocatorFunc) {
 // At least the CRUD interface must be implemented, all the other interfaces are optional.
 // `resource` should be either an empty struct instance such as `Post{}` or a pointer to
 // a struct such as `&Post{}`. The same type will be used for constructing new elements.
-func (api *API) AddResource(prototype jsonapi.MarshalIdentifier, source interface{}) {
+// It must implement jsonapi.MarshalIdentifier or use `jsonapi` struct tags.
+func (api *API) AddResource(prototype interface{}, source interface{}) {
 	api.addResource(prototype, source)
 }
 
+// MapError registers a function that converts errors returned by a resource
+// into an HTTPError, e.g. to map `sql.ErrNoRows` to a 404 in one place.
+// Mappers are called in the order of registration for every error that does not
+// already contain an HTTPError, the first non nil result is used.
+func (api *API) MapError(mapper ErrorMapperFunc) {
+	api.errorMappers = append(api.errorMappers, mapper)
+}
+
+// SetPanicHandler sets a hook that is called whenever a panic is recovered
+// while handling a request, e.g. to report it to an error tracker. The client
+// always gets a 500 error document. By default the panic is logged with a stack
+// trace.
+func (api *API) SetPanicHandler(handler PanicHandlerFunc) {
+	api.panicHandler = handler
+}
+
+// SetMaxBodySize limits the size of request bodies in bytes. Larger requests
+// are rejected with 413 Request Entity Too Large. The default of 0 means no limit.
+func (api *API) SetMaxBodySize(size int64) {
+	api.maxBodySize = size
+}
+
+// SetStrictDecoding enables strict decoding of POST and PATCH payloads.
+// Unknown members, attributes and relationships are then rejected with
+// 400 Bad Request and a pointer to every invalid member, see jsonapi.UnmarshalStrict.
+func (api *API) SetStrictDecoding(enabled bool) {
+	api.strictDecoding = enabled
+}
+
+// SetSchemaValidation enables or disables the validation of the attributes of
+// POST and PATCH payloads against the JSON schema that is derived from the
+// resource struct. Violations are rejected with 422 Unprocessable Entity and a
+// pointer to every invalid attribute. It is disabled by default.
+//
+// The schema is derived when the resource is added, so a jsonapi.MemberNamer
+// must be set before AddResource is called.
+func (api *API) SetSchemaValidation(enabled bool) {
+	api.schemaValidation = enabled
+}
+
+// SetETags enables ETags for all GET routes. The ETag is the version of the
+// result if it implements Versioned, otherwise a hash of the response body.
+// Requests with a matching If-None-Match header are answered with
+// 304 Not Modified.
+func (api *API) SetETags(enabled bool) {
+	api.etags = enabled
+}
+
+// SetRequirePreconditions forces clients to send an If-Match header for all
+// PATCH and DELETE requests, including the relationship routes. Requests
+// without it are rejected with 428 Precondition Required.
+//
+// If-Match headers are always evaluated, a mismatch results in 412 Precondition Failed.
+func (api *API) SetRequirePreconditions(required bool) {
+	api.requirePreconditions = required
+}
+
+// SetIdempotencyStore enables support for the Idempotency-Key header on the
+// POST routes of all resources, related resources and to-many relationships.
+// The responses are saved in store, a retried request with the same key and
+// payload gets the stored response without calling the resource again. Passing
+// nil disables it.
+func (api *API) SetIdempotencyStore(store IdempotencyStore) {
+	api.idempotencyStore = store
+}
+
+// SetTransactionHook sets a hook for requests that call more than one source,
+// e.g. the creation of a resource in a related collection. Passing nil removes
+// it. Without a hook, a related resource whose parent can not be updated is
+// deleted again if its source implements ResourceDeleter, otherwise it stays.
+func (api *API) SetTransactionHook(hook TransactionFunc) {
+	api.transactionHook = hook
+}
+
+// transaction calls run inside of the transaction hook if there is one
+func (api *API) transaction(c APIContexter, run func() error) error {
+	if api.transactionHook == nil {
+		return run()
+	}
+
+	return api.transactionHook(c, run)
+}
+
+// SetCodec sets the codec that is used to encode responses and decode request
+// payloads of this API. By default the codec set with jsonapi.SetCodec is used.
+func (api *API) SetCodec(codec jsonapi.Codec) {
+	api.codec = codec
+}
+
+// SetTypeNamer sets the TypeNamer of this API. It is used for the routes, the
+// `type` members of documents and the type check of request payloads instead
+// of the type namer set with jsonapi.SetTypeNamer, which stays the default.
+// Passing nil restores the default. It must be called before AddResource.
+func (api *API) SetTypeNamer(namer jsonapi.TypeNamer) {
+	api.typeNamer = namer
+}
+
+// typeName returns the jsonapi type for the name of a go struct
+func (api *API) typeName(name string) string {
+	if api.typeNamer != nil {
+		return api.typeNamer.TypeName(name)
+	}
+
+	return jsonapi.TypeName(name)
+}
+
+// jsonCodec returns the codec of this api or the one of the jsonapi package
+func (api *API) jsonCodec() jsonapi.Codec {
+	if api.codec != nil {
+		return api.codec
+	}
+
+	return jsonapi.GetCodec()
+}
+
+// jsonOptions returns the codec and the type namer of this api, nil members
+// use the ones of the jsonapi package
+func (api *API) jsonOptions() jsonapi.Options {
+	return jsonapi.Options{Codec: api.codec, TypeNamer: api.typeNamer}
+}
+
 // UseMiddleware registers middlewares that implement the api2go.HandlerFunc
 // Middleware is run before any generated routes.
 func (api *API) UseMiddleware(middleware ...HandlerFunc) {
@@ -120,7 +264,9 @@ func newAPI(prefix string, resolver URLResolver, router routing.Routeable) *API
 		router:           router,
 		info:             info,
 		middlewares:      make([]HandlerFunc, 0),
+		contextPool:      &sync.Pool{},
 		contextAllocator: nil,
+		idempotencyLocks: &idempotencyLocks{keys: map[string]bool{}},
 	}
 
 	api.contextPool.New = func() interface{} {
@@ -0,0 +1,85 @@
+package api2go
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Test route introspection", func() {
+	var api *API
+
+	BeforeEach(func() {
+		api = NewAPIWithRouting(testPrefix, NewStaticResolver(""), newTestRouter())
+		api.AddResource(Post{}, &fixtureSource{posts: map[string]*Post{}})
+		api.AddResource(OpenAPIEvent{}, openAPIEventSource{})
+	})
+
+	It("returns all generated routes", func() {
+		routes := api.Routes()
+		Expect(routes[:4]).To(Equal([]Route{
+			{Method: "OPTIONS", Path: "/v1/posts", Resource: "posts", Operation: OperationOptions},
+			{Method: "GET", Path: "/v1/posts", Resource: "posts", Operation: OperationList},
+			{Method: "OPTIONS", Path: "/v1/posts/:id", Resource: "posts", Operation: OperationOptions},
+			{Method: "GET", Path: "/v1/posts/:id", Resource: "posts", Operation: OperationGet},
+		}))
+		Expect(routes).To(ContainElement(Route{
+			Method:       "GET",
+			Path:         "/v1/posts/:id/author",
+			Resource:     "posts",
+			Operation:    OperationGetRelated,
+			Relationship: "author",
+		}))
+		Expect(routes).To(ContainElement(Route{
+			Method:       "DELETE",
+			Path:         "/v1/posts/:id/relationships/comments",
+			Resource:     "posts",
+			Operation:    OperationRemoveFromRelationship,
+			Relationship: "comments",
+		}))
+		Expect(routes).ToNot(ContainElement(Route{
+			Method:       "DELETE",
+			Path:         "/v1/posts/:id/relationships/author",
+			Resource:     "posts",
+			Operation:    OperationRemoveFromRelationship,
+			Relationship: "author",
+		}))
+		Expect(routes).To(ContainElement(Route{Method: "PATCH", Path: "/v1/posts/:id", Resource: "posts", Operation: OperationUpdate}))
+		Expect(routes).ToNot(ContainElement(Route{Method: "PATCH", Path: "/v1/openAPIEvents/:id", Resource: "openAPIEvents", Operation: OperationUpdate}))
+	})
+
+	It("returns a copy", func() {
+		api.Routes()[0].Path = "/changed"
+		Expect(api.Routes()[0].Path).To(Equal("/v1/posts"))
+	})
+
+	It("serves the routes as JSON", func() {
+		api.AddOpenAPIRoute("openapi.json")
+
+		rec := httptest.NewRecorder()
+		req, err := http.NewRequest("GET", "/debug/routes", nil)
+		Expect(err).ToNot(HaveOccurred())
+		api.RoutesHandler().ServeHTTP(rec, req)
+		Expect(rec.Code).To(Equal(http.StatusOK))
+		Expect(rec.Header().Get("Content-Type")).To(Equal("application/json"))
+
+		var routes []map[string]string
+		Expect(json.Unmarshal(rec.Body.Bytes(), &routes)).To(Succeed())
+		Expect(routes).To(HaveLen(len(api.Routes())))
+		Expect(routes).To(ContainElement(map[string]string{
+			"method":       "PATCH",
+			"path":         "/v1/posts/:id/relationships/author",
+			"resource":     "posts",
+			"operation":    "replaceRelationship",
+			"relationship": "author",
+		}))
+		Expect(routes[len(routes)-1]).To(Equal(map[string]string{
+			"method":    "GET",
+			"path":      "/v1/openapi.json",
+			"operation": "openAPI",
+		}))
+	})
+})
@@ -0,0 +1,241 @@
+package api2go
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type SchemaArticle struct {
+	ID        string    `json:"-"`
+	Title     string    `json:"title" jsonschema:"required,minLength=3"`
+	Status    string    `json:"status" jsonschema:"enum=draft,enum=published"`
+	Email     *string   `json:"email" jsonschema:"format=email"`
+	Rating    int8      `json:"rating"`
+	Tags      []string  `json:"tags" jsonschema:"maxItems=2,pattern=^[a-z]+$"`
+	Published time.Time `json:"published"`
+}
+
+func (a SchemaArticle) GetID() string {
+	return a.ID
+}
+
+func (a *SchemaArticle) SetID(ID string) error {
+	a.ID = ID
+	return nil
+}
+
+type schemaArticleSource struct {
+	articles map[string]SchemaArticle
+}
+
+func (s *schemaArticleSource) FindOne(ID string, req Request) (Responder, error) {
+	article, ok := s.articles[ID]
+	if !ok {
+		return nil, NewHTTPError(nil, "article not found", http.StatusNotFound)
+	}
+
+	return &Response{Res: article}, nil
+}
+
+func (s *schemaArticleSource) Create(obj interface{}, req Request) (Responder, error) {
+	article := obj.(SchemaArticle)
+	article.ID = "2"
+	s.articles[article.ID] = article
+
+	return &Response{Res: article, Code: http.StatusCreated}, nil
+}
+
+func (s *schemaArticleSource) Update(obj interface{}, req Request) (Responder, error) {
+	article := obj.(SchemaArticle)
+	s.articles[article.ID] = article
+
+	return &Response{Res: article, Code: http.StatusOK}, nil
+}
+
+func (s *schemaArticleSource) Delete(ID string, req Request) (Responder, error) {
+	delete(s.articles, ID)
+	return &Response{Code: http.StatusNoContent}, nil
+}
+
+type InvalidSchemaArticle struct {
+	ID     string `json:"-"`
+	Rating int    `json:"rating" jsonschema:"enum=high"`
+}
+
+func (a InvalidSchemaArticle) GetID() string {
+	return a.ID
+}
+
+// schemaLevel is decoded from text but encoded as number
+type schemaLevel int
+
+func (l *schemaLevel) UnmarshalText(text []byte) error {
+	*l = schemaLevel(len(text))
+	return nil
+}
+
+// schemaRaw accepts any JSON value
+type schemaRaw string
+
+func (r *schemaRaw) UnmarshalJSON(data []byte) error {
+	*r = schemaRaw(data)
+	return nil
+}
+
+type SchemaCounter struct {
+	ID    string      `json:"-"`
+	Count int         `json:"count,string"`
+	Limit *float64    `json:"limit,string"`
+	Level schemaLevel `json:"level"`
+	Raw   schemaRaw   `json:"raw"`
+	Name  string      `json:"name"`
+}
+
+func (c SchemaCounter) GetID() string {
+	return c.ID
+}
+
+func (c *SchemaCounter) SetID(ID string) error {
+	c.ID = ID
+	return nil
+}
+
+type schemaCounterSource struct{}
+
+func (s *schemaCounterSource) Create(obj interface{}, req Request) (Responder, error) {
+	counter := obj.(SchemaCounter)
+	counter.ID = "1"
+
+	return &Response{Res: counter, Code: http.StatusCreated}, nil
+}
+
+var _ = Describe("Test schema validation", func() {
+	var (
+		api    *API
+		source *schemaArticleSource
+		rec    *httptest.ResponseRecorder
+	)
+
+	BeforeEach(func() {
+		source = &schemaArticleSource{articles: map[string]SchemaArticle{
+			"1": {ID: "1", Title: "First", Status: "draft"},
+		}}
+		api = NewAPIWithRouting(testPrefix, NewStaticResolver(""), newTestRouter())
+		api.SetSchemaValidation(true)
+		api.AddResource(SchemaArticle{}, source)
+		rec = httptest.NewRecorder()
+	})
+
+	send := func(method, url, body string) {
+		req, err := http.NewRequest(method, url, strings.NewReader(body))
+		Expect(err).ToNot(HaveOccurred())
+		api.Handler().ServeHTTP(rec, req)
+	}
+
+	It("derives the schema from the struct fields and tags", func() {
+		Expect(openAPIJSON(api.OpenAPI(), "components", "schemas", "schemaArticles.Attributes")).To(MatchJSON(`{
+			"type": "object",
+			"required": ["title"],
+			"properties": {
+				"title": {"type": "string", "minLength": 3},
+				"status": {"type": "string", "enum": ["draft", "published"]},
+				"email": {"type": ["string", "null"], "format": "email"},
+				"rating": {"type": "integer", "format": "int32", "minimum": -128, "maximum": 127},
+				"tags": {"type": ["array", "null"], "maxItems": 2, "items": {"type": "string", "pattern": "^[a-z]+$"}},
+				"published": {"type": "string", "format": "date-time"}
+			}
+		}`))
+	})
+
+	It("accepts valid payloads", func() {
+		send("POST", "/v1/schemaArticles", `{"data": {"type": "schemaArticles", "attributes": {
+			"title": "Schemas",
+			"status": "published",
+			"email": null,
+			"rating": 5,
+			"tags": ["go"],
+			"published": "2020-01-02T15:04:05Z"
+		}}}`)
+		Expect(rec.Code).To(Equal(http.StatusCreated))
+		Expect(source.articles["2"].Title).To(Equal("Schemas"))
+	})
+
+	It("rejects invalid attributes with a pointer to each of them", func() {
+		send("POST", "/v1/schemaArticles", `{"data": {"type": "schemaArticles", "attributes": {
+			"title": 42,
+			"status": "deleted",
+			"email": "nobody",
+			"rating": 300,
+			"tags": ["go", "Rust", "c"],
+			"published": "yesterday"
+		}}}`)
+		Expect(rec.Code).To(Equal(http.StatusUnprocessableEntity))
+		Expect(rec.Body.String()).To(MatchJSON(`{"errors": [
+			{"status": "422", "code": "API2GO_INVALID_ATTRIBUTE", "title": "Invalid attribute", "detail": "must be a valid email", "source": {"pointer": "/data/attributes/email"}},
+			{"status": "422", "code": "API2GO_INVALID_ATTRIBUTE", "title": "Invalid attribute", "detail": "must be a valid date-time", "source": {"pointer": "/data/attributes/published"}},
+			{"status": "422", "code": "API2GO_INVALID_ATTRIBUTE", "title": "Invalid attribute", "detail": "must be at most 127", "source": {"pointer": "/data/attributes/rating"}},
+			{"status": "422", "code": "API2GO_INVALID_ATTRIBUTE", "title": "Invalid attribute", "detail": "must be one of [\"draft\",\"published\"]", "source": {"pointer": "/data/attributes/status"}},
+			{"status": "422", "code": "API2GO_INVALID_ATTRIBUTE", "title": "Invalid attribute", "detail": "must contain at most 2 elements", "source": {"pointer": "/data/attributes/tags"}},
+			{"status": "422", "code": "API2GO_INVALID_ATTRIBUTE", "title": "Invalid attribute", "detail": "must match the pattern ^[a-z]+$", "source": {"pointer": "/data/attributes/tags/1"}},
+			{"status": "422", "code": "API2GO_INVALID_ATTRIBUTE", "title": "Invalid attribute", "detail": "must be of type string", "source": {"pointer": "/data/attributes/title"}}
+		]}`))
+		Expect(source.articles).ToNot(HaveKey("2"))
+	})
+
+	It("requires attributes only for new resources", func() {
+		send("POST", "/v1/schemaArticles", `{"data": {"type": "schemaArticles", "attributes": {"status": "draft"}}}`)
+		Expect(rec.Code).To(Equal(http.StatusUnprocessableEntity))
+		Expect(rec.Body.String()).To(ContainSubstring(`"detail":"title is required","source":{"pointer":"/data/attributes"}`))
+
+		rec = httptest.NewRecorder()
+		send("PATCH", "/v1/schemaArticles/1", `{"data": {"type": "schemaArticles", "id": "1", "attributes": {"status": "published"}}}`)
+		Expect(rec.Code).To(Equal(http.StatusOK))
+		Expect(source.articles["1"]).To(Equal(SchemaArticle{ID: "1", Title: "First", Status: "published"}))
+
+		rec = httptest.NewRecorder()
+		send("PATCH", "/v1/schemaArticles/1", `{"data": {"type": "schemaArticles", "id": "1", "attributes": {"title": "No"}}}`)
+		Expect(rec.Code).To(Equal(http.StatusUnprocessableEntity))
+		Expect(rec.Body.String()).To(ContainSubstring(`"detail":"must be at least 3 characters long"`))
+	})
+
+	It("can be disabled", func() {
+		api.SetSchemaValidation(false)
+		send("POST", "/v1/schemaArticles", `{"data": {"type": "schemaArticles", "attributes": {"title": 42}}}`)
+		Expect(rec.Code).To(Equal(http.StatusNotAcceptable))
+	})
+
+	It("is disabled by default", func() {
+		api = NewAPIWithRouting(testPrefix, NewStaticResolver(""), newTestRouter())
+		api.AddResource(SchemaArticle{}, source)
+		send("POST", "/v1/schemaArticles", `{"data": {"type": "schemaArticles", "attributes": {"title": "No"}}}`)
+		Expect(rec.Code).To(Equal(http.StatusCreated))
+	})
+
+	It("accepts everything that encoding/json decodes", func() {
+		api.AddResource(SchemaCounter{}, &schemaCounterSource{})
+		Expect(openAPIJSON(api.OpenAPI(), "components", "schemas", "schemaCounters.Attributes")).To(MatchJSON(`{
+			"type": "object",
+			"properties": {
+				"count": {"type": "string"},
+				"limit": {"type": ["string", "null"]},
+				"level": {},
+				"raw": {},
+				"name": {"type": "string"}
+			}
+		}`))
+
+		send("POST", "/v1/schemaCounters", `{"data": {"type": "schemaCounters", "attributes": {"count": "5", "limit": null, "level": "high", "raw": 1}}}`)
+		Expect(rec.Code).To(Equal(http.StatusCreated))
+	})
+
+	It("panics for invalid tags", func() {
+		Expect(func() {
+			api.AddResource(InvalidSchemaArticle{}, source)
+		}).To(Panic())
+	})
+})
@@ -0,0 +1,153 @@
+package api2go
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/manyminds/api2go/jsonapi"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type sliceIterator struct {
+	elements []jsonapi.MarshalIdentifier
+	failAt   int
+	closed   bool
+}
+
+func (s *sliceIterator) Next() (jsonapi.MarshalIdentifier, bool, error) {
+	if s.failAt == 0 {
+		return nil, false, errors.New("iterator failed")
+	}
+	s.failAt--
+
+	if len(s.elements) == 0 {
+		return nil, false, nil
+	}
+
+	element := s.elements[0]
+	s.elements = s.elements[1:]
+	return element, true, nil
+}
+
+func (s *sliceIterator) Close() error {
+	s.closed = true
+	return nil
+}
+
+type streamingSource struct {
+	fixtureSource
+	iterator *sliceIterator
+	request  Request
+}
+
+func (s *streamingSource) StreamFindAll(req Request) (ResultIterator, error) {
+	s.request = req
+	for i := 1; i <= len(s.posts); i++ {
+		s.iterator.elements = append(s.iterator.elements, s.posts[string(rune('0'+i))])
+	}
+	return s.iterator, nil
+}
+
+var _ = Describe("Test streaming collections", func() {
+	var (
+		api    *API
+		source *streamingSource
+		rec    *httptest.ResponseRecorder
+	)
+
+	posts := func() map[string]*Post {
+		return map[string]*Post{
+			"1": {ID: "1", Title: "Hello, World!", Author: &User{ID: "1", Name: "Dieter"}},
+			"2": {ID: "2", Title: "I am NR. 2", Comments: []Comment{{ID: "1", Value: "This is a stupid post!"}}},
+		}
+	}
+
+	BeforeEach(func() {
+		source = &streamingSource{
+			fixtureSource: fixtureSource{posts(), false},
+			iterator:      &sliceIterator{failAt: -1},
+		}
+		api = NewAPIWithRouting(testPrefix, NewStaticResolver(""), newTestRouter())
+		api.AddResource(Post{}, source)
+		rec = httptest.NewRecorder()
+	})
+
+	expected := func(path string) string {
+		expectedAPI := NewAPIWithRouting(testPrefix, NewStaticResolver(""), newTestRouter())
+		expectedAPI.AddResource(Post{}, &fixtureSource{posts(), false})
+		expectedRec := httptest.NewRecorder()
+		req, err := http.NewRequest("GET", path, nil)
+		Expect(err).ToNot(HaveOccurred())
+		expectedAPI.Handler().ServeHTTP(expectedRec, req)
+		Expect(expectedRec.Code).To(Equal(http.StatusOK))
+		return expectedRec.Body.String()
+	}
+
+	It("streams the same document as FindAll", func() {
+		req, err := http.NewRequest("GET", "/v1/posts", nil)
+		Expect(err).ToNot(HaveOccurred())
+		api.Handler().ServeHTTP(rec, req)
+		Expect(rec.Code).To(Equal(http.StatusOK))
+		Expect(rec.Header().Get("Content-Type")).To(Equal(defaultContentTypHeader))
+		Expect(rec.Body.String()).To(MatchJSON(expected("/v1/posts")))
+		Expect(source.iterator.closed).To(BeTrue())
+		Expect(source.request.PlainRequest.URL.Path).To(Equal("/v1/posts"))
+	})
+
+	It("applies sparse fieldsets", func() {
+		req, err := http.NewRequest("GET", "/v1/posts?fields[posts]=title", nil)
+		Expect(err).ToNot(HaveOccurred())
+		api.Handler().ServeHTTP(rec, req)
+		Expect(rec.Code).To(Equal(http.StatusOK))
+		Expect(rec.Body.String()).To(MatchJSON(expected("/v1/posts?fields[posts]=title")))
+	})
+
+	It("returns an error document for invalid fields", func() {
+		req, err := http.NewRequest("GET", "/v1/posts?fields[posts]=nope", nil)
+		Expect(err).ToNot(HaveOccurred())
+		api.Handler().ServeHTTP(rec, req)
+		Expect(rec.Code).To(Equal(http.StatusBadRequest))
+		Expect(rec.Body.String()).To(ContainSubstring(codeInvalidQueryFields))
+		Expect(source.iterator.closed).To(BeTrue())
+	})
+
+	It("returns an error document if the iterator fails before the first element", func() {
+		source.iterator.failAt = 0
+		req, err := http.NewRequest("GET", "/v1/posts", nil)
+		Expect(err).ToNot(HaveOccurred())
+		api.Handler().ServeHTTP(rec, req)
+		Expect(rec.Code).To(Equal(http.StatusInternalServerError))
+		Expect(rec.Body.String()).To(ContainSubstring("iterator failed"))
+	})
+
+	It("aborts the response if the iterator fails later", func() {
+		source.iterator.failAt = 1
+		req, err := http.NewRequest("GET", "/v1/posts", nil)
+		Expect(err).ToNot(HaveOccurred())
+
+		func() {
+			defer func() {
+				// some routers recover panics on their own
+				recovered := recover()
+				Expect(recovered == nil || recovered == http.ErrAbortHandler).To(BeTrue())
+			}()
+			api.Handler().ServeHTTP(rec, req)
+		}()
+
+		Expect(rec.Code).To(Equal(http.StatusOK))
+		Expect(rec.Body.String()).To(HavePrefix(`{"data":[{"type":"posts","id":"1"`))
+		Expect(json.Valid(rec.Body.Bytes())).To(BeFalse())
+		Expect(source.iterator.closed).To(BeTrue())
+	})
+
+	It("uses pagination if requested", func() {
+		req, err := http.NewRequest("GET", "/v1/posts?page[offset]=0&page[limit]=1", nil)
+		Expect(err).ToNot(HaveOccurred())
+		api.Handler().ServeHTTP(rec, req)
+		Expect(rec.Code).To(Equal(http.StatusOK))
+		Expect(source.request.PlainRequest).To(BeNil())
+	})
+})
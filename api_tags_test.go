@@ -0,0 +1,178 @@
+package api2go
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type TaggedBook struct {
+	ID        string   `jsonapi:"primary,books"`
+	Title     string   `jsonapi:"attr,title"`
+	AuthorID  string   `jsonapi:"relation,author,users"`
+	ReaderIDs []string `jsonapi:"relation,readers,users"`
+}
+
+type TaggedMalformed struct {
+	ID string `jsonapi:"primary"`
+}
+
+type taggedBookSource struct {
+	books map[string]TaggedBook
+}
+
+func (s *taggedBookSource) FindAll(req Request) (Responder, error) {
+	var result []TaggedBook
+	for _, book := range s.books {
+		result = append(result, book)
+	}
+
+	return &Response{Res: result}, nil
+}
+
+func (s *taggedBookSource) FindOne(ID string, req Request) (Responder, error) {
+	book, ok := s.books[ID]
+	if !ok {
+		return nil, NewHTTPError(nil, "book not found", http.StatusNotFound)
+	}
+
+	return &Response{Res: book}, nil
+}
+
+func (s *taggedBookSource) Create(obj interface{}, req Request) (Responder, error) {
+	book := obj.(TaggedBook)
+	book.ID = "2"
+	s.books[book.ID] = book
+
+	return &Response{Res: book, Code: http.StatusCreated}, nil
+}
+
+func (s *taggedBookSource) Delete(ID string, req Request) (Responder, error) {
+	delete(s.books, ID)
+	return &Response{Code: http.StatusNoContent}, nil
+}
+
+func (s *taggedBookSource) Update(obj interface{}, req Request) (Responder, error) {
+	book := obj.(TaggedBook)
+	s.books[book.ID] = book
+
+	return &Response{Res: book, Code: http.StatusNoContent}, nil
+}
+
+var _ = Describe("Test API with struct tag resources", func() {
+	var (
+		api    *API
+		source *taggedBookSource
+		rec    *httptest.ResponseRecorder
+	)
+
+	BeforeEach(func() {
+		source = &taggedBookSource{books: map[string]TaggedBook{
+			"1": {ID: "1", Title: "Go", AuthorID: "1", ReaderIDs: []string{"2"}},
+		}}
+		api = NewAPIWithRouting(testPrefix, NewStaticResolver(""), newTestRouter())
+		api.AddResource(TaggedBook{}, source)
+		rec = httptest.NewRecorder()
+	})
+
+	It("panics for prototypes without identifier", func() {
+		Expect(func() {
+			api.AddResource(struct{ Name string }{}, source)
+		}).To(Panic())
+	})
+
+	It("panics for malformed tags", func() {
+		Expect(func() {
+			api.AddResource(TaggedMalformed{}, source)
+		}).To(PanicWith(MatchError("jsonapi: primary tag of api2go.TaggedMalformed.ID needs a type")))
+	})
+
+	It("encodes and decodes attributes with the codec of the API", func() {
+		codec := &unescapedCodec{}
+		source.books["1"] = TaggedBook{ID: "1", Title: "<b>Go</b>"}
+		api = NewAPIWithRouting(testPrefix, NewStaticResolver(""), newTestRouter())
+		api.SetCodec(codec)
+		api.AddResource(TaggedBook{}, source)
+
+		req, err := http.NewRequest("GET", "/v1/books/1", nil)
+		Expect(err).ToNot(HaveOccurred())
+		api.Handler().ServeHTTP(rec, req)
+		Expect(rec.Body.String()).To(ContainSubstring(`"attributes":{"title":"<b>Go</b>"}`))
+
+		rec = httptest.NewRecorder()
+		req, err = http.NewRequest("POST", "/v1/books", strings.NewReader(`{"data": {"type": "books", "attributes": {"title": "New"}}}`))
+		Expect(err).ToNot(HaveOccurred())
+		before := codec.unmarshalled
+		api.Handler().ServeHTTP(rec, req)
+		Expect(rec.Code).To(Equal(http.StatusCreated))
+		Expect(codec.unmarshalled - before).To(BeNumerically(">", 2))
+	})
+
+	It("returns a tagged resource", func() {
+		req, err := http.NewRequest("GET", "/v1/books/1", nil)
+		Expect(err).ToNot(HaveOccurred())
+		api.Handler().ServeHTTP(rec, req)
+		Expect(rec.Code).To(Equal(http.StatusOK))
+		Expect(rec.Body.String()).To(MatchJSON(`{
+			"data": {
+				"type": "books",
+				"id": "1",
+				"attributes": {"title": "Go"},
+				"relationships": {
+					"author": {
+						"links": {
+							"self": "/v1/books/1/relationships/author",
+							"related": "/v1/books/1/author"
+						},
+						"data": {"type": "users", "id": "1"}
+					},
+					"readers": {
+						"links": {
+							"self": "/v1/books/1/relationships/readers",
+							"related": "/v1/books/1/readers"
+						},
+						"data": [{"type": "users", "id": "2"}]
+					}
+				}
+			}
+		}`))
+	})
+
+	It("creates a tagged resource", func() {
+		req, err := http.NewRequest("POST", "/v1/books", strings.NewReader(`{"data": {
+			"type": "books",
+			"attributes": {"title": "New"},
+			"relationships": {"author": {"data": {"type": "users", "id": "3"}}}
+		}}`))
+		Expect(err).ToNot(HaveOccurred())
+		api.Handler().ServeHTTP(rec, req)
+		Expect(rec.Code).To(Equal(http.StatusCreated))
+		Expect(source.books["2"]).To(Equal(TaggedBook{ID: "2", Title: "New", AuthorID: "3"}))
+	})
+
+	It("updates to-one relationships", func() {
+		req, err := http.NewRequest("PATCH", "/v1/books/1/relationships/author", strings.NewReader(`{"data": {"type": "users", "id": "4"}}`))
+		Expect(err).ToNot(HaveOccurred())
+		api.Handler().ServeHTTP(rec, req)
+		Expect(rec.Code).To(Equal(http.StatusNoContent))
+		Expect(source.books["1"].AuthorID).To(Equal("4"))
+	})
+
+	It("adds and removes to-many relationships", func() {
+		req, err := http.NewRequest("POST", "/v1/books/1/relationships/readers", strings.NewReader(`{"data": [{"type": "users", "id": "3"}]}`))
+		Expect(err).ToNot(HaveOccurred())
+		api.Handler().ServeHTTP(rec, req)
+		Expect(rec.Code).To(Equal(http.StatusNoContent))
+		Expect(source.books["1"].ReaderIDs).To(Equal([]string{"2", "3"}))
+
+		rec = httptest.NewRecorder()
+		req, err = http.NewRequest("DELETE", "/v1/books/1/relationships/readers", strings.NewReader(`{"data": [{"type": "users", "id": "2"}]}`))
+		Expect(err).ToNot(HaveOccurred())
+		api.Handler().ServeHTTP(rec, req)
+		Expect(rec.Code).To(Equal(http.StatusNoContent))
+		Expect(source.books["1"].ReaderIDs).To(Equal([]string{"3"}))
+	})
+})
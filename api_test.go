@@ -1,6 +1,7 @@
 package api2go
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -1476,6 +1477,29 @@ var _ = Describe("RestHandler", func() {
 		})
 	})
 
+	Context("When the request has a context", func() {
+		type key string
+
+		It("makes its values available in the APIContexter", func() {
+			api := NewAPIWithRouting(testPrefix, NewStaticResolver(""), newTestRouter())
+			api.AddResource(Post{}, &fixtureSource{map[string]*Post{}, false})
+			api.UseMiddleware(func(c APIContexter, w http.ResponseWriter, r *http.Request) {
+				tenant, _ := c.Get("tenant")
+				w.Header().Set("x-tenant", tenant.(string))
+				w.Header().Set("x-user", c.Value(key("user")).(string))
+			})
+
+			ctx := context.WithValue(context.Background(), "tenant", "acme")
+			ctx = context.WithValue(ctx, key("user"), "ned")
+			req, err := http.NewRequestWithContext(ctx, "OPTIONS", "/v1/posts", nil)
+			Expect(err).ToNot(HaveOccurred())
+			rec := httptest.NewRecorder()
+			api.Handler().ServeHTTP(rec, req)
+			Expect(rec.Header().Get("x-tenant")).To(Equal("acme"))
+			Expect(rec.Header().Get("x-user")).To(Equal("ned"))
+		})
+	})
+
 	Context("Custom context", func() {
 		var (
 			api                 *API
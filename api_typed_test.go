@@ -0,0 +1,240 @@
+package api2go
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+
+	"github.com/manyminds/api2go/jsonapi"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type typedBookSource struct {
+	books map[string]*TaggedBook
+}
+
+func (s *typedBookSource) FindAll(req Request) ([]*TaggedBook, error) {
+	var result []*TaggedBook
+	for _, book := range s.books {
+		result = append(result, book)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].ID < result[j].ID
+	})
+
+	return result, nil
+}
+
+func (s *typedBookSource) FindOne(ID string, req Request) (*TaggedBook, error) {
+	book, ok := s.books[ID]
+	if !ok {
+		return nil, NewHTTPError(nil, "book not found", http.StatusNotFound)
+	}
+
+	copied := *book
+	return &copied, nil
+}
+
+func (s *typedBookSource) Create(book *TaggedBook, req Request) (*TaggedBook, error) {
+	if book.Title == "" {
+		return nil, NewHTTPError(errors.New("missing title"), "missing title", http.StatusUnprocessableEntity)
+	}
+
+	book.ID = "2"
+	s.books[book.ID] = book
+
+	return book, nil
+}
+
+func (s *typedBookSource) Update(book *TaggedBook, req Request) (*TaggedBook, error) {
+	s.books[book.ID] = book
+	return book, nil
+}
+
+func (s *typedBookSource) Delete(ID string, req Request) error {
+	delete(s.books, ID)
+	return nil
+}
+
+type paginatedTypedBookSource struct {
+	typedBookSource
+}
+
+func (s *paginatedTypedBookSource) PaginatedFindAll(req Request) (uint, []*TaggedBook, error) {
+	all, _ := s.FindAll(req)
+	return uint(len(all)), all[:1], nil
+}
+
+// streamingTypedBookSource implements all optional interfaces
+type streamingTypedBookSource struct {
+	paginatedTypedBookSource
+	streamed bool
+}
+
+func (s *streamingTypedBookSource) StreamFindAll(req Request) (ResultIterator, error) {
+	s.streamed = true
+	books, _ := s.FindAll(req)
+	iterator := &sliceIterator{failAt: -1}
+	for _, book := range books {
+		iterator.elements = append(iterator.elements, jsonapi.WrapTagged(book).(jsonapi.MarshalIdentifier))
+	}
+
+	return iterator, nil
+}
+
+func (s *streamingTypedBookSource) InitializeObject(obj interface{}) {
+	obj.(*TaggedBook).Title = "Untitled"
+}
+
+var _ = Describe("Test typed resources", func() {
+	var (
+		api    *API
+		source *typedBookSource
+		rec    *httptest.ResponseRecorder
+	)
+
+	BeforeEach(func() {
+		source = &typedBookSource{books: map[string]*TaggedBook{
+			"1": {ID: "1", Title: "Go"},
+		}}
+		api = NewAPIWithRouting(testPrefix, NewStaticResolver(""), newTestRouter())
+		AddTypedResource[*TaggedBook](api, source)
+		rec = httptest.NewRecorder()
+	})
+
+	It("returns all resources", func() {
+		req, err := http.NewRequest("GET", "/v1/books", nil)
+		Expect(err).ToNot(HaveOccurred())
+		api.Handler().ServeHTTP(rec, req)
+		Expect(rec.Code).To(Equal(http.StatusOK))
+		Expect(rec.Body.String()).To(ContainSubstring(`"attributes":{"title":"Go"}`))
+	})
+
+	It("returns errors of the source", func() {
+		req, err := http.NewRequest("GET", "/v1/books/2", nil)
+		Expect(err).ToNot(HaveOccurred())
+		api.Handler().ServeHTTP(rec, req)
+		Expect(rec.Code).To(Equal(http.StatusNotFound))
+	})
+
+	It("creates resources", func() {
+		req, err := http.NewRequest("POST", "/v1/books", strings.NewReader(`{"data": {"type": "books", "attributes": {"title": "New"}}}`))
+		Expect(err).ToNot(HaveOccurred())
+		api.Handler().ServeHTTP(rec, req)
+		Expect(rec.Code).To(Equal(http.StatusCreated))
+		Expect(rec.Header().Get("Location")).To(Equal("/v1/books/2"))
+		Expect(source.books["2"]).To(Equal(&TaggedBook{ID: "2", Title: "New"}))
+	})
+
+	It("updates resources", func() {
+		req, err := http.NewRequest("PATCH", "/v1/books/1", strings.NewReader(`{"data": {"type": "books", "id": "1", "attributes": {"title": "Changed"}}}`))
+		Expect(err).ToNot(HaveOccurred())
+		api.Handler().ServeHTTP(rec, req)
+		Expect(rec.Code).To(Equal(http.StatusOK))
+		Expect(rec.Body.String()).To(ContainSubstring(`"title":"Changed"`))
+		Expect(source.books["1"].Title).To(Equal("Changed"))
+	})
+
+	It("updates relationships", func() {
+		req, err := http.NewRequest("POST", "/v1/books/1/relationships/readers", strings.NewReader(`{"data": [{"type": "users", "id": "3"}]}`))
+		Expect(err).ToNot(HaveOccurred())
+		api.Handler().ServeHTTP(rec, req)
+		Expect(rec.Code).To(Equal(http.StatusNoContent))
+		Expect(source.books["1"].ReaderIDs).To(Equal([]string{"3"}))
+	})
+
+	It("deletes resources", func() {
+		req, err := http.NewRequest("DELETE", "/v1/books/1", nil)
+		Expect(err).ToNot(HaveOccurred())
+		api.Handler().ServeHTTP(rec, req)
+		Expect(rec.Code).To(Equal(http.StatusNoContent))
+		Expect(source.books).To(BeEmpty())
+	})
+
+	It("paginates if the source supports it", func() {
+		source.books["2"] = &TaggedBook{ID: "2", Title: "Generics"}
+		api = NewAPIWithRouting(testPrefix, NewStaticResolver(""), newTestRouter())
+		AddTypedResource[*TaggedBook](api, &paginatedTypedBookSource{*source})
+
+		req, err := http.NewRequest("GET", "/v1/books?page[number]=1&page[size]=1", nil)
+		Expect(err).ToNot(HaveOccurred())
+		api.Handler().ServeHTTP(rec, req)
+		Expect(rec.Code).To(Equal(http.StatusOK))
+		Expect(rec.Body.String()).To(ContainSubstring(`"next":"/v1/books?page[number]=2\u0026page[size]=1"`))
+		Expect(rec.Body.String()).ToNot(ContainSubstring(`"Generics"`))
+	})
+
+	It("forwards the optional interfaces of the source", func() {
+		source.books["3"] = &TaggedBook{ID: "3", Title: "Generics"}
+		streaming := &streamingTypedBookSource{paginatedTypedBookSource: paginatedTypedBookSource{*source}}
+		api = NewAPIWithRouting(testPrefix, NewStaticResolver(""), newTestRouter())
+		AddTypedResource[*TaggedBook](api, streaming)
+
+		req, err := http.NewRequest("GET", "/v1/books", nil)
+		Expect(err).ToNot(HaveOccurred())
+		api.Handler().ServeHTTP(rec, req)
+		Expect(rec.Code).To(Equal(http.StatusOK))
+		Expect(rec.Body.String()).To(ContainSubstring(`"title":"Go"`))
+		Expect(streaming.streamed).To(BeTrue())
+
+		rec = httptest.NewRecorder()
+		req, err = http.NewRequest("GET", "/v1/books?page[number]=1&page[size]=1", nil)
+		Expect(err).ToNot(HaveOccurred())
+		api.Handler().ServeHTTP(rec, req)
+		Expect(rec.Body.String()).To(ContainSubstring(`"next":"/v1/books?page[number]=2\u0026page[size]=1"`))
+		Expect(rec.Body.String()).ToNot(ContainSubstring(`"Generics"`))
+
+		rec = httptest.NewRecorder()
+		req, err = http.NewRequest("POST", "/v1/books", strings.NewReader(`{"data": {"type": "books", "attributes": {}}}`))
+		Expect(err).ToNot(HaveOccurred())
+		api.Handler().ServeHTTP(rec, req)
+		Expect(rec.Code).To(Equal(http.StatusCreated))
+		Expect(source.books["2"]).To(Equal(&TaggedBook{ID: "2", Title: "Untitled"}))
+	})
+
+	It("works with struct values", func() {
+		api = NewAPIWithRouting(testPrefix, NewStaticResolver(""), newTestRouter())
+		values := &typedValueSource{}
+		AddTypedResource[TaggedBook](api, values)
+
+		req, err := http.NewRequest("POST", "/v1/books", strings.NewReader(`{"data": {"type": "books", "id": "5", "attributes": {"title": "Value"}}}`))
+		Expect(err).ToNot(HaveOccurred())
+		api.Handler().ServeHTTP(rec, req)
+		Expect(rec.Code).To(Equal(http.StatusCreated))
+		Expect(values.created).To(Equal(TaggedBook{ID: "5", Title: "Value"}))
+	})
+
+	It("returns untyped values of TypedResponse", func() {
+		response := NewTypedResponse([]int{1}, http.StatusAccepted)
+		Expect(response.Result()).To(Equal([]int{1}))
+		Expect(response.StatusCode()).To(Equal(http.StatusAccepted))
+	})
+})
+
+type typedValueSource struct {
+	created TaggedBook
+}
+
+func (s *typedValueSource) FindAll(req Request) ([]TaggedBook, error) {
+	return nil, nil
+}
+
+func (s *typedValueSource) FindOne(ID string, req Request) (TaggedBook, error) {
+	return TaggedBook{ID: ID}, nil
+}
+
+func (s *typedValueSource) Create(book TaggedBook, req Request) (TaggedBook, error) {
+	s.created = book
+	return book, nil
+}
+
+func (s *typedValueSource) Update(book TaggedBook, req Request) (TaggedBook, error) {
+	return book, nil
+}
+
+func (s *typedValueSource) Delete(ID string, req Request) error {
+	return nil
+}
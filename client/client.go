@@ -0,0 +1,160 @@
+// Package client talks to jsonapi servers like the ones built with api2go.
+//
+// A Client holds the connection settings, a Resource provides the typed
+// operations for one resource type:
+//
+//	c, err := client.New("http://localhost:8080/v1")
+//	posts := client.NewResource[*Post](c)
+//	post, err := posts.Get(ctx, "1", client.NewQuery().Include("comments"))
+//
+// The models are marshalled with the jsonapi package, so they must implement
+// its interfaces or use jsonapi struct tags. Error responses are returned as
+// *ResponseError.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/manyminds/api2go"
+)
+
+const contentType = "application/vnd.api+json"
+
+// Client sends requests to a jsonapi server
+type Client struct {
+	baseURL    *url.URL
+	httpClient *http.Client
+	header     http.Header
+}
+
+// New returns a client for the server at baseURL, it must contain the prefix
+// of the api, e.g. http://localhost:8080/v1
+func New(baseURL string) (*Client, error) {
+	parsed, err := url.Parse(strings.TrimSuffix(baseURL, "/"))
+	if err != nil {
+		return nil, err
+	}
+
+	if !parsed.IsAbs() {
+		return nil, fmt.Errorf("base URL %s must be absolute", baseURL)
+	}
+
+	return &Client{baseURL: parsed, httpClient: http.DefaultClient, header: http.Header{}}, nil
+}
+
+// SetHTTPClient sets the http client that is used to send the requests, the
+// default is http.DefaultClient
+func (c *Client) SetHTTPClient(httpClient *http.Client) {
+	c.httpClient = httpClient
+}
+
+// SetHeader sets a header that is sent with every request, e.g. for
+// authentication
+func (c *Client) SetHeader(key, value string) {
+	c.header.Set(key, value)
+}
+
+// ResponseError is returned if the server responded with an error status code.
+// Errors contains the errors of the error document, it is empty if the
+// response did not contain one.
+type ResponseError struct {
+	StatusCode int
+	Errors     []api2go.Error
+}
+
+// Error returns the status code and the first error of the document
+func (e *ResponseError) Error() string {
+	if len(e.Errors) == 0 {
+		return fmt.Sprintf("jsonapi request failed with status %d", e.StatusCode)
+	}
+
+	message := e.Errors[0].Title
+	if e.Errors[0].Detail != "" {
+		message = e.Errors[0].Detail
+	}
+
+	if len(e.Errors) > 1 {
+		return fmt.Sprintf("jsonapi request failed with status %d: %s and %d more errors", e.StatusCode, message, len(e.Errors)-1)
+	}
+
+	return fmt.Sprintf("jsonapi request failed with status %d: %s", e.StatusCode, message)
+}
+
+// resolve returns the absolute URL for a path relative to the base URL or for
+// a link returned by the server
+func (c *Client) resolve(path string, query *Query) (string, error) {
+	if query != nil {
+		if encoded := query.Encode(); encoded != "" {
+			path += "?" + encoded
+		}
+	}
+
+	reference, err := url.Parse(path)
+	if err != nil {
+		return "", err
+	}
+
+	if reference.IsAbs() || strings.HasPrefix(reference.Path, "/") {
+		return c.baseURL.ResolveReference(reference).String(), nil
+	}
+
+	result := *c.baseURL
+	result.Path += "/" + reference.Path
+	result.RawPath = ""
+	result.RawQuery = reference.RawQuery
+	return result.String(), nil
+}
+
+// do sends a request and returns the status code and the body of successful
+// responses
+func (c *Client) do(ctx context.Context, method, target string, payload []byte) (int, []byte, error) {
+	var body io.Reader
+	if payload != nil {
+		body = bytes.NewReader(payload)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, target, body)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	for key, values := range c.header {
+		req.Header[key] = values
+	}
+	req.Header.Set("Accept", contentType)
+	if payload != nil {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer resp.Body.Close()
+
+	result, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return resp.StatusCode, nil, err
+	}
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		responseError := &ResponseError{StatusCode: resp.StatusCode}
+		var document struct {
+			Errors []api2go.Error `json:"errors"`
+		}
+		if json.Unmarshal(result, &document) == nil {
+			responseError.Errors = document.Errors
+		}
+
+		return resp.StatusCode, nil, responseError
+	}
+
+	return resp.StatusCode, result, nil
+}
@@ -0,0 +1,233 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+
+	"github.com/manyminds/api2go"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type Article struct {
+	ID       string   `jsonapi:"primary,articles"`
+	Title    string   `jsonapi:"attr,title"`
+	AuthorID string   `jsonapi:"relation,author,people"`
+	TagIDs   []string `jsonapi:"relation,tags"`
+}
+
+type articleSource struct {
+	articles map[string]*Article
+	lastID   int
+}
+
+func (s *articleSource) sorted() []*Article {
+	result := []*Article{}
+	for _, article := range s.articles {
+		result = append(result, article)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].ID < result[j].ID
+	})
+
+	return result
+}
+
+func (s *articleSource) FindAll(req api2go.Request) ([]*Article, error) {
+	result := []*Article{}
+	for _, article := range s.sorted() {
+		if title, ok := req.QueryParams["filter[title]"]; ok && title[0] != article.Title {
+			continue
+		}
+		result = append(result, article)
+	}
+
+	return result, nil
+}
+
+func (s *articleSource) PaginatedFindAll(req api2go.Request) (uint, []*Article, error) {
+	all := s.sorted()
+	number, _ := strconv.Atoi(req.Pagination["number"])
+	size, _ := strconv.Atoi(req.Pagination["size"])
+	start := (number - 1) * size
+	end := start + size
+	if end > len(all) {
+		end = len(all)
+	}
+
+	return uint(len(all)), all[start:end], nil
+}
+
+func (s *articleSource) FindOne(ID string, req api2go.Request) (*Article, error) {
+	article, ok := s.articles[ID]
+	if !ok {
+		return nil, api2go.NewHTTPError(nil, "article not found", http.StatusNotFound)
+	}
+
+	copied := *article
+	return &copied, nil
+}
+
+func (s *articleSource) Create(article *Article, req api2go.Request) (*Article, error) {
+	if article.Title == "" {
+		httpErr := api2go.NewHTTPError(errors.New("invalid"), "invalid", http.StatusUnprocessableEntity)
+		httpErr.Errors = []api2go.Error{
+			{Title: "invalid", Detail: "title must not be empty"},
+			{Title: "invalid", Detail: "another error"},
+		}
+		return nil, httpErr
+	}
+
+	s.lastID++
+	article.ID = strconv.Itoa(s.lastID)
+	s.articles[article.ID] = article
+	return article, nil
+}
+
+func (s *articleSource) Update(article *Article, req api2go.Request) (*Article, error) {
+	s.articles[article.ID] = article
+	return article, nil
+}
+
+func (s *articleSource) Delete(ID string, req api2go.Request) error {
+	delete(s.articles, ID)
+	return nil
+}
+
+var _ = Describe("Client", func() {
+	var (
+		server   *httptest.Server
+		source   *articleSource
+		articles *Resource[*Article]
+		ctx      context.Context
+		headers  http.Header
+	)
+
+	BeforeEach(func() {
+		source = &articleSource{articles: map[string]*Article{
+			"1": {ID: "1", Title: "First", AuthorID: "5", TagIDs: []string{"go"}},
+			"2": {ID: "2", Title: "Second"},
+			"3": {ID: "3", Title: "Third"},
+		}, lastID: 3}
+
+		api := api2go.NewAPIWithResolver("v1", api2go.NewStaticResolver(""))
+		api2go.AddTypedResource[*Article](api, source)
+		handler := api.Handler()
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			headers = r.Header
+			handler.ServeHTTP(w, r)
+		}))
+
+		c, err := New(server.URL + "/v1/")
+		Expect(err).ToNot(HaveOccurred())
+		c.SetHeader("Authorization", "Bearer token")
+		articles = NewResource[*Article](c)
+		ctx = context.Background()
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("needs an absolute base URL", func() {
+		_, err := New("/v1")
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("uses the api2go resource name", func() {
+		Expect(articles.Name()).To(Equal("articles"))
+	})
+
+	It("gets a resource", func() {
+		article, err := articles.Get(ctx, "1", nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(article).To(Equal(&Article{ID: "1", Title: "First", AuthorID: "5", TagIDs: []string{"go"}}))
+		Expect(headers.Get("Authorization")).To(Equal("Bearer token"))
+		Expect(headers.Get("Accept")).To(Equal("application/vnd.api+json"))
+	})
+
+	It("decodes error documents", func() {
+		_, err := articles.Get(ctx, "42", nil)
+		var responseError *ResponseError
+		Expect(errors.As(err, &responseError)).To(BeTrue())
+		Expect(responseError.StatusCode).To(Equal(http.StatusNotFound))
+		Expect(responseError.Errors).To(Equal([]api2go.Error{{Status: "404", Title: "article not found"}}))
+		Expect(err).To(MatchError("jsonapi request failed with status 404: article not found"))
+	})
+
+	It("lists resources with a query", func() {
+		result, err := articles.List(ctx, NewQuery().Filter("title", "Second"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result).To(Equal([]*Article{{ID: "2", Title: "Second", TagIDs: []string{}}}))
+	})
+
+	It("iterates over all pages", func() {
+		iterator := articles.Iterate(ctx, NewQuery().Page("number", "1").Page("size", "2"))
+		IDs := []string{}
+		for iterator.Next() {
+			IDs = append(IDs, iterator.Value().ID)
+		}
+		Expect(iterator.Err()).ToNot(HaveOccurred())
+		Expect(IDs).To(Equal([]string{"1", "2", "3"}))
+	})
+
+	It("stops iterating on errors", func() {
+		server.Close()
+		iterator := articles.Iterate(ctx, nil)
+		Expect(iterator.Next()).To(BeFalse())
+		Expect(iterator.Err()).To(HaveOccurred())
+	})
+
+	It("creates resources", func() {
+		article, err := articles.Create(ctx, &Article{Title: "New", AuthorID: "6"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(article.ID).To(Equal("4"))
+		Expect(source.articles["4"]).To(Equal(&Article{ID: "4", Title: "New", AuthorID: "6", TagIDs: []string{}}))
+	})
+
+	It("returns all errors of a document", func() {
+		_, err := articles.Create(ctx, &Article{})
+		Expect(err).To(MatchError("jsonapi request failed with status 422: title must not be empty and 1 more errors"))
+	})
+
+	It("updates resources", func() {
+		article, err := articles.Update(ctx, &Article{ID: "2", Title: "Changed"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(article.Title).To(Equal("Changed"))
+		Expect(source.articles["2"].Title).To(Equal("Changed"))
+	})
+
+	It("deletes resources", func() {
+		Expect(articles.Delete(ctx, "2")).To(Succeed())
+		Expect(source.articles).ToNot(HaveKey("2"))
+	})
+
+	It("edits relationships", func() {
+		Expect(articles.ReplaceToOne(ctx, "1", "author", "7")).To(Succeed())
+		Expect(source.articles["1"].AuthorID).To(Equal("7"))
+		Expect(articles.ReplaceToOne(ctx, "1", "author", "")).To(Succeed())
+		Expect(source.articles["1"].AuthorID).To(BeEmpty())
+
+		Expect(articles.AddToMany(ctx, "1", "tags", []string{"api", "json"})).To(Succeed())
+		Expect(articles.RemoveFromMany(ctx, "1", "tags", []string{"go"})).To(Succeed())
+		Expect(source.articles["1"].TagIDs).To(Equal([]string{"api", "json"}))
+
+		Expect(articles.ReplaceToMany(ctx, "1", "tags", []string{"client"})).To(Succeed())
+		Expect(source.articles["1"].TagIDs).To(Equal([]string{"client"}))
+	})
+
+	It("builds queries", func() {
+		query := NewQuery().
+			Include("author").
+			Include("tags").
+			Fields("articles", "title", "author").
+			Sort("-title").
+			Filter("title", "a", "b").
+			Set("custom", "value")
+		Expect(query.Encode()).To(Equal("custom=value&fields%5Barticles%5D=title%2Cauthor&filter%5Btitle%5D=a%2Cb&include=author%2Ctags&sort=-title"))
+	})
+})
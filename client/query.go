@@ -0,0 +1,67 @@
+package client
+
+import (
+	"net/url"
+	"strings"
+)
+
+// Query builds the query parameters of a request. All methods return the query
+// so that calls can be chained:
+//
+//	client.NewQuery().Include("author").Sort("-created").Filter("published", "true")
+type Query struct {
+	values url.Values
+}
+
+// NewQuery returns an empty query
+func NewQuery() *Query {
+	return &Query{values: url.Values{}}
+}
+
+// Include requests the related resources of the given relationship paths,
+// e.g. "comments.author"
+func (q *Query) Include(paths ...string) *Query {
+	return q.appendList("include", paths)
+}
+
+// Fields requests only the given fields of a resource type
+func (q *Query) Fields(resourceType string, fields ...string) *Query {
+	return q.appendList("fields["+resourceType+"]", fields)
+}
+
+// Sort sorts by the given fields, a leading "-" sorts descending
+func (q *Query) Sort(fields ...string) *Query {
+	return q.appendList("sort", fields)
+}
+
+// Filter adds a filter[name] parameter, multiple values are separated by commas
+func (q *Query) Filter(name string, values ...string) *Query {
+	return q.appendList("filter["+name+"]", values)
+}
+
+// Page adds a page[name] parameter, e.g. Page("number", "2")
+func (q *Query) Page(name, value string) *Query {
+	q.values.Set("page["+name+"]", value)
+	return q
+}
+
+// Set sets any other query parameter
+func (q *Query) Set(key, value string) *Query {
+	q.values.Set(key, value)
+	return q
+}
+
+// Encode returns the url encoded query
+func (q *Query) Encode() string {
+	return q.values.Encode()
+}
+
+// appendList appends values to the comma separated list of key
+func (q *Query) appendList(key string, values []string) *Query {
+	if existing := q.values.Get(key); existing != "" {
+		values = append([]string{existing}, values...)
+	}
+
+	q.values.Set(key, strings.Join(values, ","))
+	return q
+}
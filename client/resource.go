@@ -0,0 +1,287 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"reflect"
+
+	"github.com/manyminds/api2go/jsonapi"
+)
+
+// Resource provides the operations for one resource type. T is the model, it
+// can be a struct or a pointer to a struct.
+type Resource[T any] struct {
+	client     *Client
+	name       string
+	references map[string]string
+}
+
+// NewResource returns the resource for the model type T. The name in the URLs
+// is determined like api2go does it for AddResource.
+func NewResource[T any](client *Client) *Resource[T] {
+	value := newValue[T]()
+	valueType := reflect.TypeOf(value)
+	if valueType.Kind() == reflect.Ptr {
+		valueType = valueType.Elem()
+	}
+
+	prototype := jsonapi.WrapTagged(value)
+	name := jsonapi.TypeName(valueType.Name())
+	if namer, ok := prototype.(jsonapi.EntityNamer); ok {
+		name = namer.GetName()
+	}
+
+	references := map[string]string{}
+	if referencer, ok := prototype.(jsonapi.MarshalReferences); ok {
+		for _, reference := range referencer.GetReferences() {
+			references[reference.Name] = reference.Type
+		}
+	}
+
+	return &Resource[T]{client: client, name: name, references: references}
+}
+
+// Name returns the name of the resource in the URLs
+func (r *Resource[T]) Name() string {
+	return r.name
+}
+
+// Get returns the resource with the given ID, query can be nil
+func (r *Resource[T]) Get(ctx context.Context, ID string, query *Query) (T, error) {
+	result := newValue[T]()
+
+	target, err := r.client.resolve(r.path(ID), query)
+	if err != nil {
+		return result, err
+	}
+
+	_, body, err := r.client.do(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return result, err
+	}
+
+	err = jsonapi.Unmarshal(body, pointerTo(&result))
+	return result, err
+}
+
+// List returns all resources of the first page, use Iterate to get the
+// resources of all pages. query can be nil.
+func (r *Resource[T]) List(ctx context.Context, query *Query) ([]T, error) {
+	target, err := r.client.resolve(r.name, query)
+	if err != nil {
+		return nil, err
+	}
+
+	result, _, err := r.list(ctx, target)
+	return result, err
+}
+
+// list returns the resources at target and the link to the next page
+func (r *Resource[T]) list(ctx context.Context, target string) ([]T, string, error) {
+	_, body, err := r.client.do(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return nil, "", err
+	}
+
+	result := []T{}
+	document, err := jsonapi.UnmarshalDocument(body, &result)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return result, document.Links["next"].Href, nil
+}
+
+// Create creates obj and returns the created resource. If the server does not
+// return it, obj is returned.
+func (r *Resource[T]) Create(ctx context.Context, obj T) (T, error) {
+	return r.send(ctx, http.MethodPost, r.name, obj)
+}
+
+// Update updates obj and returns the updated resource. If the server does not
+// return it, obj is returned.
+func (r *Resource[T]) Update(ctx context.Context, obj T) (T, error) {
+	identifier, ok := jsonapi.WrapTagged(obj).(jsonapi.MarshalIdentifier)
+	if !ok {
+		return obj, errors.New("the model must implement jsonapi.MarshalIdentifier or use jsonapi struct tags")
+	}
+
+	return r.send(ctx, http.MethodPatch, r.path(identifier.GetID()), obj)
+}
+
+func (r *Resource[T]) send(ctx context.Context, method, path string, obj T) (T, error) {
+	payload, err := jsonapi.Marshal(obj)
+	if err != nil {
+		return obj, err
+	}
+
+	target, err := r.client.resolve(path, nil)
+	if err != nil {
+		return obj, err
+	}
+
+	status, body, err := r.client.do(ctx, method, target, payload)
+	if err != nil || status == http.StatusAccepted || status == http.StatusNoContent || len(body) == 0 {
+		return obj, err
+	}
+
+	result := newValue[T]()
+	err = jsonapi.Unmarshal(body, pointerTo(&result))
+	return result, err
+}
+
+// Delete deletes the resource with the given ID
+func (r *Resource[T]) Delete(ctx context.Context, ID string) error {
+	target, err := r.client.resolve(r.path(ID), nil)
+	if err != nil {
+		return err
+	}
+
+	_, _, err = r.client.do(ctx, http.MethodDelete, target, nil)
+	return err
+}
+
+// ReplaceToOne replaces a to-one relationship, an empty relatedID removes it
+func (r *Resource[T]) ReplaceToOne(ctx context.Context, ID, relation, relatedID string) error {
+	data := &jsonapi.RelationshipDataContainer{}
+	if relatedID != "" {
+		data.DataObject = &jsonapi.RelationshipData{Type: r.relatedType(relation), ID: relatedID}
+	}
+
+	return r.sendRelationship(ctx, http.MethodPatch, ID, relation, data)
+}
+
+// ReplaceToMany replaces all IDs of a to-many relationship
+func (r *Resource[T]) ReplaceToMany(ctx context.Context, ID, relation string, relatedIDs []string) error {
+	return r.sendRelationship(ctx, http.MethodPatch, ID, relation, r.relationshipData(relation, relatedIDs))
+}
+
+// AddToMany adds IDs to a to-many relationship
+func (r *Resource[T]) AddToMany(ctx context.Context, ID, relation string, relatedIDs []string) error {
+	return r.sendRelationship(ctx, http.MethodPost, ID, relation, r.relationshipData(relation, relatedIDs))
+}
+
+// RemoveFromMany removes IDs from a to-many relationship
+func (r *Resource[T]) RemoveFromMany(ctx context.Context, ID, relation string, relatedIDs []string) error {
+	return r.sendRelationship(ctx, http.MethodDelete, ID, relation, r.relationshipData(relation, relatedIDs))
+}
+
+func (r *Resource[T]) relationshipData(relation string, relatedIDs []string) *jsonapi.RelationshipDataContainer {
+	data := &jsonapi.RelationshipDataContainer{DataArray: []jsonapi.RelationshipData{}}
+	for _, relatedID := range relatedIDs {
+		data.DataArray = append(data.DataArray, jsonapi.RelationshipData{Type: r.relatedType(relation), ID: relatedID})
+	}
+
+	return data
+}
+
+func (r *Resource[T]) sendRelationship(ctx context.Context, method, ID, relation string, data *jsonapi.RelationshipDataContainer) error {
+	payload, err := json.Marshal(map[string]interface{}{"data": data})
+	if err != nil {
+		return err
+	}
+
+	target, err := r.client.resolve(r.path(ID)+"/relationships/"+url.PathEscape(jsonapi.MemberName(relation)), nil)
+	if err != nil {
+		return err
+	}
+
+	_, _, err = r.client.do(ctx, method, target, payload)
+	return err
+}
+
+// relatedType returns the type of a relationship, it falls back to the type
+// name of the relationship if the model does not know it
+func (r *Resource[T]) relatedType(relation string) string {
+	if relatedType, ok := r.references[relation]; ok {
+		return relatedType
+	}
+
+	return jsonapi.TypeName(relation)
+}
+
+func (r *Resource[T]) path(ID string) string {
+	return r.name + "/" + url.PathEscape(ID)
+}
+
+// Iterate returns an iterator over the resources of all pages, it follows the
+// next links of the responses. query can be nil.
+func (r *Resource[T]) Iterate(ctx context.Context, query *Query) *Iterator[T] {
+	target, err := r.client.resolve(r.name, query)
+	return &Iterator[T]{resource: r, ctx: ctx, next: target, err: err}
+}
+
+// Iterator iterates over the resources of all pages:
+//
+//	iterator := posts.Iterate(ctx, client.NewQuery().Page("size", "100"))
+//	for iterator.Next() {
+//		post := iterator.Value()
+//	}
+//	if err := iterator.Err(); err != nil {
+//	}
+type Iterator[T any] struct {
+	resource *Resource[T]
+	ctx      context.Context
+	next     string
+	page     []T
+	current  T
+	err      error
+}
+
+// Next advances to the next resource and loads the next page if necessary, it
+// returns false if there are no more resources or an error occurred
+func (i *Iterator[T]) Next() bool {
+	for len(i.page) == 0 {
+		if i.err != nil || i.next == "" {
+			return false
+		}
+
+		var next string
+		i.page, next, i.err = i.resource.list(i.ctx, i.next)
+		if i.err != nil {
+			return false
+		}
+
+		i.next = ""
+		if next != "" {
+			i.next, i.err = i.resource.client.resolve(next, nil)
+		}
+	}
+
+	i.current = i.page[0]
+	i.page = i.page[1:]
+	return true
+}
+
+// Value returns the current resource
+func (i *Iterator[T]) Value() T {
+	return i.current
+}
+
+// Err returns the error that stopped the iteration
+func (i *Iterator[T]) Err() error {
+	return i.err
+}
+
+// newValue returns an empty T, for pointer types it points to an empty struct
+func newValue[T any]() T {
+	var value T
+	valueType := reflect.TypeOf(value)
+	if valueType != nil && valueType.Kind() == reflect.Ptr {
+		value = reflect.New(valueType.Elem()).Interface().(T)
+	}
+
+	return value
+}
+
+// pointerTo returns the unmarshal target for value, pointers are used directly
+func pointerTo[T any](value *T) interface{} {
+	if reflect.TypeOf(*value) != nil && reflect.TypeOf(*value).Kind() == reflect.Ptr {
+		return *value
+	}
+
+	return value
+}
@@ -0,0 +1,339 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// generate returns the formatted source code with the implementations for all
+// models of f
+func generate(f *file) ([]byte, error) {
+	body := &bytes.Buffer{}
+	for _, model := range f.models {
+		generateModel(body, model)
+	}
+
+	code := body.String()
+	standard := []string{}
+	for _, name := range []string{"fmt", "strconv"} {
+		if uses(code, name) {
+			standard = append(standard, `"`+name+`"`)
+		}
+	}
+
+	other := []string{}
+	if uses(code, "jsonapi") {
+		other = append(other, `"github.com/manyminds/api2go/jsonapi"`)
+	}
+	for name, importPath := range f.imports {
+		if name != "jsonapi" && uses(code, name) {
+			other = append(other, name+" "+importPath)
+		}
+	}
+	sort.Slice(other, func(i, j int) bool {
+		return importPath(other[i]) < importPath(other[j])
+	})
+
+	result := &bytes.Buffer{}
+	fmt.Fprintf(result, "// Code generated by api2go-gen. DO NOT EDIT.\n\npackage %s\n\n", f.pkg)
+	if len(standard)+len(other) > 0 {
+		groups := []string{}
+		for _, group := range [][]string{standard, other} {
+			if len(group) > 0 {
+				groups = append(groups, strings.Join(group, "\n"))
+			}
+		}
+		fmt.Fprintf(result, "import (\n%s\n)\n\n", strings.Join(groups, "\n\n"))
+	}
+	result.WriteString(code)
+
+	return format.Source(result.Bytes())
+}
+
+// importPath returns the quoted path of an import spec
+func importPath(spec string) string {
+	return spec[strings.Index(spec, `"`):]
+}
+
+// uses returns whether code uses the package name
+func uses(code, name string) bool {
+	return regexp.MustCompile(`(^|[^\w.])` + regexp.QuoteMeta(name) + `\.`).MatchString(code)
+}
+
+func generateModel(w *bytes.Buffer, m *model) {
+	r := receiver(m.name)
+	id := r + "." + m.id.field
+
+	fmt.Fprintf(w, "// GetID returns the ID of %s\n", m.name)
+	fmt.Fprintf(w, "func (%s %s) GetID() string {\n\treturn %s\n}\n\n", r, m.name, formatID(id, m.id.kind))
+
+	fmt.Fprintf(w, "// SetID sets the ID of %s\n", m.name)
+	fmt.Fprintf(w, "func (%s *%s) SetID(ID string) error {\n", r, m.name)
+	if m.id.kind == "string" {
+		fmt.Fprintf(w, "\t%s = ID\n\treturn nil\n}\n\n", id)
+	} else {
+		fmt.Fprintf(w, "\tif ID == \"\" {\n\t\t%s = 0\n\t\treturn nil\n\t}\n\n", id)
+		value := parseID(w, "ID", m.id.kind)
+		fmt.Fprintf(w, "\t%s = %s\n\treturn nil\n}\n\n", id, value)
+	}
+
+	if len(m.relations) == 0 {
+		return
+	}
+
+	var toOne, toMany, structs []*relation
+	for _, relation := range m.relations {
+		if relation.toMany {
+			toMany = append(toMany, relation)
+		} else {
+			toOne = append(toOne, relation)
+		}
+		if relation.structs() {
+			structs = append(structs, relation)
+		}
+	}
+
+	fmt.Fprintf(w, "// GetReferences returns all relationships of %s\n", m.name)
+	fmt.Fprintf(w, "func (%s %s) GetReferences() []jsonapi.Reference {\n\treturn []jsonapi.Reference{\n", r, m.name)
+	for _, relation := range m.relations {
+		fmt.Fprintf(w, "\t\t{Type: %s, Name: %q, Relationship: %s},\n", relation.typ, relation.name, relationshipType(relation))
+	}
+	fmt.Fprintf(w, "\t}\n}\n\n")
+
+	fmt.Fprintf(w, "// GetReferencedIDs returns the IDs of all related resources of %s\n", m.name)
+	fmt.Fprintf(w, "func (%s %s) GetReferencedIDs() []jsonapi.ReferenceID {\n\tresult := []jsonapi.ReferenceID{}\n", r, m.name)
+	for _, relation := range m.relations {
+		forEachElement(w, r, relation, func(element string) {
+			fmt.Fprintf(w, "result = append(result, jsonapi.ReferenceID{ID: %s, Type: %s, Name: %q, Relationship: %s})\n",
+				relatedID(element, relation), relation.typ, relation.name, relationshipType(relation))
+		})
+	}
+	fmt.Fprintf(w, "\n\treturn result\n}\n\n")
+
+	if len(structs) > 0 {
+		fmt.Fprintf(w, "// GetReferencedStructs returns all related structs of %s\n", m.name)
+		fmt.Fprintf(w, "func (%s %s) GetReferencedStructs() []jsonapi.MarshalIdentifier {\n\tresult := []jsonapi.MarshalIdentifier{}\n", r, m.name)
+		for _, relation := range structs {
+			forEachElement(w, r, relation, func(element string) {
+				fmt.Fprintf(w, "result = append(result, %s)\n", element)
+			})
+		}
+		fmt.Fprintf(w, "\n\treturn result\n}\n\n")
+	}
+
+	if len(toOne) > 0 {
+		fmt.Fprintf(w, "// SetToOneReferenceID sets the reference ID of a to-one relationship of %s\n", m.name)
+		fmt.Fprintf(w, "func (%s *%s) SetToOneReferenceID(name, ID string) error {\n\tswitch name {\n", r, m.name)
+		for _, relation := range toOne {
+			field := r + "." + relation.field
+			fmt.Fprintf(w, "\tcase %q:\n", relation.name)
+			if relation.kind == "string" {
+				fmt.Fprintf(w, "\t\t%s = ID\n\t\treturn nil\n", field)
+				continue
+			}
+
+			fmt.Fprintf(w, "\t\tif ID == \"\" {\n\t\t\t%s = %s\n\t\t\treturn nil\n\t\t}\n\n", field, zeroValue(elementType(relation)))
+			if relation.structs() {
+				newElement(w, relation)
+				fmt.Fprintf(w, "\t\t%s = element\n\t\treturn nil\n", field)
+			} else {
+				fmt.Fprintf(w, "\t\t%s = %s\n\t\treturn nil\n", field, parseID(w, "ID", relation.kind))
+			}
+		}
+		fmt.Fprintf(w, "\t}\n\n\treturn fmt.Errorf(\"unknown to-one relationship %%s of %s\", name)\n}\n\n", m.name)
+	}
+
+	if len(toMany) == 0 {
+		return
+	}
+
+	fmt.Fprintf(w, "// SetToManyReferenceIDs sets the reference IDs of a to-many relationship of %s\n", m.name)
+	fmt.Fprintf(w, "func (%s *%s) SetToManyReferenceIDs(name string, IDs []string) error {\n\tswitch name {\n", r, m.name)
+	for _, relation := range toMany {
+		fmt.Fprintf(w, "\tcase %q:\n", relation.name)
+		if relation.kind == "string" {
+			fmt.Fprintf(w, "\t\t%s.%s = IDs\n\t\treturn nil\n", r, relation.field)
+			continue
+		}
+
+		fmt.Fprintf(w, "\t\telements := make([]%s, 0, len(IDs))\n\t\tfor _, ID := range IDs {\n", elementType(relation))
+		newElement(w, relation)
+		fmt.Fprintf(w, "\t\t\telements = append(elements, element)\n\t\t}\n")
+		fmt.Fprintf(w, "\t\t%s.%s = elements\n\t\treturn nil\n", r, relation.field)
+	}
+	fmt.Fprintf(w, "\t}\n\n\treturn fmt.Errorf(\"unknown to-many relationship %%s of %s\", name)\n}\n\n", m.name)
+
+	fmt.Fprintf(w, "// AddToManyIDs adds reference IDs to a to-many relationship of %s\n", m.name)
+	fmt.Fprintf(w, "func (%s *%s) AddToManyIDs(name string, IDs []string) error {\n\tswitch name {\n", r, m.name)
+	for _, relation := range toMany {
+		field := r + "." + relation.field
+		if relation.kind == "string" {
+			fmt.Fprintf(w, "\tcase %q:\n\t\t%s = append(%s, IDs...)\n\t\treturn nil\n", relation.name, field, field)
+			continue
+		}
+
+		fmt.Fprintf(w, "\tcase %q:\n\t\tfor _, ID := range IDs {\n", relation.name)
+		newElement(w, relation)
+		fmt.Fprintf(w, "\t\t\t%s = append(%s, element)\n\t\t}\n\t\treturn nil\n", field, field)
+	}
+	fmt.Fprintf(w, "\t}\n\n\treturn fmt.Errorf(\"unknown to-many relationship %%s of %s\", name)\n}\n\n", m.name)
+
+	fmt.Fprintf(w, "// DeleteToManyIDs removes reference IDs from a to-many relationship of %s\n", m.name)
+	fmt.Fprintf(w, "func (%s *%s) DeleteToManyIDs(name string, IDs []string) error {\n", r, m.name)
+	fmt.Fprintf(w, "\tdeleted := make(map[string]bool, len(IDs))\n\tfor _, ID := range IDs {\n\t\tdeleted[ID] = true\n\t}\n\n\tswitch name {\n")
+	for _, relation := range toMany {
+		field := r + "." + relation.field
+		deleted := "deleted[" + relatedID("element", relation) + "]"
+		if relation.pointer {
+			deleted = "element != nil && " + deleted
+		}
+
+		fmt.Fprintf(w, "\tcase %q:\n", relation.name)
+		fmt.Fprintf(w, "\t\telements := make([]%s, 0, len(%s))\n\t\tfor _, element := range %s {\n", elementType(relation), field, field)
+		fmt.Fprintf(w, "\t\t\tif %s {\n\t\t\t\tcontinue\n\t\t\t}\n\t\t\telements = append(elements, element)\n\t\t}\n", deleted)
+		fmt.Fprintf(w, "\t\t%s = elements\n\t\treturn nil\n", field)
+	}
+	fmt.Fprintf(w, "\t}\n\n\treturn fmt.Errorf(\"unknown to-many relationship %%s of %s\", name)\n}\n\n", m.name)
+}
+
+// receiver returns the receiver name for a type
+func receiver(name string) string {
+	return string(unicode.ToLower([]rune(name)[0]))
+}
+
+func relationshipType(relation *relation) string {
+	if relation.toMany {
+		return "jsonapi.ToManyRelationship"
+	}
+
+	return "jsonapi.ToOneRelationship"
+}
+
+// elementType returns the go type of one element of a relation
+func elementType(relation *relation) string {
+	if relation.pointer {
+		return "*" + relation.element
+	}
+
+	return relation.element
+}
+
+// forEachElement writes a block that calls body for every set element of a
+// relation, nil pointers, empty IDs and structs with the ID of an empty struct
+// are skipped
+func forEachElement(w *bytes.Buffer, r string, relation *relation, body func(element string)) {
+	field := r + "." + relation.field
+	element := field
+	if relation.toMany {
+		element = "element"
+		fmt.Fprintf(w, "\tfor _, element := range %s {\n", field)
+		if relation.pointer {
+			fmt.Fprintf(w, "\t\tif element == nil {\n\t\t\tcontinue\n\t\t}\n")
+		}
+		body(element)
+		fmt.Fprintf(w, "\t}\n")
+		return
+	}
+
+	switch {
+	case relation.pointer:
+		fmt.Fprintf(w, "\tif %s != nil {\n", element)
+	case relation.structs():
+		// the ID of an empty struct is not necessarily empty, e.g. "0"
+		fmt.Fprintf(w, "\tif %s.GetID() != (&%s{}).GetID() {\n", element, relation.element)
+	default:
+		fmt.Fprintf(w, "\tif %s != %s {\n", element, zeroValue(relation.kind))
+	}
+	body(element)
+	fmt.Fprintf(w, "\t}\n")
+}
+
+// relatedID returns the expression for the ID of one related element
+func relatedID(element string, relation *relation) string {
+	if relation.structs() {
+		return element + ".GetID()"
+	}
+
+	return formatID(element, relation.kind)
+}
+
+// newElement writes the statements that create the related element for ID
+func newElement(w *bytes.Buffer, relation *relation) {
+	switch {
+	case relation.pointer:
+		fmt.Fprintf(w, "element := &%s{}\n", relation.element)
+		fmt.Fprintf(w, "if err := element.SetID(ID); err != nil {\nreturn err\n}\n")
+	case relation.structs():
+		fmt.Fprintf(w, "var element %s\n", relation.element)
+		fmt.Fprintf(w, "if err := element.SetID(ID); err != nil {\nreturn err\n}\n")
+	default:
+		fmt.Fprintf(w, "element := %s\n", parseID(w, "ID", relation.kind))
+	}
+}
+
+// formatID returns the expression for the string representation of an ID
+func formatID(value, kind string) string {
+	switch {
+	case kind == "string":
+		return value
+	case strings.HasPrefix(kind, "uint"):
+		if kind == "uint64" {
+			return "strconv.FormatUint(" + value + ", 10)"
+		}
+		return "strconv.FormatUint(uint64(" + value + "), 10)"
+	default:
+		if kind == "int64" {
+			return "strconv.FormatInt(" + value + ", 10)"
+		}
+		return "strconv.FormatInt(int64(" + value + "), 10)"
+	}
+}
+
+// parseID writes the statements that parse ID and returns the expression for
+// the parsed value
+func parseID(w *bytes.Buffer, ID, kind string) string {
+	if kind == "string" {
+		return ID
+	}
+
+	parse := "ParseInt"
+	if strings.HasPrefix(kind, "uint") {
+		parse = "ParseUint"
+	}
+
+	fmt.Fprintf(w, "number, err := strconv.%s(%s, 10, %d)\nif err != nil {\nreturn err\n}\n", parse, ID, bitSize(kind))
+	if kind == "int64" || kind == "uint64" {
+		return "number"
+	}
+
+	return kind + "(number)"
+}
+
+// bitSize returns the bit size argument of strconv for an ID type
+func bitSize(kind string) int {
+	if kind == "int64" || kind == "uint64" {
+		return 64
+	}
+
+	return idBits[kind]
+}
+
+// zeroValue returns the zero value of an ID or element type
+func zeroValue(goType string) string {
+	switch {
+	case goType == "string":
+		return `""`
+	case strings.HasPrefix(goType, "*"):
+		return "nil"
+	default:
+		if _, ok := idBits[goType]; ok {
+			return "0"
+		}
+		return goType + "{}"
+	}
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/manyminds/api2go/cmd/api2go-gen/internal/fixtures"
+	"github.com/manyminds/api2go/jsonapi"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("api2go-gen", func() {
+	Context("generating code", func() {
+		It("matches the generated fixtures", func() {
+			parsed, err := parseFile("internal/fixtures/models.go", nil)
+			Expect(err).ToNot(HaveOccurred())
+
+			code, err := generate(parsed)
+			Expect(err).ToNot(HaveOccurred())
+
+			expected, err := os.ReadFile("internal/fixtures/models_api2go.go")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(code)).To(Equal(string(expected)), "run go generate ./cmd/api2go-gen/...")
+		})
+
+		It("writes the output file", func() {
+			dir, err := os.MkdirTemp("", "api2go-gen")
+			Expect(err).ToNot(HaveOccurred())
+			defer os.RemoveAll(dir)
+
+			target := filepath.Join(dir, "models_api2go.go")
+			Expect(run("internal/fixtures/models.go", target)).To(Succeed())
+			Expect(target).To(BeAnExistingFile())
+			Expect(outputName("dir/models.go")).To(Equal("dir/models_api2go.go"))
+		})
+
+		It("imports the packages of related structs", func() {
+			parsed, err := parseFile("models.go", `package models
+
+import other "example.com/models"
+
+type Post struct {
+	ID     string       `+"`jsonapi:\"primary,posts\"`"+`
+	Author *other.User  `+"`jsonapi:\"relation\"`"+`
+}
+`)
+			Expect(err).ToNot(HaveOccurred())
+
+			code, err := generate(parsed)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(code)).To(ContainSubstring(`other "example.com/models"`))
+			Expect(string(code)).To(ContainSubstring(`element := &other.User{}`))
+			Expect(string(code)).To(ContainSubstring(`Type: jsonapi.TypeName("User"), Name: "author"`))
+		})
+
+		DescribeTable("reports invalid models",
+			func(src, message string) {
+				_, err := parseFile("models.go", "package models\n\n"+src)
+				Expect(err).To(MatchError(ContainSubstring(message)))
+			},
+			Entry("missing type", "type Post struct {\n\tID string `jsonapi:\"primary\"`\n}\n", "primary tag of ID needs a type"),
+			Entry("two primary tags", "type Post struct {\n\tID string `jsonapi:\"primary,posts\"`\n\tKey string `jsonapi:\"primary,posts\"`\n}\n", "Post has more than one primary tag"),
+			Entry("invalid ID type", "type Post struct {\n\tID float64 `jsonapi:\"primary,posts\"`\n}\n", "primary field ID must be a string or an integer"),
+			Entry("invalid tag", "type Post struct {\n\tID string `jsonapi:\"identifier\"`\n}\n", `unknown jsonapi tag "identifier" of ID`),
+			Entry("invalid relation", "type Post struct {\n\tID string `jsonapi:\"primary,posts\"`\n\tAuthorID string `jsonapi:\"relation,author,users,x\"`\n}\n", "relation tag of AuthorID must be relation[,name[,type]]"),
+			Entry("ID pointer", "type Post struct {\n\tID string `jsonapi:\"primary,posts\"`\n\tAuthorID *string `jsonapi:\"relation,author\"`\n}\n", "relation AuthorID must not contain pointers to IDs"),
+		)
+
+		It("ignores structs without a primary tag", func() {
+			parsed, err := parseFile("models.go", "package models\n\ntype Post struct {\n\tID string `json:\"id\"`\n}\n\ntype Name string\n")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(parsed.models).To(BeEmpty())
+		})
+	})
+
+	Context("using the generated code", func() {
+		var post fixtures.Post
+
+		BeforeEach(func() {
+			post = fixtures.Post{
+				ID:         "1",
+				Title:      "Hello",
+				Author:     &fixtures.User{ID: 2, Name: "Jane"},
+				Comments:   []*fixtures.Comment{{ID: 3, Text: "First", AuthorID: "2"}},
+				CategoryID: 4,
+				TagIDs:     []string{"go"},
+				ReaderIDs:  []int64{5, 6},
+			}
+		})
+
+		It("marshals models", func() {
+			result, err := jsonapi.Marshal(post)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result).To(MatchJSON(`{
+				"data": {
+					"type": "posts",
+					"id": "1",
+					"attributes": {"title": "Hello"},
+					"relationships": {
+						"author": {"data": {"type": "users", "id": "2"}},
+						"editor": {"data": null},
+						"comments": {"data": [{"type": "notes", "id": "3"}]},
+						"likes": {"data": []},
+						"category": {"data": {"type": "categories", "id": "4"}},
+						"tags": {"data": [{"type": "labels", "id": "go"}]},
+						"readers": {"data": [{"type": "users", "id": "5"}, {"type": "users", "id": "6"}]}
+					}
+				},
+				"included": [
+					{"type": "users", "id": "2", "attributes": {"name": "Jane"}},
+					{
+						"type": "notes",
+						"id": "3",
+						"attributes": {"text": "First"},
+						"relationships": {"author": {"data": {"type": "users", "id": "2"}}}
+					}
+				]
+			}`))
+		})
+
+		It("unmarshals models", func() {
+			payload, err := jsonapi.Marshal(post)
+			Expect(err).ToNot(HaveOccurred())
+
+			var target fixtures.Post
+			Expect(jsonapi.Unmarshal(payload, &target)).To(Succeed())
+			Expect(target).To(Equal(fixtures.Post{
+				ID:         "1",
+				Title:      "Hello",
+				Author:     &fixtures.User{ID: 2, Name: "Jane"},
+				Comments:   []*fixtures.Comment{{ID: 3, Text: "First", AuthorID: "2"}},
+				Likes:      []fixtures.User{},
+				CategoryID: 4,
+				TagIDs:     []string{"go"},
+				ReaderIDs:  []int64{5, 6},
+			}))
+		})
+
+		It("edits to-many relationships", func() {
+			Expect(post.AddToManyIDs("readers", []string{"7"})).To(Succeed())
+			Expect(post.DeleteToManyIDs("readers", []string{"5"})).To(Succeed())
+			Expect(post.ReaderIDs).To(Equal([]int64{6, 7}))
+
+			Expect(post.AddToManyIDs("comments", []string{"8"})).To(Succeed())
+			Expect(post.DeleteToManyIDs("comments", []string{"3"})).To(Succeed())
+			Expect(post.Comments).To(Equal([]*fixtures.Comment{{ID: 8}}))
+
+			Expect(post.AddToManyIDs("readers", []string{"invalid"})).ToNot(Succeed())
+			Expect(post.DeleteToManyIDs("author", nil)).To(MatchError("unknown to-many relationship author of Post"))
+		})
+
+		It("resolves missing types with the type namer", func() {
+			jsonapi.SetTypeNamer(jsonapi.SingularTypeNames)
+			defer jsonapi.SetTypeNamer(nil)
+
+			Expect(post.GetReferences()).To(ContainElement(jsonapi.Reference{
+				Type:         "category",
+				Name:         "category",
+				Relationship: jsonapi.ToOneRelationship,
+			}))
+		})
+
+		It("resets to-one relationships", func() {
+			Expect(post.SetToOneReferenceID("author", "")).To(Succeed())
+			Expect(post.SetToOneReferenceID("category", "")).To(Succeed())
+			Expect(post.Author).To(BeNil())
+			Expect(post.CategoryID).To(BeZero())
+			Expect(post.SetToOneReferenceID("tags", "1")).To(MatchError("unknown to-one relationship tags of Post"))
+		})
+	})
+})
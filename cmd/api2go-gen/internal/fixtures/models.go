@@ -0,0 +1,36 @@
+// Package fixtures contains models for the api2go-gen tests, the generated code
+// is compared with models_api2go.go.
+package fixtures
+
+//go:generate go run github.com/manyminds/api2go/cmd/api2go-gen
+
+// Post has all kinds of relations
+type Post struct {
+	ID         string     `jsonapi:"primary,posts"`
+	Title      string     `jsonapi:"attr,title"`
+	Author     *User      `jsonapi:"relation,author"`
+	Editor     User       `jsonapi:"relation,editor"`
+	Comments   []*Comment `jsonapi:"relation,comments"`
+	Likes      []User     `jsonapi:"relation,likes"`
+	CategoryID uint32     `jsonapi:"relation,category"`
+	TagIDs     []string   `jsonapi:"relation,tags,labels"`
+	ReaderIDs  []int64    `jsonapi:"relation,readers,users"`
+}
+
+// User has an integer ID and no relations
+type User struct {
+	ID   int    `jsonapi:"primary,users"`
+	Name string `jsonapi:"attr,name"`
+}
+
+// Comment uses a type name that differs from the struct name
+type Comment struct {
+	ID       uint64 `jsonapi:"primary,notes"`
+	Text     string `jsonapi:"attr,text"`
+	AuthorID string `jsonapi:"relation,author,users"`
+}
+
+// Ignored has no primary tag
+type Ignored struct {
+	ID string `json:"id"`
+}
@@ -0,0 +1,336 @@
+// Code generated by api2go-gen. DO NOT EDIT.
+
+package fixtures
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/manyminds/api2go/jsonapi"
+)
+
+// GetID returns the ID of Post
+func (p Post) GetID() string {
+	return p.ID
+}
+
+// SetID sets the ID of Post
+func (p *Post) SetID(ID string) error {
+	p.ID = ID
+	return nil
+}
+
+// GetReferences returns all relationships of Post
+func (p Post) GetReferences() []jsonapi.Reference {
+	return []jsonapi.Reference{
+		{Type: "users", Name: "author", Relationship: jsonapi.ToOneRelationship},
+		{Type: "users", Name: "editor", Relationship: jsonapi.ToOneRelationship},
+		{Type: "notes", Name: "comments", Relationship: jsonapi.ToManyRelationship},
+		{Type: "users", Name: "likes", Relationship: jsonapi.ToManyRelationship},
+		{Type: jsonapi.TypeName("category"), Name: "category", Relationship: jsonapi.ToOneRelationship},
+		{Type: "labels", Name: "tags", Relationship: jsonapi.ToManyRelationship},
+		{Type: "users", Name: "readers", Relationship: jsonapi.ToManyRelationship},
+	}
+}
+
+// GetReferencedIDs returns the IDs of all related resources of Post
+func (p Post) GetReferencedIDs() []jsonapi.ReferenceID {
+	result := []jsonapi.ReferenceID{}
+	if p.Author != nil {
+		result = append(result, jsonapi.ReferenceID{ID: p.Author.GetID(), Type: "users", Name: "author", Relationship: jsonapi.ToOneRelationship})
+	}
+	if p.Editor.GetID() != (&User{}).GetID() {
+		result = append(result, jsonapi.ReferenceID{ID: p.Editor.GetID(), Type: "users", Name: "editor", Relationship: jsonapi.ToOneRelationship})
+	}
+	for _, element := range p.Comments {
+		if element == nil {
+			continue
+		}
+		result = append(result, jsonapi.ReferenceID{ID: element.GetID(), Type: "notes", Name: "comments", Relationship: jsonapi.ToManyRelationship})
+	}
+	for _, element := range p.Likes {
+		result = append(result, jsonapi.ReferenceID{ID: element.GetID(), Type: "users", Name: "likes", Relationship: jsonapi.ToManyRelationship})
+	}
+	if p.CategoryID != 0 {
+		result = append(result, jsonapi.ReferenceID{ID: strconv.FormatUint(uint64(p.CategoryID), 10), Type: jsonapi.TypeName("category"), Name: "category", Relationship: jsonapi.ToOneRelationship})
+	}
+	for _, element := range p.TagIDs {
+		result = append(result, jsonapi.ReferenceID{ID: element, Type: "labels", Name: "tags", Relationship: jsonapi.ToManyRelationship})
+	}
+	for _, element := range p.ReaderIDs {
+		result = append(result, jsonapi.ReferenceID{ID: strconv.FormatInt(element, 10), Type: "users", Name: "readers", Relationship: jsonapi.ToManyRelationship})
+	}
+
+	return result
+}
+
+// GetReferencedStructs returns all related structs of Post
+func (p Post) GetReferencedStructs() []jsonapi.MarshalIdentifier {
+	result := []jsonapi.MarshalIdentifier{}
+	if p.Author != nil {
+		result = append(result, p.Author)
+	}
+	if p.Editor.GetID() != (&User{}).GetID() {
+		result = append(result, p.Editor)
+	}
+	for _, element := range p.Comments {
+		if element == nil {
+			continue
+		}
+		result = append(result, element)
+	}
+	for _, element := range p.Likes {
+		result = append(result, element)
+	}
+
+	return result
+}
+
+// SetToOneReferenceID sets the reference ID of a to-one relationship of Post
+func (p *Post) SetToOneReferenceID(name, ID string) error {
+	switch name {
+	case "author":
+		if ID == "" {
+			p.Author = nil
+			return nil
+		}
+
+		element := &User{}
+		if err := element.SetID(ID); err != nil {
+			return err
+		}
+		p.Author = element
+		return nil
+	case "editor":
+		if ID == "" {
+			p.Editor = User{}
+			return nil
+		}
+
+		var element User
+		if err := element.SetID(ID); err != nil {
+			return err
+		}
+		p.Editor = element
+		return nil
+	case "category":
+		if ID == "" {
+			p.CategoryID = 0
+			return nil
+		}
+
+		number, err := strconv.ParseUint(ID, 10, 32)
+		if err != nil {
+			return err
+		}
+		p.CategoryID = uint32(number)
+		return nil
+	}
+
+	return fmt.Errorf("unknown to-one relationship %s of Post", name)
+}
+
+// SetToManyReferenceIDs sets the reference IDs of a to-many relationship of Post
+func (p *Post) SetToManyReferenceIDs(name string, IDs []string) error {
+	switch name {
+	case "comments":
+		elements := make([]*Comment, 0, len(IDs))
+		for _, ID := range IDs {
+			element := &Comment{}
+			if err := element.SetID(ID); err != nil {
+				return err
+			}
+			elements = append(elements, element)
+		}
+		p.Comments = elements
+		return nil
+	case "likes":
+		elements := make([]User, 0, len(IDs))
+		for _, ID := range IDs {
+			var element User
+			if err := element.SetID(ID); err != nil {
+				return err
+			}
+			elements = append(elements, element)
+		}
+		p.Likes = elements
+		return nil
+	case "tags":
+		p.TagIDs = IDs
+		return nil
+	case "readers":
+		elements := make([]int64, 0, len(IDs))
+		for _, ID := range IDs {
+			number, err := strconv.ParseInt(ID, 10, 64)
+			if err != nil {
+				return err
+			}
+			element := number
+			elements = append(elements, element)
+		}
+		p.ReaderIDs = elements
+		return nil
+	}
+
+	return fmt.Errorf("unknown to-many relationship %s of Post", name)
+}
+
+// AddToManyIDs adds reference IDs to a to-many relationship of Post
+func (p *Post) AddToManyIDs(name string, IDs []string) error {
+	switch name {
+	case "comments":
+		for _, ID := range IDs {
+			element := &Comment{}
+			if err := element.SetID(ID); err != nil {
+				return err
+			}
+			p.Comments = append(p.Comments, element)
+		}
+		return nil
+	case "likes":
+		for _, ID := range IDs {
+			var element User
+			if err := element.SetID(ID); err != nil {
+				return err
+			}
+			p.Likes = append(p.Likes, element)
+		}
+		return nil
+	case "tags":
+		p.TagIDs = append(p.TagIDs, IDs...)
+		return nil
+	case "readers":
+		for _, ID := range IDs {
+			number, err := strconv.ParseInt(ID, 10, 64)
+			if err != nil {
+				return err
+			}
+			element := number
+			p.ReaderIDs = append(p.ReaderIDs, element)
+		}
+		return nil
+	}
+
+	return fmt.Errorf("unknown to-many relationship %s of Post", name)
+}
+
+// DeleteToManyIDs removes reference IDs from a to-many relationship of Post
+func (p *Post) DeleteToManyIDs(name string, IDs []string) error {
+	deleted := make(map[string]bool, len(IDs))
+	for _, ID := range IDs {
+		deleted[ID] = true
+	}
+
+	switch name {
+	case "comments":
+		elements := make([]*Comment, 0, len(p.Comments))
+		for _, element := range p.Comments {
+			if element != nil && deleted[element.GetID()] {
+				continue
+			}
+			elements = append(elements, element)
+		}
+		p.Comments = elements
+		return nil
+	case "likes":
+		elements := make([]User, 0, len(p.Likes))
+		for _, element := range p.Likes {
+			if deleted[element.GetID()] {
+				continue
+			}
+			elements = append(elements, element)
+		}
+		p.Likes = elements
+		return nil
+	case "tags":
+		elements := make([]string, 0, len(p.TagIDs))
+		for _, element := range p.TagIDs {
+			if deleted[element] {
+				continue
+			}
+			elements = append(elements, element)
+		}
+		p.TagIDs = elements
+		return nil
+	case "readers":
+		elements := make([]int64, 0, len(p.ReaderIDs))
+		for _, element := range p.ReaderIDs {
+			if deleted[strconv.FormatInt(element, 10)] {
+				continue
+			}
+			elements = append(elements, element)
+		}
+		p.ReaderIDs = elements
+		return nil
+	}
+
+	return fmt.Errorf("unknown to-many relationship %s of Post", name)
+}
+
+// GetID returns the ID of User
+func (u User) GetID() string {
+	return strconv.FormatInt(int64(u.ID), 10)
+}
+
+// SetID sets the ID of User
+func (u *User) SetID(ID string) error {
+	if ID == "" {
+		u.ID = 0
+		return nil
+	}
+
+	number, err := strconv.ParseInt(ID, 10, 0)
+	if err != nil {
+		return err
+	}
+	u.ID = int(number)
+	return nil
+}
+
+// GetID returns the ID of Comment
+func (c Comment) GetID() string {
+	return strconv.FormatUint(c.ID, 10)
+}
+
+// SetID sets the ID of Comment
+func (c *Comment) SetID(ID string) error {
+	if ID == "" {
+		c.ID = 0
+		return nil
+	}
+
+	number, err := strconv.ParseUint(ID, 10, 64)
+	if err != nil {
+		return err
+	}
+	c.ID = number
+	return nil
+}
+
+// GetReferences returns all relationships of Comment
+func (c Comment) GetReferences() []jsonapi.Reference {
+	return []jsonapi.Reference{
+		{Type: "users", Name: "author", Relationship: jsonapi.ToOneRelationship},
+	}
+}
+
+// GetReferencedIDs returns the IDs of all related resources of Comment
+func (c Comment) GetReferencedIDs() []jsonapi.ReferenceID {
+	result := []jsonapi.ReferenceID{}
+	if c.AuthorID != "" {
+		result = append(result, jsonapi.ReferenceID{ID: c.AuthorID, Type: "users", Name: "author", Relationship: jsonapi.ToOneRelationship})
+	}
+
+	return result
+}
+
+// SetToOneReferenceID sets the reference ID of a to-one relationship of Comment
+func (c *Comment) SetToOneReferenceID(name, ID string) error {
+	switch name {
+	case "author":
+		c.AuthorID = ID
+		return nil
+	}
+
+	return fmt.Errorf("unknown to-one relationship %s of Comment", name)
+}
@@ -0,0 +1,95 @@
+// Command api2go-gen generates the jsonapi interface implementations for
+// structs with `jsonapi` struct tags, so that the IDs and relationships are not
+// handled with reflection at runtime. The attributes are still marshalled with
+// their `attr` tags:
+//
+//	//go:generate api2go-gen
+//
+//	type Post struct {
+//		ID       string     `jsonapi:"primary,posts"`
+//		Title    string     `jsonapi:"attr,title"`
+//		AuthorID string     `jsonapi:"relation,author,users"`
+//		Comments []*Comment `jsonapi:"relation,comments"`
+//	}
+//
+// Every struct with a primary tag is generated. The ID must be a string or an
+// integer. A relation can contain IDs, structs or pointers to structs, slices
+// of them are to-many relations. The related type is the third value of the
+// tag. If it is missing, the type of a related struct of the same file is used,
+// otherwise jsonapi.TypeName resolves it at runtime from the name of the
+// related struct or the relation name for IDs. Related structs must implement
+// MarshalIdentifier and UnmarshalIdentifier.
+//
+// For every source file, api2go-gen writes the implementations of
+// MarshalIdentifier, UnmarshalIdentifier, MarshalReferences,
+// MarshalLinkedRelations, MarshalIncludedRelations, UnmarshalToOneRelations,
+// UnmarshalToManyRelations and EditToManyRelations into a file with the suffix
+// _api2go.go. Without arguments, it uses the file that contains the
+// go:generate comment.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+)
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("api2go-gen: ")
+
+	output := flag.String("output", "", "output file name, only allowed for one input file")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: api2go-gen [flags] [file.go ...]\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	files := flag.Args()
+	if len(files) == 0 {
+		if goFile := os.Getenv("GOFILE"); goFile != "" {
+			files = []string{goFile}
+		}
+	}
+
+	if len(files) == 0 || (*output != "" && len(files) > 1) {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	for _, filename := range files {
+		target := *output
+		if target == "" {
+			target = outputName(filename)
+		}
+
+		if err := run(filename, target); err != nil {
+			log.Fatal(err)
+		}
+	}
+}
+
+// outputName returns the name of the generated file for filename
+func outputName(filename string) string {
+	return strings.TrimSuffix(filename, ".go") + "_api2go.go"
+}
+
+func run(filename, target string) error {
+	parsed, err := parseFile(filename, nil)
+	if err != nil {
+		return err
+	}
+
+	if len(parsed.models) == 0 {
+		return fmt.Errorf("%s does not contain a struct with a jsonapi primary tag", filename)
+	}
+
+	code, err := generate(parsed)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(target, code, 0644)
+}
@@ -0,0 +1,249 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"path"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/manyminds/api2go/jsonapi"
+)
+
+const (
+	tagKey      = "jsonapi"
+	tagPrimary  = "primary"
+	tagRelation = "relation"
+	tagAttr     = "attr"
+)
+
+// idBits contains the supported ID types and their size for strconv, 0 means
+// the size of int
+var idBits = map[string]int{
+	"string": 0,
+	"int":    0,
+	"int8":   8,
+	"int16":  16,
+	"int32":  32,
+	"int64":  64,
+	"uint":   0,
+	"uint8":  8,
+	"uint16": 16,
+	"uint32": 32,
+	"uint64": 64,
+}
+
+// file contains all models of one source file
+type file struct {
+	pkg     string
+	imports map[string]string
+	models  []*model
+}
+
+// model is a struct with a primary tag
+type model struct {
+	name string
+	// typeName is the jsonapi type of the primary tag
+	typeName  string
+	id        idField
+	relations []*relation
+}
+
+type idField struct {
+	field string
+	kind  string
+}
+
+// relation is a field with a relation tag
+type relation struct {
+	field string
+	name  string
+	// typ is the go expression for the jsonapi type of the related resources
+	typ string
+	// toMany relations are slices
+	toMany bool
+	// element is the go type of one related element
+	element string
+	// kind is the ID type if the relation contains IDs instead of structs
+	kind    string
+	pointer bool
+}
+
+func (r *relation) structs() bool {
+	return r.kind == ""
+}
+
+// parseFile returns all models of the go source file filename
+func parseFile(filename string, src interface{}) (*file, error) {
+	fset := token.NewFileSet()
+	parsed, err := parser.ParseFile(fset, filename, src, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &file{pkg: parsed.Name.Name, imports: map[string]string{}}
+	for _, spec := range parsed.Imports {
+		importPath, _ := strconv.Unquote(spec.Path.Value)
+		name := path.Base(importPath)
+		if spec.Name != nil {
+			name = spec.Name.Name
+		}
+		result.imports[name] = spec.Path.Value
+	}
+
+	var relations []*relation
+	for _, decl := range parsed.Decls {
+		genDecl, ok := decl.(*ast.GenDecl)
+		if !ok || genDecl.Tok != token.TYPE {
+			continue
+		}
+
+		for _, spec := range genDecl.Specs {
+			typeSpec := spec.(*ast.TypeSpec)
+			structType, ok := typeSpec.Type.(*ast.StructType)
+			if !ok {
+				continue
+			}
+
+			model, err := parseModel(fset, typeSpec.Name.Name, structType)
+			if err != nil {
+				return nil, err
+			}
+			if model == nil {
+				continue
+			}
+
+			result.models = append(result.models, model)
+			relations = append(relations, model.relations...)
+		}
+	}
+
+	// related models of the same file are marshalled with the type of their
+	// primary tag, everything else is resolved by the TypeNamer at runtime like
+	// the struct tags of the jsonapi package do
+	for _, relation := range relations {
+		if relation.typ != "" {
+			continue
+		}
+
+		if !relation.structs() {
+			relation.typ = typeNameExpr(relation.name)
+			continue
+		}
+
+		relation.typ = typeNameExpr(goTypeName(relation.element))
+		for _, model := range result.models {
+			if model.name == relation.element {
+				relation.typ = strconv.Quote(model.typeName)
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// parseModel returns the model of a struct, it is nil if the struct has no
+// primary tag
+func parseModel(fset *token.FileSet, name string, structType *ast.StructType) (*model, error) {
+	result := &model{name: name}
+
+	for _, field := range structType.Fields.List {
+		if field.Tag == nil || len(field.Names) == 0 {
+			continue
+		}
+
+		tag, _ := strconv.Unquote(field.Tag.Value)
+		value, ok := reflect.StructTag(tag).Lookup(tagKey)
+		if !ok {
+			continue
+		}
+
+		position := fset.Position(field.Pos())
+		values := strings.Split(value, ",")
+		for _, fieldName := range field.Names {
+			switch values[0] {
+			case tagPrimary:
+				if len(values) < 2 || values[1] == "" {
+					return nil, fmt.Errorf("%s: primary tag of %s needs a type", position, fieldName.Name)
+				}
+				if result.id.field != "" {
+					return nil, fmt.Errorf("%s: %s has more than one primary tag", position, name)
+				}
+
+				kind := types.ExprString(field.Type)
+				if _, ok := idBits[kind]; !ok {
+					return nil, fmt.Errorf("%s: primary field %s must be a string or an integer", position, fieldName.Name)
+				}
+				result.id = idField{field: fieldName.Name, kind: kind}
+				result.typeName = values[1]
+			case tagRelation:
+				if len(values) > 3 {
+					return nil, fmt.Errorf("%s: relation tag of %s must be relation[,name[,type]]", position, fieldName.Name)
+				}
+
+				relation := parseRelation(field.Type)
+				if _, ok := idBits[relation.element]; ok && relation.pointer {
+					return nil, fmt.Errorf("%s: relation %s must not contain pointers to IDs", position, fieldName.Name)
+				}
+				relation.field = fieldName.Name
+				relation.name = jsonapi.Jsonify(fieldName.Name)
+				if len(values) > 1 && values[1] != "" {
+					relation.name = values[1]
+				}
+				if len(values) > 2 && values[2] != "" {
+					relation.typ = strconv.Quote(values[2])
+				}
+				result.relations = append(result.relations, relation)
+			case tagAttr:
+			default:
+				return nil, fmt.Errorf("%s: unknown jsonapi tag %q of %s", position, value, fieldName.Name)
+			}
+		}
+	}
+
+	if result.id.field == "" {
+		return nil, nil
+	}
+
+	return result, nil
+}
+
+func parseRelation(expr ast.Expr) *relation {
+	result := &relation{}
+
+	if slice, ok := expr.(*ast.ArrayType); ok && slice.Len == nil {
+		result.toMany = true
+		expr = slice.Elt
+	}
+
+	if pointer, ok := expr.(*ast.StarExpr); ok {
+		result.pointer = true
+		expr = pointer.X
+	}
+
+	result.element = types.ExprString(expr)
+	if _, ok := idBits[result.element]; ok && !result.pointer {
+		result.kind = result.element
+	}
+
+	return result
+}
+
+// goTypeName returns the name of a go type without its package
+func goTypeName(goType string) string {
+	if index := strings.LastIndex(goType, "."); index >= 0 {
+		return goType[index+1:]
+	}
+
+	return goType
+}
+
+// typeNameExpr returns the go expression that resolves the jsonapi type for
+// name with the TypeNamer at runtime
+func typeNameExpr(name string) string {
+	return "jsonapi.TypeName(" + strconv.Quote(name) + ")"
+}
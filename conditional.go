@@ -0,0 +1,207 @@
+package api2go
+
+import (
+	"crypto/sha1"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/manyminds/api2go/jsonapi"
+)
+
+// writeCacheHeaders sets the ETag and the caching headers of a successful GET
+// response and returns true if the client already has the current version
+func (api *API) writeCacheHeaders(w http.ResponseWriter, r *http.Request, obj Responder, body []byte) bool {
+	var (
+		etag         string
+		lastModified time.Time
+	)
+
+	if api.etags {
+		etag = computeETag(obj.Result(), body)
+		w.Header().Set("ETag", etag)
+	}
+
+	if cacheResponder, ok := obj.(CacheResponder); ok {
+		if cacheControl := cacheResponder.CacheControl(); cacheControl != "" {
+			w.Header().Set("Cache-Control", cacheControl)
+		}
+
+		lastModified = cacheResponder.LastModified()
+		if !lastModified.IsZero() {
+			w.Header().Set("Last-Modified", lastModified.UTC().Format(http.TimeFormat))
+		}
+	}
+
+	// If-Modified-Since must be ignored if If-None-Match is present
+	if ifNoneMatch := r.Header.Get("If-None-Match"); ifNoneMatch != "" {
+		return etag != "" && etagMatches(ifNoneMatch, etag, false)
+	}
+
+	if ifModifiedSince := r.Header.Get("If-Modified-Since"); ifModifiedSince != "" && !lastModified.IsZero() {
+		since, err := http.ParseTime(ifModifiedSince)
+		return err == nil && !lastModified.Truncate(time.Second).After(since)
+	}
+
+	return false
+}
+
+// computeETag uses the versions of a Versioned result or a hash of the body.
+// Versions with characters that are not allowed in an ETag are hashed.
+func computeETag(result interface{}, body []byte) string {
+	if version, ok := versionOf(result); ok {
+		if !validETagValue(version) {
+			return fmt.Sprintf(`"%x"`, sha1.Sum([]byte(version)))
+		}
+
+		return `"` + version + `"`
+	}
+
+	return fmt.Sprintf(`"%x"`, sha1.Sum(body))
+}
+
+// validETagValue checks that value only consists of the characters that
+// RFC 7232 allows inside of the quotes of an ETag
+func validETagValue(value string) bool {
+	for i := 0; i < len(value); i++ {
+		if c := value[i]; c == '"' || c <= ' ' || c == 0x7f {
+			return false
+		}
+	}
+
+	return true
+}
+
+// versionOf returns the version of a Versioned element or a combined version
+// for a slice in which all elements are Versioned
+func versionOf(result interface{}) (string, bool) {
+	if versioned, ok := result.(Versioned); ok {
+		return versioned.GetVersion(), true
+	}
+
+	value := reflect.ValueOf(result)
+	if !value.IsValid() || value.Kind() != reflect.Slice || value.Len() == 0 {
+		return "", false
+	}
+
+	versions := make([]string, value.Len())
+	for i := 0; i < value.Len(); i++ {
+		versioned, ok := value.Index(i).Interface().(Versioned)
+		if !ok {
+			return "", false
+		}
+		versions[i] = versioned.GetVersion()
+	}
+
+	return fmt.Sprintf("%x", sha1.Sum([]byte(strings.Join(versions, "\x00")))), true
+}
+
+// etagMatches checks if etag is contained in the list of an If-Match or
+// If-None-Match header. Weak comparison ignores the W/ prefix.
+func etagMatches(header, etag string, strong bool) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "" {
+			continue
+		}
+		if candidate == "*" {
+			return true
+		}
+
+		if strong {
+			if !strings.HasPrefix(candidate, "W/") && !strings.HasPrefix(etag, "W/") && candidate == etag {
+				return true
+			}
+			continue
+		}
+
+		if strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// checkPreconditions evaluates the If-Match header of a modifying request
+// against the current ETag of the target
+func (api *API) checkPreconditions(r *http.Request, currentETag func() (string, error)) error {
+	ifMatch := r.Header.Get("If-Match")
+	if ifMatch == "" {
+		if api.requirePreconditions {
+			httpErr := NewHTTPError(nil, "Precondition Required", http.StatusPreconditionRequired)
+			httpErr.Errors = append(httpErr.Errors, Error{
+				Status: strconv.Itoa(http.StatusPreconditionRequired),
+				Title:  "Precondition Required",
+				Detail: "This request must contain an If-Match header",
+			})
+			return httpErr
+		}
+
+		return nil
+	}
+
+	etag, err := currentETag()
+	if err != nil {
+		return err
+	}
+
+	if !etagMatches(ifMatch, etag, true) {
+		httpErr := NewHTTPError(nil, "Precondition Failed", http.StatusPreconditionFailed)
+		httpErr.Errors = append(httpErr.Errors, Error{
+			Status: strconv.Itoa(http.StatusPreconditionFailed),
+			Title:  "Precondition Failed",
+			Detail: "The resource has been modified in the meantime",
+		})
+		return httpErr
+	}
+
+	return nil
+}
+
+// resourceETag returns the ETag that a GET request for a single resource gets
+func (api *API) resourceETag(obj Responder, info information, r *http.Request) (string, error) {
+	document, err := buildDocument(obj, info, withoutQuery(r), api.jsonOptions())
+	if err != nil {
+		return "", err
+	}
+
+	return api.renderETag(obj, document)
+}
+
+// relationshipETag returns the ETag that a GET request for a relationship gets
+func (api *API) relationshipETag(obj Responder, info information, r *http.Request, relation jsonapi.Reference) (string, error) {
+	rel, err := buildRelationship(obj, info, relation, api.jsonOptions())
+	if err != nil {
+		return "", err
+	}
+
+	return api.renderETag(obj, rel)
+}
+
+// renderETag hashes the complete document, the ETag of a resource does not
+// depend on sparse fieldsets or other query parameters of the request.
+func (api *API) renderETag(obj Responder, resp interface{}) (string, error) {
+	body, err := api.jsonCodec().Marshal(resp)
+	if err != nil {
+		return "", err
+	}
+
+	return computeETag(obj.Result(), body), nil
+}
+
+// withoutQuery returns a shallow copy of r without query parameters
+func withoutQuery(r *http.Request) *http.Request {
+	if r.URL.RawQuery == "" {
+		return r
+	}
+
+	u := *r.URL
+	u.RawQuery = ""
+	clone := *r
+	clone.URL = &u
+	return &clone
+}
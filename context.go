@@ -16,9 +16,25 @@ type APIContexter interface {
 	Reset()
 }
 
-// APIContext api2go context for handlers, nil implementations related to Deadline and Done.
+// A RequestContexter is an APIContexter that can use the context of the http
+// request as parent. SetRequestContext is called with r.Context() for every
+// request before the middlewares run, so values that were added by the router
+// or a middleware of a framework are available through the APIContexter.
+type RequestContexter interface {
+	SetRequestContext(ctx context.Context)
+}
+
+// APIContext api2go context for handlers. Values and the cancellation of the
+// request context are available through it, without a request context
+// Deadline, Done and Err return nil values.
 type APIContext struct {
-	keys map[string]interface{}
+	keys   map[string]interface{}
+	parent context.Context
+}
+
+// SetRequestContext sets the context of the http request as parent
+func (c *APIContext) SetRequestContext(ctx context.Context) {
+	c.parent = ctx
 }
 
 // Set a string key value in the context
@@ -29,31 +45,46 @@ func (c *APIContext) Set(key string, value interface{}) {
 	c.keys[key] = value
 }
 
-// Get a key value from the context
+// Get a key value from the context, values that were not set are looked up in
+// the request context
 func (c *APIContext) Get(key string) (value interface{}, exists bool) {
 	if c.keys != nil {
 		value, exists = c.keys[key]
 	}
+	if !exists && c.parent != nil {
+		value = c.parent.Value(key)
+		exists = value != nil
+	}
 	return
 }
 
 // Reset resets all values on Context, making it safe to reuse
 func (c *APIContext) Reset() {
 	c.keys = nil
+	c.parent = nil
 }
 
 // Deadline implements net/context
 func (c *APIContext) Deadline() (deadline time.Time, ok bool) {
+	if c.parent != nil {
+		return c.parent.Deadline()
+	}
 	return
 }
 
 // Done implements net/context
 func (c *APIContext) Done() <-chan struct{} {
+	if c.parent != nil {
+		return c.parent.Done()
+	}
 	return nil
 }
 
 // Err implements net/context
 func (c *APIContext) Err() error {
+	if c.parent != nil {
+		return c.parent.Err()
+	}
 	return nil
 }
 
@@ -63,11 +94,17 @@ func (c *APIContext) Value(key interface{}) interface{} {
 		val, _ := c.Get(keyAsString)
 		return val
 	}
+	if c.parent != nil {
+		return c.parent.Value(key)
+	}
 	return nil
 }
 
 // Compile time check
-var _ APIContexter = &APIContext{}
+var (
+	_ APIContexter     = &APIContext{}
+	_ RequestContexter = &APIContext{}
+)
 
 // ContextQueryParams fetches the QueryParams if Set
 func ContextQueryParams(c *APIContext) map[string][]string {
@@ -1,6 +1,7 @@
 package api2go
 
 import (
+	"context"
 	"time"
 
 	. "github.com/onsi/ginkgo"
@@ -81,6 +82,48 @@ var _ = Describe("Context", func() {
 
 	})
 
+	Context("with a request context", func() {
+		type key string
+
+		var cancel context.CancelFunc
+
+		BeforeEach(func() {
+			parent := context.WithValue(context.Background(), "tenant", "acme")
+			parent = context.WithValue(parent, key("user"), "ned")
+			parent, cancel = context.WithCancel(parent)
+			c.SetRequestContext(parent)
+		})
+
+		It("gets values of the request context", func() {
+			value, ok := c.Get("tenant")
+			Expect(ok).To(BeTrue())
+			Expect(value).To(Equal("acme"))
+			Expect(c.Value("tenant")).To(Equal("acme"))
+			Expect(c.Value(key("user"))).To(Equal("ned"))
+
+			_, ok = c.Get("nope")
+			Expect(ok).To(BeFalse())
+		})
+
+		It("prefers values that were set", func() {
+			c.Set("tenant", "other")
+			Expect(c.Value("tenant")).To(Equal("other"))
+		})
+
+		It("is canceled with the request context", func() {
+			Expect(c.Err()).To(BeNil())
+			cancel()
+			Eventually(c.Done()).Should(BeClosed())
+			Expect(c.Err()).To(Equal(context.Canceled))
+		})
+
+		It("reset removes the request context", func() {
+			c.Reset()
+			Expect(c.Value(key("user"))).To(BeNil())
+			Expect(c.Done()).To(BeNil())
+		})
+	})
+
 	Context("ContextQueryParams", func() {
 		It("returns them if set", func() {
 			queryParams := map[string][]string{
@@ -9,6 +9,7 @@ import (
 
 	"github.com/labstack/echo"
 	"github.com/manyminds/api2go/routing"
+	echorouter "github.com/manyminds/api2go/routing/echo"
 )
 
 func customHTTPErrorHandler(err error, c echo.Context) {
@@ -23,7 +24,7 @@ func newTestRouter() routing.Routeable {
 	e := echo.New()
 	// not found handler, this needs to be fixed as well: see: https://github.com/manyminds/api2go/issues/301
 	e.HTTPErrorHandler = customHTTPErrorHandler
-	return routing.Echo(e)
+	return echorouter.New(e)
 }
 
 func init() {
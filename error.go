@@ -1,10 +1,12 @@
 package api2go
 
 import (
-	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"strconv"
+
+	"github.com/manyminds/api2go/jsonapi"
 )
 
 // HTTPError is used for errors
@@ -51,12 +53,12 @@ type ErrorSource struct {
 }
 
 // marshalHTTPError marshals an internal httpError
-func marshalHTTPError(input HTTPError) string {
+func marshalHTTPError(input HTTPError, codec jsonapi.Codec) string {
 	if len(input.Errors) == 0 {
 		input.Errors = []Error{{Title: input.msg, Status: strconv.Itoa(input.status)}}
 	}
 
-	data, err := json.Marshal(input)
+	data, err := codec.Marshal(input)
 
 	if err != nil {
 		log.Println(err)
@@ -82,3 +84,33 @@ func (e HTTPError) Error() string {
 
 	return msg
 }
+
+// Unwrap returns the wrapped internal error, so that `errors.Is` and
+// `errors.As` can look through an HTTPError.
+func (e HTTPError) Unwrap() error {
+	return e.err
+}
+
+// Status returns the http status code of the error
+func (e HTTPError) Status() int {
+	return e.status
+}
+
+// ErrorMapperFunc converts an arbitrary error returned by a resource into an
+// HTTPError. It must return nil if it does not handle the given error.
+type ErrorMapperFunc func(error) *HTTPError
+
+// asHTTPError finds the first HTTPError or *HTTPError in the chain of err
+func asHTTPError(err error) (HTTPError, bool) {
+	var httpErr HTTPError
+	if errors.As(err, &httpErr) {
+		return httpErr, true
+	}
+
+	var httpErrPtr *HTTPError
+	if errors.As(err, &httpErrPtr) && httpErrPtr != nil {
+		return *httpErrPtr, true
+	}
+
+	return HTTPError{}, false
+}
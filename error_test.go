@@ -2,8 +2,10 @@ package api2go
 
 import (
 	"errors"
+	"fmt"
 	"net/http"
 
+	"github.com/manyminds/api2go/jsonapi"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 )
@@ -33,17 +35,45 @@ var _ = Describe("Errors test", func() {
 		})
 	})
 
+	Context("errors package support", func() {
+		It("returns the status", func() {
+			httpErr := NewHTTPError(nil, "hi", http.StatusNotFound)
+			Expect(httpErr.Status()).To(Equal(http.StatusNotFound))
+		})
+
+		It("unwraps the internal error", func() {
+			internal := errors.New("internal")
+			httpErr := NewHTTPError(internal, "hi", http.StatusNotFound)
+			Expect(errors.Unwrap(httpErr)).To(Equal(internal))
+			Expect(errors.Is(httpErr, internal)).To(BeTrue())
+		})
+
+		It("finds wrapped errors", func() {
+			httpErr := NewHTTPError(nil, "hi", http.StatusNotFound)
+			found, ok := asHTTPError(fmt.Errorf("wrapped: %w", httpErr))
+			Expect(ok).To(BeTrue())
+			Expect(found.Status()).To(Equal(http.StatusNotFound))
+
+			found, ok = asHTTPError(fmt.Errorf("wrapped: %w", &httpErr))
+			Expect(ok).To(BeTrue())
+			Expect(found.Status()).To(Equal(http.StatusNotFound))
+
+			_, ok = asHTTPError(errors.New("plain"))
+			Expect(ok).To(BeFalse())
+		})
+	})
+
 	Context("Marshalling", func() {
 		It("will be marshalled correctly with default error", func() {
 			httpErr := NewHTTPError(nil, "Invalid use case done", http.StatusInternalServerError)
-			result := marshalHTTPError(httpErr)
+			result := marshalHTTPError(httpErr, jsonapi.StandardCodec)
 			expected := `{"errors":[{"status":"500","title":"Invalid use case done"}]}`
 			Expect(result).To(Equal(expected))
 		})
 
 		It("will be marshalled correctly without child errors", func() {
 			httpErr := NewHTTPError(errors.New("Bad Request"), "Bad Request", 400)
-			result := marshalHTTPError(httpErr)
+			result := marshalHTTPError(httpErr, jsonapi.StandardCodec)
 			expected := `{"errors":[{"status":"400","title":"Bad Request"}]}`
 			Expect(result).To(Equal(expected))
 		})
@@ -70,7 +100,7 @@ var _ = Describe("Errors test", func() {
 
 			httpErr.Errors = append(httpErr.Errors, errorOne)
 
-			result := marshalHTTPError(httpErr)
+			result := marshalHTTPError(httpErr, jsonapi.StandardCodec)
 			expected := `{"errors":[{"id":"001","links":{"about":"http://bla/blub"},"status":"500","code":"001","title":"Title must not be empty","detail":"Never occures in real life","source":{"pointer":"#titleField"},"meta":{"creator":"api2go"}}]}`
 			Expect(result).To(Equal(expected))
 		})
@@ -91,9 +121,14 @@ var _ = Describe("Errors test", func() {
 
 			httpErr.Errors = append(httpErr.Errors, errorOne)
 
-			result := marshalHTTPError(httpErr)
+			result := marshalHTTPError(httpErr, jsonapi.StandardCodec)
 			expected := `{"errors":[{"id":"001","status":"500","code":"001","title":"Title must not be empty","detail":"Never occures in real life","meta":{"creator":"api2go"}}]}`
 			Expect(result).To(Equal(expected))
 		})
+
+		It("returns an empty object if the codec fails", func() {
+			httpErr := NewHTTPError(nil, "Invalid use case done", http.StatusInternalServerError)
+			Expect(marshalHTTPError(httpErr, ErrorMarshaler{})).To(Equal("{}"))
+		})
 	})
 })
@@ -8,6 +8,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/manyminds/api2go/routing"
+	ginrouter "github.com/manyminds/api2go/routing/gin"
 )
 
 func newTestRouter() routing.Routeable {
@@ -19,7 +20,7 @@ func newTestRouter() routing.Routeable {
 
 	gg.NoRoute(notFound)
 
-	return routing.Gin(gg)
+	return ginrouter.New(gg)
 }
 
 func init() {
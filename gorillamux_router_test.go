@@ -8,12 +8,13 @@ import (
 
 	"github.com/gorilla/mux"
 	"github.com/manyminds/api2go/routing"
+	"github.com/manyminds/api2go/routing/gorilla"
 )
 
 func newTestRouter() routing.Routeable {
 	router := mux.NewRouter()
 	router.MethodNotAllowedHandler = notAllowedHandler{}
-	return routing.Gorilla(router)
+	return gorilla.New(router)
 }
 
 func init() {
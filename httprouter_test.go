@@ -1,5 +1,5 @@
-//go:build !gingonic && !gorillamux && !echo
-// +build !gingonic,!gorillamux,!echo
+//go:build !gingonic && !gorillamux && !echo && !servemux
+// +build !gingonic,!gorillamux,!echo,!servemux
 
 package api2go
 
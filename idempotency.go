@@ -0,0 +1,212 @@
+package api2go
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"reflect"
+	"sync"
+	"time"
+)
+
+const idempotencyKeyHeader = "Idempotency-Key"
+
+// IdempotentResponse is the stored response of a request with an
+// Idempotency-Key header. PayloadHash identifies the request body.
+type IdempotentResponse struct {
+	PayloadHash string
+	StatusCode  int
+	Header      http.Header
+	Body        []byte
+}
+
+// The IdempotencyStore interface is used to save responses of POST requests
+// with an Idempotency-Key header, so that retried requests get the same
+// response instead of creating duplicates.
+type IdempotencyStore interface {
+	// Get returns the stored response for a key, ok is false if there is none
+	Get(key string) (response IdempotentResponse, ok bool, err error)
+	// Set stores the response for a key
+	Set(key string, response IdempotentResponse) error
+}
+
+type memoryIdempotencyEntry struct {
+	response IdempotentResponse
+	expires  time.Time
+}
+
+type memoryIdempotencyStore struct {
+	ttl     time.Duration
+	mutex   sync.Mutex
+	entries map[string]memoryIdempotencyEntry
+}
+
+// NewMemoryIdempotencyStore returns an IdempotencyStore that keeps all
+// responses in memory for the duration of ttl. Use a shared store if you run
+// more than one instance of your api.
+func NewMemoryIdempotencyStore(ttl time.Duration) IdempotencyStore {
+	return &memoryIdempotencyStore{ttl: ttl, entries: map[string]memoryIdempotencyEntry{}}
+}
+
+func (m *memoryIdempotencyStore) Get(key string) (IdempotentResponse, bool, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	entry, ok := m.entries[key]
+	if !ok {
+		return IdempotentResponse{}, false, nil
+	}
+
+	if time.Now().After(entry.expires) {
+		delete(m.entries, key)
+		return IdempotentResponse{}, false, nil
+	}
+
+	return entry.response, true, nil
+}
+
+func (m *memoryIdempotencyStore) Set(key string, response IdempotentResponse) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	now := time.Now()
+	for k, entry := range m.entries {
+		if now.After(entry.expires) {
+			delete(m.entries, k)
+		}
+	}
+
+	m.entries[key] = memoryIdempotencyEntry{response: response, expires: now.Add(m.ttl)}
+	return nil
+}
+
+// recordingResponseWriter passes everything on to the wrapped writer and keeps
+// a copy of the status code and body
+type recordingResponseWriter struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (rw *recordingResponseWriter) WriteHeader(status int) {
+	if rw.status == 0 {
+		rw.status = status
+	}
+	rw.ResponseWriter.WriteHeader(status)
+}
+
+func (rw *recordingResponseWriter) Write(data []byte) (int, error) {
+	if rw.status == 0 {
+		rw.status = http.StatusOK
+	}
+	rw.body.Write(data)
+	return rw.ResponseWriter.Write(data)
+}
+
+// handleIdempotent runs handler only once for every Idempotency-Key and payload.
+// Retries get the stored response, a different payload for a used key is
+// rejected with 422.
+func (api *API) handleIdempotent(w http.ResponseWriter, r *http.Request, handler func(http.ResponseWriter) error) error {
+	key := r.Header.Get(idempotencyKeyHeader)
+	if api.idempotencyStore == nil || key == "" {
+		return handler(w)
+	}
+
+	body, err := unmarshalRequest(r, api.maxBodySize)
+	if err != nil {
+		return err
+	}
+	r.Body = ioutil.NopCloser(bytes.NewReader(body))
+	payloadHash := fmt.Sprintf("%x", sha256.Sum256(body))
+
+	storeKey := r.Method + " " + r.URL.Path + " " + key
+	if !api.lockIdempotencyKey(storeKey) {
+		return NewHTTPError(nil, "A request with the same Idempotency-Key is still being processed", http.StatusConflict)
+	}
+	defer api.unlockIdempotencyKey(storeKey)
+
+	stored, ok, err := api.idempotencyStore.Get(storeKey)
+	if err != nil {
+		return err
+	}
+
+	if ok {
+		if stored.PayloadHash != payloadHash {
+			return NewHTTPError(nil, "The Idempotency-Key has already been used with a different payload", http.StatusUnprocessableEntity)
+		}
+
+		for name, values := range stored.Header {
+			w.Header()[name] = values
+		}
+		w.WriteHeader(stored.StatusCode)
+		w.Write(stored.Body)
+		return nil
+	}
+
+	// headers of middlewares are set again for every request and are not stored
+	before := w.Header().Clone()
+	recorder := &recordingResponseWriter{ResponseWriter: w}
+	err = handler(recorder)
+	if err != nil {
+		api.handleError(err, recorder, r)
+	}
+
+	// server errors are not stored, the client should be able to retry
+	if recorder.status == 0 || recorder.status >= http.StatusInternalServerError {
+		return nil
+	}
+
+	err = api.idempotencyStore.Set(storeKey, IdempotentResponse{
+		PayloadHash: payloadHash,
+		StatusCode:  recorder.status,
+		Header:      changedHeaders(before, w.Header()),
+		Body:        recorder.body.Bytes(),
+	})
+	if err != nil {
+		// the response has already been written, a retry creates a duplicate
+		log.Printf("could not store the response for Idempotency-Key %q: %v", key, err)
+	}
+
+	return nil
+}
+
+// changedHeaders returns all headers of after that differ from before
+func changedHeaders(before, after http.Header) http.Header {
+	result := http.Header{}
+	for name, values := range after {
+		if !reflect.DeepEqual(before[name], values) {
+			result[name] = append([]string(nil), values...)
+		}
+	}
+
+	return result
+}
+
+// idempotencyLocks contains the keys of all requests that are in progress
+type idempotencyLocks struct {
+	sync.Mutex
+	keys map[string]bool
+}
+
+// lockIdempotencyKey returns false if a request with the key is in progress
+func (api *API) lockIdempotencyKey(key string) bool {
+	api.idempotencyLocks.Lock()
+	defer api.idempotencyLocks.Unlock()
+
+	if api.idempotencyLocks.keys[key] {
+		return false
+	}
+
+	api.idempotencyLocks.keys[key] = true
+	return true
+}
+
+func (api *API) unlockIdempotencyKey(key string) {
+	api.idempotencyLocks.Lock()
+	defer api.idempotencyLocks.Unlock()
+
+	delete(api.idempotencyLocks.keys, key)
+}
@@ -0,0 +1,67 @@
+package api2go
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+
+	"github.com/manyminds/api2go/routing"
+)
+
+// UUIDIDs can be embedded into a source to only accept UUIDs as IDs
+type UUIDIDs struct{}
+
+// ValidID returns true if id is a UUID
+func (UUIDIDs) ValidID(id string) bool {
+	return uuidRegex.MatchString(id)
+}
+
+// NumericIDs can be embedded into a source to only accept decimal numbers as IDs
+type NumericIDs struct{}
+
+// ValidID returns true if id only consists of digits
+func (NumericIDs) ValidID(id string) bool {
+	if id == "" {
+		return false
+	}
+
+	for _, c := range id {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+
+	return true
+}
+
+// RegexpIDs can be embedded into a source to only accept IDs that match
+// Pattern. Anchor the pattern with `^` and `$` to match the whole ID.
+type RegexpIDs struct {
+	Pattern *regexp.Regexp
+}
+
+// ValidID returns true if id matches the pattern
+func (v RegexpIDs) ValidID(id string) bool {
+	return v.Pattern.MatchString(id)
+}
+
+// validateID answers requests with an id that the IDValidator of the source
+// rejects with 404 Not Found, without calling handler
+func (res *resource) validateID(handler routing.HandlerFunc) routing.HandlerFunc {
+	validator, ok := res.source.(IDValidator)
+	if !ok {
+		return handler
+	}
+
+	return func(w http.ResponseWriter, r *http.Request, params map[string]string, context map[string]interface{}) {
+		id, ok := params["id"]
+		if !ok || validator.ValidID(id) {
+			handler(w, r, params, context)
+			return
+		}
+
+		res.api.handleRequest(w, r, context, func(c APIContexter) error {
+			return NewHTTPError(fmt.Errorf("invalid id %q for %s", id, res.name), http.StatusText(http.StatusNotFound), http.StatusNotFound)
+		})
+	}
+}
@@ -2,6 +2,8 @@ package jsonapi
 
 import (
 	"database/sql"
+	"io/ioutil"
+	"strconv"
 	"testing"
 )
 
@@ -120,3 +122,68 @@ func BenchmarkUnmarshalWithRelationships(b *testing.B) {
 		}
 	}
 }
+
+func largePostSlice(length int) []Post {
+	posts := make([]Post, length)
+	for i := range posts {
+		posts[i] = Post{
+			ID:       i,
+			Title:    "Title",
+			Author:   &User{ID: i % 10, Name: "Author"},
+			Comments: []Comment{{ID: i % 100, Text: "Comment"}, {ID: i%100 + 1, Text: "Comment"}},
+		}
+	}
+
+	return posts
+}
+
+func BenchmarkMarshalLargeSlice(b *testing.B) {
+	posts := make([]SimplePost, 10000)
+	for i := range posts {
+		posts[i] = SimplePost{ID: strconv.Itoa(i), Title: "Title", Text: "Text", Size: i}
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		_, err := Marshal(posts)
+		if err != nil {
+			panic(err)
+		}
+	}
+}
+
+func BenchmarkMarshalLargeSliceWithIncludes(b *testing.B) {
+	posts := largePostSlice(10000)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		_, err := MarshalWithURLs(posts, CompleteServerInformation{})
+		if err != nil {
+			panic(err)
+		}
+	}
+}
+
+func BenchmarkEncodeLargeSliceWithIncludes(b *testing.B) {
+	posts := largePostSlice(10000)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		encoder := NewEncoder(ioutil.Discard, CompleteServerInformation{})
+		for _, post := range posts {
+			if err := encoder.Encode(post); err != nil {
+				panic(err)
+			}
+		}
+
+		if err := encoder.Close(); err != nil {
+			panic(err)
+		}
+	}
+}
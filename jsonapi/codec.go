@@ -0,0 +1,65 @@
+package jsonapi
+
+import (
+	"encoding/json"
+	"sync/atomic"
+)
+
+// A Codec encodes and decodes JSON. It can be used to replace encoding/json,
+// for example with a faster implementation or one with a different
+// configuration for number handling or HTML escaping.
+//
+// Implementations must respect the json.Marshaler and json.Unmarshaler
+// interfaces and the `json` struct tags like encoding/json does.
+type Codec interface {
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(data []byte, v interface{}) error
+}
+
+type standardCodec struct{}
+
+func (standardCodec) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (standardCodec) Unmarshal(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
+
+// StandardCodec uses encoding/json and is used if no other codec was set.
+var StandardCodec Codec = standardCodec{}
+
+// codecHolder is needed because atomic.Value only accepts one concrete type
+type codecHolder struct {
+	codec Codec
+}
+
+var globalCodec atomic.Value
+
+// SetCodec replaces the codec that is used by this package, passing nil
+// restores StandardCodec. It should be called before anything is marshalled.
+func SetCodec(codec Codec) {
+	if codec == nil {
+		codec = StandardCodec
+	}
+
+	globalCodec.Store(codecHolder{codec: codec})
+}
+
+// GetCodec returns the codec that is used by this package.
+func GetCodec() Codec {
+	if holder, ok := globalCodec.Load().(codecHolder); ok {
+		return holder.codec
+	}
+
+	return StandardCodec
+}
+
+// codecOrDefault returns codec or the package codec if codec is nil
+func codecOrDefault(codec Codec) Codec {
+	if codec == nil {
+		return GetCodec()
+	}
+
+	return codec
+}
@@ -0,0 +1,162 @@
+package jsonapi
+
+import (
+	"bytes"
+	"encoding/json"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// rawCodec does not escape HTML and decodes numbers as json.Number
+type rawCodec struct {
+	marshalled int
+}
+
+func (c *rawCodec) Marshal(v interface{}) ([]byte, error) {
+	c.marshalled++
+	buffer := &bytes.Buffer{}
+	encoder := json.NewEncoder(buffer)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(v); err != nil {
+		return nil, err
+	}
+
+	return bytes.TrimSuffix(buffer.Bytes(), []byte("\n")), nil
+}
+
+func (c *rawCodec) Unmarshal(data []byte, v interface{}) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	return decoder.Decode(v)
+}
+
+type numberPost struct {
+	ID    string      `json:"-"`
+	Title string      `json:"title"`
+	Value interface{} `json:"value"`
+}
+
+func (p numberPost) GetID() string {
+	return p.ID
+}
+
+func (p *numberPost) SetID(ID string) error {
+	p.ID = ID
+	return nil
+}
+
+// linkedNumberPost has links and a relationship with characters that are
+// escaped by encoding/json
+type linkedNumberPost struct {
+	numberPost
+	AuthorID string `json:"-"`
+}
+
+func (p linkedNumberPost) GetReferences() []Reference {
+	return []Reference{{Type: "users", Name: "author"}}
+}
+
+func (p linkedNumberPost) GetReferencedIDs() []ReferenceID {
+	return []ReferenceID{{ID: p.AuthorID, Type: "users", Name: "author"}}
+}
+
+func (p linkedNumberPost) GetCustomLinks(base string) Links {
+	return Links{"search": Link{Href: base + "?q=<b>"}}
+}
+
+var _ = Describe("Codec", func() {
+	var codec *rawCodec
+
+	BeforeEach(func() {
+		codec = &rawCodec{}
+	})
+
+	AfterEach(func() {
+		SetCodec(nil)
+	})
+
+	It("uses encoding/json by default", func() {
+		Expect(GetCodec()).To(Equal(StandardCodec))
+		result, err := Marshal(numberPost{ID: "1", Title: "<b>"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(result)).To(ContainSubstring(`\u003cb\u003e`))
+	})
+
+	It("can be replaced globally", func() {
+		SetCodec(codec)
+		Expect(GetCodec()).To(Equal(codec))
+
+		result, err := Marshal([]numberPost{{ID: "1", Title: "<b>"}})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(result)).To(ContainSubstring(`"title":"<b>"`))
+		Expect(codec.marshalled).To(BeNumerically(">", 1))
+
+		var post numberPost
+		Expect(Unmarshal([]byte(`{"data": {"type": "numberPosts", "id": "1", "attributes": {"value": 1.5}}}`), &post)).To(Succeed())
+		Expect(post.Value).To(Equal(json.Number("1.5")))
+	})
+
+	It("can be passed per call", func() {
+		document, err := MarshalToStructWithCodec([]numberPost{{ID: "1", Title: "<b>"}}, nil, codec)
+		Expect(err).ToNot(HaveOccurred())
+
+		result, err := codec.Marshal(document)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(result)).To(ContainSubstring(`"title":"<b>"`))
+
+		var post numberPost
+		payload := []byte(`{"data": {"type": "numberPosts", "id": "1", "attributes": {"value": 1}}}`)
+		Expect(UnmarshalWithCodec(payload, &post, codec)).To(Succeed())
+		Expect(post.Value).To(Equal(json.Number("1")))
+
+		post = numberPost{}
+		Expect(UnmarshalStrictWithCodec(payload, &post, codec)).To(Succeed())
+		Expect(post.Value).To(Equal(json.Number("1")))
+
+		post = numberPost{}
+		Expect(Unmarshal(payload, &post)).To(Succeed())
+		Expect(post.Value).To(Equal(float64(1)))
+	})
+
+	It("is used by the encoder", func() {
+		buffer := &bytes.Buffer{}
+		encoder := NewEncoder(buffer, nil)
+		encoder.SetCodec(codec)
+		Expect(encoder.Encode(numberPost{ID: "1", Title: "<b>"})).To(Succeed())
+		Expect(encoder.Close()).To(Succeed())
+		Expect(buffer.String()).To(ContainSubstring(`"title":"<b>"`))
+	})
+
+	It("is used for links and relationship data", func() {
+		document, err := MarshalToStructWithCodec(linkedNumberPost{numberPost{ID: "1"}, "<b>"}, CompleteServerInformation{}, codec)
+		Expect(err).ToNot(HaveOccurred())
+		document.Links = Links{"next": Link{Href: "/posts?page=<b>"}}
+		document.SetCodec(codec)
+
+		result, err := codec.Marshal(document)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(result)).To(ContainSubstring(`"search":"http://my.domain/v1/linkedNumberPosts/1?q=<b>"`))
+		Expect(string(result)).To(ContainSubstring(`"next":"/posts?page=<b>"`))
+		Expect(string(result)).To(ContainSubstring(`"data":{"type":"users","id":"<b>"}`))
+
+		payload := []byte(`{
+			"links": {"self": {"href": "/posts/1", "meta": {"count": 1}}},
+			"data": {"type": "linkedNumberPosts", "id": "1", "attributes": {}, "relationships": {
+				"author": {"links": {"related": {"href": "/users/2", "meta": {"count": 2}}}, "data": {"type": "users", "id": "2"}}
+			}},
+			"errors": [{"links": {"about": {"href": "/errors/1", "meta": {"count": 3}}}}]
+		}`)
+		decoded, err := decodeDocument(payload, codec)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(decoded.Links["self"].Meta["count"]).To(Equal(json.Number("1")))
+		relationship := decoded.Data.DataObject.Relationships["author"]
+		Expect(relationship.Links["related"].Meta["count"]).To(Equal(json.Number("2")))
+		Expect(relationship.Data.DataObject).To(Equal(&RelationshipData{Type: "users", ID: "2"}))
+		Expect(decoded.Errors[0].Links["about"].Meta["count"]).To(Equal(json.Number("3")))
+
+		decoded, err = decodeDocument(payload, nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(decoded.Links["self"].Meta["count"]).To(Equal(float64(1)))
+	})
+})
@@ -16,6 +16,97 @@ type Document struct {
 	Data     *DataContainer         `json:"data"`
 	Included []Data                 `json:"included,omitempty"`
 	Meta     map[string]interface{} `json:"meta,omitempty"`
+	JSONAPI  *JSONAPIObject         `json:"jsonapi,omitempty"`
+	Errors   []ErrorObject          `json:"errors,omitempty"`
+}
+
+// documentPayload is used to decode the members of a Document with the codec
+// that decodes the Document itself
+type documentPayload struct {
+	Document
+	Links    map[string]json.RawMessage `json:"links"`
+	Data     json.RawMessage            `json:"data"`
+	Included []dataPayload              `json:"included"`
+	Errors   []errorPayload             `json:"errors"`
+}
+
+// errorPayload is used to decode the links of an ErrorObject
+type errorPayload struct {
+	ErrorObject
+	Links map[string]json.RawMessage `json:"links"`
+}
+
+// decodeDocument decodes payload and all of its members with codec, or with
+// the codec set with SetCodec if it is nil
+func decodeDocument(payload []byte, codec Codec) (*Document, error) {
+	var decoded documentPayload
+	if err := codecOrDefault(codec).Unmarshal(payload, &decoded); err != nil {
+		return nil, err
+	}
+
+	document := decoded.Document
+
+	links, err := decodeLinks(decoded.Links, codec)
+	if err != nil {
+		return nil, err
+	}
+	document.Links = links
+
+	if len(decoded.Data) > 0 && string(decoded.Data) != "null" {
+		document.Data = &DataContainer{codec: codec}
+		if err := document.Data.UnmarshalJSON(decoded.Data); err != nil {
+			return nil, err
+		}
+	}
+
+	if decoded.Included != nil {
+		document.Included = make([]Data, len(decoded.Included))
+		for i := range decoded.Included {
+			if err := decoded.Included[i].decode(&document.Included[i], codec); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if decoded.Errors != nil {
+		document.Errors = make([]ErrorObject, len(decoded.Errors))
+		for i, payload := range decoded.Errors {
+			document.Errors[i] = payload.ErrorObject
+			document.Errors[i].Links, err = decodeLinks(payload.Links, codec)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return &document, nil
+}
+
+// JSONAPIObject describes the server implementation, see
+// http://jsonapi.org/format/#document-jsonapi-object
+type JSONAPIObject struct {
+	Version string `json:"version,omitempty"`
+	Meta    Meta   `json:"meta,omitempty"`
+}
+
+// ErrorObject is one entry of the errors of a document, see
+// http://jsonapi.org/format/#error-objects
+type ErrorObject struct {
+	ID     string       `json:"id,omitempty"`
+	Links  Links        `json:"links,omitempty"`
+	Status string       `json:"status,omitempty"`
+	Code   string       `json:"code,omitempty"`
+	Title  string       `json:"title,omitempty"`
+	Detail string       `json:"detail,omitempty"`
+	Source *ErrorSource `json:"source,omitempty"`
+	Meta   Meta         `json:"meta,omitempty"`
+}
+
+// ErrorSource contains references to the source of an error. Pointer is a JSON
+// Pointer into the request document, Parameter the name of a query parameter.
+type ErrorSource struct {
+	Pointer   string `json:"pointer,omitempty"`
+	Parameter string `json:"parameter,omitempty"`
 }
 
 // A DataContainer is used to marshal and unmarshal single objects and arrays
@@ -23,17 +114,64 @@ type Document struct {
 type DataContainer struct {
 	DataObject *Data
 	DataArray  []Data
+
+	codec Codec
+}
+
+// SetCodec sets the codec that is used to encode the data, links and
+// relationships of the document. MarshalToStructWithCodec calls it, so it only
+// has to be called again for links that are added afterwards.
+func (d *Document) SetCodec(codec Codec) {
+	setLinksCodec(d.Links, codec)
+
+	if d.Data != nil {
+		d.Data.codec = codec
+		if d.Data.DataObject != nil {
+			d.Data.DataObject.setCodec(codec)
+		}
+		for i := range d.Data.DataArray {
+			d.Data.DataArray[i].setCodec(codec)
+		}
+	}
+
+	for i := range d.Included {
+		d.Included[i].setCodec(codec)
+	}
+
+	for i := range d.Errors {
+		setLinksCodec(d.Errors[i].Links, codec)
+	}
 }
 
 // UnmarshalJSON unmarshals the JSON-encoded data to the DataObject field if the
 // root element is an object or to the DataArray field for arrays.
 func (c *DataContainer) UnmarshalJSON(payload []byte) error {
+	codec := codecOrDefault(c.codec)
+
 	if bytes.HasPrefix(payload, objectSuffix) {
-		return json.Unmarshal(payload, &c.DataObject)
+		var data dataPayload
+		if err := codec.Unmarshal(payload, &data); err != nil {
+			return err
+		}
+
+		c.DataObject = &Data{}
+		return data.decode(c.DataObject, c.codec)
 	}
 
 	if bytes.HasPrefix(payload, arraySuffix) {
-		return json.Unmarshal(payload, &c.DataArray)
+		var datas []dataPayload
+		if err := codec.Unmarshal(payload, &datas); err != nil {
+			return err
+		}
+
+		c.DataArray = make([]Data, len(datas))
+		for i := range datas {
+			if err := datas[i].decode(&c.DataArray[i], c.codec); err != nil {
+				return err
+			}
+		}
+
+		return nil
 	}
 
 	return errors.New("expected a JSON encoded object or array")
@@ -42,17 +180,20 @@ func (c *DataContainer) UnmarshalJSON(payload []byte) error {
 // MarshalJSON returns the JSON encoding of the DataArray field or the DataObject
 // field. It will return "null" if neither of them is set.
 func (c *DataContainer) MarshalJSON() ([]byte, error) {
+	codec := codecOrDefault(c.codec)
 	if c.DataArray != nil {
-		return json.Marshal(c.DataArray)
+		return codec.Marshal(c.DataArray)
 	}
 
-	return json.Marshal(c.DataObject)
+	return codec.Marshal(c.DataObject)
 }
 
 // Link represents a link for return in the document.
 type Link struct {
 	Href string `json:"href"`
 	Meta Meta   `json:"meta,omitempty"`
+
+	codec Codec
 }
 
 // UnmarshalJSON marshals a string value into the Href field or marshals an
@@ -64,13 +205,15 @@ func (l *Link) UnmarshalJSON(payload []byte) error {
 		return nil
 	}
 
+	codec := codecOrDefault(l.codec)
+
 	if bytes.HasPrefix(payload, stringSuffix) {
-		return json.Unmarshal(payload, &l.Href)
+		return codec.Unmarshal(payload, &l.Href)
 	}
 
 	if bytes.HasPrefix(payload, objectSuffix) {
 		obj := make(map[string]interface{})
-		err := json.Unmarshal(payload, &obj)
+		err := codec.Unmarshal(payload, &obj)
 		if err != nil {
 			return err
 		}
@@ -90,13 +233,14 @@ func (l *Link) UnmarshalJSON(payload []byte) error {
 // MarshalJSON returns the JSON encoding of only the Href field if the Meta
 // field is empty, otherwise it marshals the whole struct.
 func (l Link) MarshalJSON() ([]byte, error) {
+	codec := codecOrDefault(l.codec)
 	if l.Empty() {
-		return json.Marshal(nil)
+		return codec.Marshal(nil)
 	}
 	if len(l.Meta) == 0 {
-		return json.Marshal(l.Href)
+		return codec.Marshal(l.Href)
 	}
-	return json.Marshal(map[string]interface{}{
+	return codec.Marshal(map[string]interface{}{
 		"href": l.Href,
 		"meta": l.Meta,
 	})
@@ -110,6 +254,32 @@ func (l Link) Empty() bool {
 // Links contains a map of custom Link objects as given by an element.
 type Links map[string]Link
 
+// setLinksCodec sets the codec of all links
+func setLinksCodec(links Links, codec Codec) {
+	for name, link := range links {
+		link.codec = codec
+		links[name] = link
+	}
+}
+
+// decodeLinks decodes every link with the given codec
+func decodeLinks(payload map[string]json.RawMessage, codec Codec) (Links, error) {
+	if payload == nil {
+		return nil, nil
+	}
+
+	links := make(Links, len(payload))
+	for name, raw := range payload {
+		link := Link{codec: codec}
+		if err := link.UnmarshalJSON(raw); err != nil {
+			return nil, err
+		}
+		links[name] = link
+	}
+
+	return links, nil
+}
+
 // Meta contains unstructured metadata
 type Meta map[string]interface{}
 
@@ -123,6 +293,53 @@ type Data struct {
 	Meta          json.RawMessage         `json:"meta,omitempty"`
 }
 
+// setCodec sets the codec of the links and relationships of d
+func (d *Data) setCodec(codec Codec) {
+	setLinksCodec(d.Links, codec)
+
+	for name, relationship := range d.Relationships {
+		setLinksCodec(relationship.Links, codec)
+		if relationship.Data != nil {
+			relationship.Data.codec = codec
+		}
+		d.Relationships[name] = relationship
+	}
+}
+
+// dataPayload is used to decode the links and relationships of a Data with the
+// codec that decodes the Data itself
+type dataPayload struct {
+	Data
+	Relationships map[string]relationshipPayload `json:"relationships"`
+	Links         map[string]json.RawMessage     `json:"links"`
+}
+
+// decode sets data to the decoded payload
+func (p dataPayload) decode(data *Data, codec Codec) error {
+	*data = p.Data
+
+	links, err := decodeLinks(p.Links, codec)
+	if err != nil {
+		return err
+	}
+	data.Links = links
+
+	if p.Relationships == nil {
+		return nil
+	}
+
+	data.Relationships = make(map[string]Relationship, len(p.Relationships))
+	for name, payload := range p.Relationships {
+		relationship, err := payload.decode(codec)
+		if err != nil {
+			return err
+		}
+		data.Relationships[name] = relationship
+	}
+
+	return nil
+}
+
 // Relationship contains reference IDs to the related structs
 type Relationship struct {
 	Links Links                      `json:"links,omitempty"`
@@ -135,19 +352,51 @@ type Relationship struct {
 type RelationshipDataContainer struct {
 	DataObject *RelationshipData
 	DataArray  []RelationshipData
+
+	codec Codec
+}
+
+// relationshipPayload is used to decode the links and data of a Relationship
+// with the codec that decodes the Relationship itself
+type relationshipPayload struct {
+	Relationship
+	Links map[string]json.RawMessage `json:"links"`
+	Data  json.RawMessage            `json:"data"`
+}
+
+// decode returns the decoded relationship
+func (p relationshipPayload) decode(codec Codec) (Relationship, error) {
+	relationship := p.Relationship
+
+	links, err := decodeLinks(p.Links, codec)
+	if err != nil {
+		return relationship, err
+	}
+	relationship.Links = links
+
+	if len(p.Data) > 0 && string(p.Data) != "null" {
+		relationship.Data = &RelationshipDataContainer{codec: codec}
+		if err := relationship.Data.UnmarshalJSON(p.Data); err != nil {
+			return relationship, err
+		}
+	}
+
+	return relationship, nil
 }
 
 // UnmarshalJSON unmarshals the JSON-encoded data to the DataObject field if the
 // root element is an object or to the DataArray field for arrays.
 func (c *RelationshipDataContainer) UnmarshalJSON(payload []byte) error {
+	codec := codecOrDefault(c.codec)
+
 	if bytes.HasPrefix(payload, objectSuffix) {
 		// payload is an object
-		return json.Unmarshal(payload, &c.DataObject)
+		return codec.Unmarshal(payload, &c.DataObject)
 	}
 
 	if bytes.HasPrefix(payload, arraySuffix) {
 		// payload is an array
-		return json.Unmarshal(payload, &c.DataArray)
+		return codec.Unmarshal(payload, &c.DataArray)
 	}
 
 	return errors.New("Invalid json for relationship data array/object")
@@ -156,10 +405,11 @@ func (c *RelationshipDataContainer) UnmarshalJSON(payload []byte) error {
 // MarshalJSON returns the JSON encoding of the DataArray field or the DataObject
 // field. It will return "null" if neither of them is set.
 func (c *RelationshipDataContainer) MarshalJSON() ([]byte, error) {
+	codec := codecOrDefault(c.codec)
 	if c.DataArray != nil {
-		return json.Marshal(c.DataArray)
+		return codec.Marshal(c.DataArray)
 	}
-	return json.Marshal(c.DataObject)
+	return codec.Marshal(c.DataObject)
 }
 
 // RelationshipData represents one specific reference ID.
@@ -0,0 +1,203 @@
+package jsonapi
+
+import (
+	"errors"
+	"io"
+)
+
+// An Encoder writes a JSON API document with an array of resources to an
+// output stream. Every element is written as soon as it is passed to Encode,
+// so large collections never have to be held in memory at once. Included
+// resources, links and meta are written when the Encoder is closed.
+type Encoder struct {
+	// Links are written as top-level links on Close if not empty.
+	Links Links
+	// Meta is written as top-level meta on Close if not empty.
+	Meta map[string]interface{}
+
+	w               io.Writer
+	information     ServerInformation
+	filter          func(*Data) error
+	codec           Codec
+	names           *typeNames
+	started         bool
+	closed          bool
+	included        []Data
+	alreadyIncluded map[string]map[string]bool
+	err             error
+}
+
+// NewEncoder returns a new Encoder that writes to w. The ServerInformation is
+// optional and used to generate urls inside `links` like in MarshalWithURLs.
+func NewEncoder(w io.Writer, information ServerInformation) *Encoder {
+	return &Encoder{
+		w:               w,
+		information:     information,
+		alreadyIncluded: map[string]map[string]bool{},
+	}
+}
+
+// SetFilter registers a function that is called with every data and included
+// element before it is written. It can be used to modify the element, for
+// example to remove attributes. If it returns an error, encoding stops.
+func (e *Encoder) SetFilter(filter func(*Data) error) {
+	e.filter = filter
+}
+
+// SetCodec sets the codec that is used to encode the elements, by default the
+// codec set with SetCodec is used.
+func (e *Encoder) SetCodec(codec Codec) {
+	e.codec = codec
+}
+
+// SetTypeNamer sets the type namer that is used for the types of the elements,
+// by default the type namer set with SetTypeNamer is used.
+func (e *Encoder) SetTypeNamer(namer TypeNamer) {
+	e.names = newTypeNames(namer)
+}
+
+// Encode writes the JSON encoding of element as the next item of the data
+// array. Element must implement MarshalIdentifier or use `jsonapi` struct tags.
+// Once an error occurred, all following calls return that error.
+func (e *Encoder) Encode(v interface{}) error {
+	if e.err != nil {
+		return e.err
+	}
+
+	if e.closed {
+		return errors.New("encoder is already closed")
+	}
+
+	if e.err = CheckTags(v); e.err != nil {
+		return e.err
+	}
+
+	element, ok := wrapTagged(v, e.codec, e.names).(MarshalIdentifier)
+	if !ok || element == nil {
+		e.err = errors.New("element must implement MarshalIdentifier and must not be nil")
+		return e.err
+	}
+
+	codec := codecOrDefault(e.codec)
+
+	var data Data
+	if e.err = marshalData(element, &data, e.information, codec, e.names); e.err != nil {
+		return e.err
+	}
+
+	if e.filter != nil {
+		if e.err = e.filter(&data); e.err != nil {
+			return e.err
+		}
+	}
+
+	data.setCodec(e.codec)
+	result, err := codec.Marshal(data)
+	if err != nil {
+		e.err = err
+		return err
+	}
+
+	if included, ok := element.(MarshalIncludedRelations); ok {
+		if e.err = e.include(recursivelyEmbedIncludes(included.GetReferencedStructs())); e.err != nil {
+			return e.err
+		}
+	}
+
+	if e.started {
+		e.write([]byte(","))
+	} else {
+		e.write([]byte(`{"data":[`))
+		e.started = true
+	}
+	e.write(result)
+
+	return e.err
+}
+
+// Close finishes the document. It must be called after the last element
+// was encoded, it does not close the underlying writer.
+func (e *Encoder) Close() error {
+	if e.err != nil || e.closed {
+		return e.err
+	}
+
+	e.closed = true
+	if !e.started {
+		e.write([]byte(`{"data":[`))
+		e.started = true
+	}
+	e.write([]byte("]"))
+
+	if len(e.included) > 0 {
+		e.writeMember("included", e.included)
+	}
+
+	if len(e.Links) > 0 {
+		setLinksCodec(e.Links, e.codec)
+		e.writeMember("links", e.Links)
+	}
+
+	if len(e.Meta) > 0 {
+		e.writeMember("meta", e.Meta)
+	}
+
+	e.write([]byte("}"))
+
+	return e.err
+}
+
+func (e *Encoder) include(elements []MarshalIdentifier) error {
+	for _, element := range elements {
+		structType := e.names.structType(element)
+
+		if e.alreadyIncluded[structType] == nil {
+			e.alreadyIncluded[structType] = make(map[string]bool)
+		}
+
+		if e.alreadyIncluded[structType][element.GetID()] {
+			continue
+		}
+
+		var data Data
+		err := marshalData(element, &data, e.information, codecOrDefault(e.codec), e.names)
+		if err != nil {
+			return err
+		}
+
+		if e.filter != nil {
+			if err := e.filter(&data); err != nil {
+				return err
+			}
+		}
+
+		data.setCodec(e.codec)
+		e.included = append(e.included, data)
+		e.alreadyIncluded[structType][element.GetID()] = true
+	}
+
+	return nil
+}
+
+func (e *Encoder) writeMember(name string, value interface{}) {
+	if e.err != nil {
+		return
+	}
+
+	result, err := codecOrDefault(e.codec).Marshal(value)
+	if err != nil {
+		e.err = err
+		return
+	}
+
+	e.write([]byte(`,"` + name + `":`))
+	e.write(result)
+}
+
+func (e *Encoder) write(p []byte) {
+	if e.err != nil {
+		return
+	}
+
+	_, e.err = e.w.Write(p)
+}
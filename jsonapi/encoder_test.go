@@ -0,0 +1,118 @@
+package jsonapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type failingWriter struct{}
+
+func (f failingWriter) Write(p []byte) (int, error) {
+	return 0, errors.New("write failed")
+}
+
+var _ = Describe("Encoder", func() {
+	var (
+		buffer  *bytes.Buffer
+		encoder *Encoder
+		posts   []Post
+	)
+
+	BeforeEach(func() {
+		buffer = &bytes.Buffer{}
+		encoder = NewEncoder(buffer, CompleteServerInformation{})
+		posts = []Post{
+			{
+				ID:       1,
+				Title:    "First",
+				Author:   &User{ID: 1, Name: "Test Author"},
+				Comments: []Comment{{ID: 1, Text: "First!"}, {ID: 2, Text: "Second!"}},
+			},
+			{
+				ID:       2,
+				Title:    "Second",
+				Author:   &User{ID: 1, Name: "Test Author"},
+				Comments: []Comment{{ID: 2, Text: "Second!"}},
+			},
+		}
+	})
+
+	It("writes the same document as MarshalWithURLs", func() {
+		for _, post := range posts {
+			Expect(encoder.Encode(post)).To(Succeed())
+		}
+		Expect(encoder.Close()).To(Succeed())
+
+		expected, err := MarshalWithURLs(posts, CompleteServerInformation{})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(buffer.String()).To(MatchJSON(expected))
+	})
+
+	It("writes elements before the encoder is closed", func() {
+		Expect(encoder.Encode(SimplePost{ID: "1", Title: "First"})).To(Succeed())
+		Expect(buffer.String()).To(HavePrefix(`{"data":[{"type":"simplePosts","id":"1"`))
+	})
+
+	It("writes an empty data array", func() {
+		Expect(encoder.Close()).To(Succeed())
+		Expect(buffer.String()).To(MatchJSON(`{"data": []}`))
+	})
+
+	It("writes links and meta", func() {
+		encoder = NewEncoder(buffer, nil)
+		encoder.Links = Links{"self": Link{Href: "/posts"}}
+		encoder.Meta = map[string]interface{}{"total": 1}
+		Expect(encoder.Encode(SimplePost{ID: "1", Title: "First"})).To(Succeed())
+		Expect(encoder.Close()).To(Succeed())
+
+		var document Document
+		Expect(json.Unmarshal(buffer.Bytes(), &document)).To(Succeed())
+		Expect(document.Data.DataArray).To(HaveLen(1))
+		Expect(document.Links).To(Equal(Links{"self": Link{Href: "/posts"}}))
+		Expect(document.Meta).To(Equal(map[string]interface{}{"total": float64(1)}))
+	})
+
+	It("calls the filter for data and included elements", func() {
+		var types []string
+		encoder.SetFilter(func(data *Data) error {
+			types = append(types, data.Type)
+			data.Attributes = []byte("{}")
+			return nil
+		})
+		Expect(encoder.Encode(posts[0])).To(Succeed())
+		Expect(encoder.Close()).To(Succeed())
+		Expect(types).To(Equal([]string{"posts", "users", "comments", "comments"}))
+		Expect(buffer.String()).ToNot(ContainSubstring("First!"))
+	})
+
+	It("stops after an error", func() {
+		filterErr := errors.New("invalid")
+		encoder.SetFilter(func(data *Data) error {
+			return filterErr
+		})
+		Expect(encoder.Encode(posts[0])).To(Equal(filterErr))
+		Expect(encoder.Encode(posts[1])).To(Equal(filterErr))
+		Expect(encoder.Close()).To(Equal(filterErr))
+		Expect(buffer.Len()).To(Equal(0))
+	})
+
+	It("rejects nil elements", func() {
+		var post *SimplePost
+		Expect(encoder.Encode(post)).To(HaveOccurred())
+		Expect(NewEncoder(buffer, nil).Encode(nil)).To(HaveOccurred())
+	})
+
+	It("returns write errors", func() {
+		encoder = NewEncoder(failingWriter{}, nil)
+		Expect(encoder.Encode(posts[0])).To(MatchError("write failed"))
+	})
+
+	It("rejects elements after close", func() {
+		Expect(encoder.Close()).To(Succeed())
+		Expect(encoder.Encode(posts[0])).To(HaveOccurred())
+	})
+})
@@ -1,6 +1,8 @@
 package jsonapi
 
 import (
+	"encoding/json"
+	"reflect"
 	"strings"
 	"unicode"
 
@@ -59,7 +61,112 @@ func Jsonify(s string) string {
 	return string(rs)
 }
 
-// Pluralize returns the pluralization of a noun.
+// Pluralize returns the pluralization of a noun. Plurals that were registered
+// with AddPlural take precedence.
 func Pluralize(word string) string {
+	if plural, ok := registeredPlural(word); ok {
+		return plural
+	}
+
 	return inflector.Pluralize(word)
 }
+
+var jsonUnmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+
+// An AttributeField is a struct field that is marshalled as attribute. Name is
+// the name before the MemberNamer is applied, Tag contains all struct tags of
+// the field. String is set for the `,string` option of encoding/json.
+type AttributeField struct {
+	Name      string
+	Type      reflect.Type
+	Tag       reflect.StructTag
+	OmitEmpty bool
+	String    bool
+}
+
+// AttributeFields returns the attributes of the struct type t or the struct
+// type that t points to in the order of the fields. It uses the jsonapi struct
+// tags or the fields that encoding/json decodes. The result is only valid if
+// ok is true, this is not the case for types that implement json.Unmarshaler.
+func AttributeFields(t reflect.Type) (fields []AttributeField, ok bool) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if t.Kind() != reflect.Struct || reflect.PtrTo(t).Implements(jsonUnmarshalerType) {
+		return nil, false
+	}
+
+	if definition := getTagDefinition(t); definition != nil {
+		for _, attribute := range definition.attributes {
+			fields = append(fields, AttributeField{
+				Name:      attribute.name,
+				Type:      t.Field(attribute.index).Type,
+				Tag:       t.Field(attribute.index).Tag,
+				OmitEmpty: attribute.omitEmpty,
+			})
+		}
+
+		return fields, true
+	}
+
+	fields = []AttributeField{}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+
+		options := strings.Split(tag, ",")
+		name := options[0]
+
+		fieldType := field.Type
+		if fieldType.Kind() == reflect.Ptr {
+			fieldType = fieldType.Elem()
+		}
+
+		// fields of embedded structs are promoted unless the struct is named
+		if field.Anonymous && name == "" && fieldType.Kind() == reflect.Struct {
+			embedded, ok := AttributeFields(fieldType)
+			if !ok {
+				return nil, false
+			}
+			fields = append(fields, embedded...)
+			continue
+		}
+
+		if field.PkgPath != "" {
+			continue
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+
+		attribute := AttributeField{Name: name, Type: field.Type, Tag: field.Tag}
+		for _, option := range options[1:] {
+			attribute.OmitEmpty = attribute.OmitEmpty || option == "omitempty"
+			attribute.String = attribute.String || option == "string"
+		}
+		fields = append(fields, attribute)
+	}
+
+	return fields, true
+}
+
+// jsonFieldNames returns the names of all attributes of the given struct type,
+// see AttributeFields
+func jsonFieldNames(t reflect.Type) (names map[string]bool, ok bool) {
+	fields, ok := AttributeFields(t)
+	if !ok {
+		return nil, false
+	}
+
+	names = make(map[string]bool, len(fields))
+	for _, field := range fields {
+		names[field.Name] = true
+	}
+
+	return names, true
+}
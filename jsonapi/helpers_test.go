@@ -1,6 +1,9 @@
 package jsonapi
 
 import (
+	"reflect"
+	"time"
+
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 )
@@ -23,4 +26,25 @@ var _ = Describe("StringHelpers", func() {
 			})
 		})
 	})
+
+	Context("AttributeFields", func() {
+		It("returns the attributes of a struct", func() {
+			fields, ok := AttributeFields(reflect.TypeOf(SimplePost{}))
+			Expect(ok).To(BeTrue())
+			Expect(fields).To(Equal([]AttributeField{
+				{Name: "title", Type: reflect.TypeOf(""), Tag: `json:"title"`},
+				{Name: "text", Type: reflect.TypeOf(""), Tag: `json:"text"`},
+				{Name: "size", Type: reflect.TypeOf(0), Tag: `json:"size"`},
+				{Name: "created-date", Type: reflect.TypeOf(time.Time{}), Tag: `json:"created-date"`},
+				{Name: "updated-date", Type: reflect.TypeOf(time.Time{}), Tag: `json:"updated-date"`},
+			}))
+		})
+
+		It("includes embedded structs", func() {
+			fields, ok := AttributeFields(reflect.TypeOf(&SimplePostWithMetadata{}))
+			Expect(ok).To(BeTrue())
+			Expect(fields).To(HaveLen(6))
+			Expect(fields[0].Name).To(Equal("title"))
+		})
+	})
 })
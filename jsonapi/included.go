@@ -0,0 +1,151 @@
+package jsonapi
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// includedKey identifies an unmarshalled included resource, the same resource
+// can be unmarshalled into different go types
+type includedKey struct {
+	typ    string
+	id     string
+	goType reflect.Type
+}
+
+// includedResolver unmarshals the included resources of a document into the
+// structs of targets that implement UnmarshalIncludedRelations
+type includedResolver struct {
+	included map[string]*Data
+	resolved map[includedKey]interface{}
+	codec    Codec
+	names    *typeNames
+}
+
+func newIncludedResolver(included []Data, codec Codec, names *typeNames) *includedResolver {
+	resolver := &includedResolver{
+		included: make(map[string]*Data, len(included)),
+		resolved: map[includedKey]interface{}{},
+		codec:    codec,
+		names:    names,
+	}
+
+	for i := range included {
+		resolver.included[included[i].Type+"/"+included[i].ID] = &included[i]
+	}
+
+	return resolver
+}
+
+// resolve passes the included resources of all relationships of data to target
+func (r *includedResolver) resolve(data *Data, target interface{}) error {
+	if len(r.included) == 0 {
+		return nil
+	}
+
+	// relationships that point back to target use target itself
+	r.resolved[includedKey{typ: data.Type, id: data.ID, goType: reflect.TypeOf(target)}] = target
+
+	relations, ok := wrapTagged(target, r.codec, r.names).(UnmarshalIncludedRelations)
+	if !ok {
+		return nil
+	}
+
+	for name, relationship := range localRelationships(data.Relationships, target) {
+		if relationship.Data == nil {
+			continue
+		}
+
+		references := relationship.Data.DataArray
+		if relationship.Data.DataObject != nil {
+			references = []RelationshipData{*relationship.Data.DataObject}
+		}
+
+		structs := []interface{}{}
+		found := false
+		for _, reference := range references {
+			element, resolved, err := r.unmarshal(name, reference, relations)
+			if err != nil {
+				return err
+			}
+
+			if element == nil {
+				found = false
+				break
+			}
+
+			found = found || resolved
+			structs = append(structs, element)
+		}
+
+		// without included resources the IDs are already set
+		if !found {
+			continue
+		}
+
+		if err := relations.SetReferencedStructs(name, structs); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// unmarshal returns the struct for a reference of the relationship name and
+// whether it was found in the document. Resources that are not included only
+// get their ID. The struct is nil if the relationship should not be resolved.
+func (r *includedResolver) unmarshal(name string, reference RelationshipData, relations UnmarshalIncludedRelations) (interface{}, bool, error) {
+	element, err := relations.NewReferencedStruct(name)
+	if err != nil || element == nil {
+		return nil, false, err
+	}
+
+	if reflect.TypeOf(element).Kind() != reflect.Ptr {
+		return nil, false, fmt.Errorf("NewReferencedStruct must return a pointer for relationship %s, got %T", name, element)
+	}
+
+	key := includedKey{typ: reference.Type, id: reference.ID, goType: reflect.TypeOf(element)}
+	if existing, ok := r.resolved[key]; ok {
+		return existing, true, nil
+	}
+
+	data, ok := r.included[reference.Type+"/"+reference.ID]
+	if !ok {
+		identifier, ok := wrapTagged(element, r.codec, r.names).(UnmarshalIdentifier)
+		if !ok {
+			return nil, false, fmt.Errorf("%T must implement UnmarshalIdentifier", element)
+		}
+
+		return element, false, identifier.SetID(reference.ID)
+	}
+
+	// included resources often contain relationships that the related structs
+	// do not unmarshal, those are skipped instead of failing
+	accepted := *data
+	accepted.Relationships = acceptedRelationships(data.Relationships, wrapTagged(element, r.codec, r.names))
+	if err := setDataIntoTarget(&accepted, element, r.codec, r.names); err != nil {
+		return nil, false, err
+	}
+
+	if err := r.resolve(data, element); err != nil {
+		return nil, false, err
+	}
+
+	return element, true, nil
+}
+
+// acceptedRelationships returns the relationships that target can unmarshal
+func acceptedRelationships(relationships map[string]Relationship, target interface{}) map[string]Relationship {
+	_, toOne := target.(UnmarshalToOneRelations)
+	_, toMany := target.(UnmarshalToManyRelations)
+
+	result := map[string]Relationship{}
+	for name, relationship := range relationships {
+		isToMany := relationship.Data != nil && relationship.Data.DataArray != nil
+		if (isToMany && toMany) || (!isToMany && toOne) {
+			result[name] = relationship
+		}
+	}
+
+	return result
+}
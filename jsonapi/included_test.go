@@ -0,0 +1,174 @@
+package jsonapi
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type TaggedPerson struct {
+	ID    string         `jsonapi:"primary,people"`
+	Name  string         `jsonapi:"attr,name"`
+	Posts []*TaggedEntry `jsonapi:"relation,posts"`
+}
+
+type TaggedEntry struct {
+	ID     string        `jsonapi:"primary,entries"`
+	Title  string        `jsonapi:"attr,title"`
+	Author *TaggedPerson `jsonapi:"relation,author"`
+	Notes  []TaggedNote  `jsonapi:"relation,notes"`
+}
+
+type TaggedNote struct {
+	ID   string `jsonapi:"primary,notes"`
+	Text string `jsonapi:"attr,text"`
+}
+
+// IncludingBlog implements UnmarshalIncludedRelations without tags
+type IncludingBlog struct {
+	ID      string
+	PostIDs []string
+	Posts   []*SimplePost
+	skip    bool
+	fail    bool
+}
+
+func (b IncludingBlog) GetID() string {
+	return b.ID
+}
+
+func (b *IncludingBlog) SetID(ID string) error {
+	b.ID = ID
+	return nil
+}
+
+func (b *IncludingBlog) SetToManyReferenceIDs(name string, IDs []string) error {
+	b.PostIDs = IDs
+	return nil
+}
+
+func (b *IncludingBlog) NewReferencedStruct(name string) (interface{}, error) {
+	if b.skip {
+		return nil, nil
+	}
+
+	if b.fail {
+		return SimplePost{}, nil
+	}
+
+	return &SimplePost{}, nil
+}
+
+func (b *IncludingBlog) SetReferencedStructs(name string, structs []interface{}) error {
+	if name != "posts" {
+		return errors.New("unknown relationship")
+	}
+
+	for _, element := range structs {
+		b.Posts = append(b.Posts, element.(*SimplePost))
+	}
+
+	return nil
+}
+
+var _ = Describe("Unmarshalling included resources", func() {
+	peopleJSON := []byte(`{
+		"data": [{
+			"type": "people",
+			"id": "1",
+			"attributes": {"name": "Jane"},
+			"relationships": {
+				"posts": {"data": [{"type": "entries", "id": "10"}, {"type": "entries", "id": "11"}]}
+			}
+		}],
+		"included": [
+			{
+				"type": "entries",
+				"id": "10",
+				"attributes": {"title": "Hello"},
+				"relationships": {
+					"author": {"data": {"type": "people", "id": "1"}},
+					"notes": {"data": [{"type": "notes", "id": "100"}]}
+				}
+			},
+			{
+				"type": "notes",
+				"id": "100",
+				"attributes": {"text": "Nice"}
+			}
+		]
+	}`)
+
+	It("resolves nested includes", func() {
+		var people []*TaggedPerson
+		Expect(Unmarshal(peopleJSON, &people)).To(Succeed())
+		Expect(people).To(HaveLen(1))
+
+		person := people[0]
+		Expect(person.Name).To(Equal("Jane"))
+		Expect(person.Posts).To(HaveLen(2))
+		Expect(person.Posts[0].Title).To(Equal("Hello"))
+		Expect(person.Posts[0].Notes).To(Equal([]TaggedNote{{ID: "100", Text: "Nice"}}))
+	})
+
+	It("keeps the IDs of resources that are not included", func() {
+		var people []*TaggedPerson
+		Expect(Unmarshal(peopleJSON, &people)).To(Succeed())
+		Expect(people[0].Posts[1]).To(Equal(&TaggedEntry{ID: "11"}))
+	})
+
+	It("resolves cycles with the same pointer", func() {
+		var people []*TaggedPerson
+		Expect(Unmarshal(peopleJSON, &people)).To(Succeed())
+		Expect(people[0].Posts[0].Author).To(BeIdenticalTo(people[0]))
+	})
+
+	It("resolves includes of single objects", func() {
+		var entry TaggedEntry
+		Expect(Unmarshal([]byte(`{
+			"data": {
+				"type": "entries",
+				"id": "10",
+				"relationships": {"author": {"data": {"type": "people", "id": "1"}}}
+			},
+			"included": [{"type": "people", "id": "1", "attributes": {"name": "Jane"}}]
+		}`), &entry)).To(Succeed())
+		Expect(entry.Author).To(Equal(&TaggedPerson{ID: "1", Name: "Jane"}))
+	})
+
+	Context("with an UnmarshalIncludedRelations implementation", func() {
+		blogJSON := []byte(`{
+			"data": {
+				"type": "includingBlogs",
+				"id": "1",
+				"relationships": {"posts": {"data": [{"type": "simplePosts", "id": "2"}]}}
+			},
+			"included": [{
+				"type": "simplePosts",
+				"id": "2",
+				"attributes": {"title": "Included"},
+				"relationships": {"comments": {"data": []}}
+			}]
+		}`)
+
+		It("passes the included structs", func() {
+			var blog IncludingBlog
+			Expect(Unmarshal(blogJSON, &blog)).To(Succeed())
+			Expect(blog.PostIDs).To(Equal([]string{"2"}))
+			Expect(blog.Posts).To(Equal([]*SimplePost{{ID: "2", Title: "Included"}}))
+		})
+
+		It("skips relationships without struct", func() {
+			blog := IncludingBlog{skip: true}
+			Expect(Unmarshal(blogJSON, &blog)).To(Succeed())
+			Expect(blog.Posts).To(BeNil())
+		})
+
+		It("needs pointers", func() {
+			blog := IncludingBlog{fail: true}
+			err := Unmarshal(blogJSON, &blog)
+			Expect(err).To(MatchError("NewReferencedStruct must return a pointer for relationship posts, got jsonapi.SimplePost"))
+		})
+	})
+})
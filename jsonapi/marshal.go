@@ -1,7 +1,6 @@
 package jsonapi
 
 import (
-	"encoding/json"
 	"errors"
 	"fmt"
 	"reflect"
@@ -108,7 +107,7 @@ func MarshalWithURLs(data interface{}, information ServerInformation) ([]byte, e
 		return nil, err
 	}
 
-	return json.Marshal(document)
+	return GetCodec().Marshal(document)
 }
 
 // Marshal wraps data in a Document and returns its JSON encoding.
@@ -121,7 +120,7 @@ func Marshal(data interface{}) ([]byte, error) {
 		return nil, err
 	}
 
-	return json.Marshal(document)
+	return GetCodec().Marshal(document)
 }
 
 // MarshalToStruct marshals an api2go compatible struct into a jsonapi Document
@@ -129,26 +128,61 @@ func Marshal(data interface{}) ([]byte, error) {
 // you want to extract or extend parts of the document. You should directly use
 // Marshal to get a []byte with JSON in it.
 func MarshalToStruct(data interface{}, information ServerInformation) (*Document, error) {
+	return MarshalToStructWithCodec(data, information, nil)
+}
+
+// MarshalToStructWithCodec works like MarshalToStruct but encodes attributes
+// and meta with the given codec instead of the one set with SetCodec.
+func MarshalToStructWithCodec(data interface{}, information ServerInformation, codec Codec) (*Document, error) {
+	return MarshalToStructWithOptions(data, information, Options{Codec: codec})
+}
+
+// MarshalToStructWithOptions works like MarshalToStruct but uses the codec and
+// the type namer of options instead of the ones of this package.
+func MarshalToStructWithOptions(data interface{}, information ServerInformation, options Options) (*Document, error) {
+	codec := options.Codec
+	names := newTypeNames(options.TypeNamer)
+
 	if data == nil {
 		return &Document{}, nil
 	}
 
+	var document *Document
+	var err error
+
 	switch reflect.TypeOf(data).Kind() {
 	case reflect.Slice:
-		return marshalSlice(data, information)
+		document, err = marshalSlice(data, information, codecOrDefault(codec), names)
 	case reflect.Struct, reflect.Ptr:
-		return marshalStruct(data.(MarshalIdentifier), information)
+		if err := CheckTags(data); err != nil {
+			return nil, err
+		}
+		element, ok := wrapTagged(data, codec, names).(MarshalIdentifier)
+		if !ok {
+			return nil, errors.New("data must implement api2go.MarshalIdentifier or use jsonapi struct tags")
+		}
+		document, err = marshalStruct(element, information, codecOrDefault(codec), names)
 	default:
 		return nil, errors.New("Marshal only accepts slice, struct or ptr types")
 	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	// the data, links and relationships are marshalled with the same codec as
+	// the attributes
+	document.SetCodec(codec)
+
+	return document, nil
 }
 
 func recursivelyEmbedIncludes(input []MarshalIdentifier) []MarshalIdentifier {
 	var referencedStructs []MarshalIdentifier
 
 	for _, referencedStruct := range input {
-		included, ok := referencedStruct.(MarshalIncludedRelations)
-		if ok {
+		if getTypeInfo(referencedStruct).includedRelations {
+			included := referencedStruct.(MarshalIncludedRelations)
 			referencedStructs = append(referencedStructs, included.GetReferencedStructs()...)
 		}
 	}
@@ -164,33 +198,47 @@ func recursivelyEmbedIncludes(input []MarshalIdentifier) []MarshalIdentifier {
 	return referencedStructs
 }
 
-func marshalSlice(data interface{}, information ServerInformation) (*Document, error) {
+func marshalSlice(data interface{}, information ServerInformation, codec Codec, names *typeNames) (*Document, error) {
 	result := &Document{}
 
 	val := reflect.ValueOf(data)
 	dataElements := make([]Data, val.Len())
 	var referencedStructs []MarshalIdentifier
 
+	// the tags only have to be checked per element if the slice can hold
+	// elements of different types
+	checkElements := val.Type().Elem().Kind() == reflect.Interface
+	if !checkElements {
+		if err := CheckTags(data); err != nil {
+			return nil, err
+		}
+	}
+
 	for i := 0; i < val.Len(); i++ {
-		k := val.Index(i).Interface()
-		element, ok := k.(MarshalIdentifier)
+		value := val.Index(i).Interface()
+		if checkElements {
+			if err := CheckTags(value); err != nil {
+				return nil, err
+			}
+		}
+		element, ok := wrapTagged(value, codec, names).(MarshalIdentifier)
 		if !ok {
 			return nil, errors.New("all elements within the slice must implement api2go.MarshalIdentifier")
 		}
 
-		err := marshalData(element, &dataElements[i], information)
+		err := marshalData(element, &dataElements[i], information, codec, names)
 		if err != nil {
 			return nil, err
 		}
 
-		included, ok := k.(MarshalIncludedRelations)
-		if ok {
+		if getTypeInfo(element).includedRelations {
+			included := element.(MarshalIncludedRelations)
 			referencedStructs = append(referencedStructs, included.GetReferencedStructs()...)
 		}
 	}
 
 	allReferencedStructs := recursivelyEmbedIncludes(referencedStructs)
-	includedElements, err := filterDuplicates(allReferencedStructs, information)
+	includedElements, err := filterDuplicates(allReferencedStructs, information, codec, names)
 	if err != nil {
 		return nil, err
 	}
@@ -206,12 +254,12 @@ func marshalSlice(data interface{}, information ServerInformation) (*Document, e
 	return result, nil
 }
 
-func filterDuplicates(input []MarshalIdentifier, information ServerInformation) ([]Data, error) {
+func filterDuplicates(input []MarshalIdentifier, information ServerInformation, codec Codec, names *typeNames) ([]Data, error) {
 	alreadyIncluded := map[string]map[string]bool{}
 	includedElements := []Data{}
 
 	for _, referencedStruct := range input {
-		structType := getStructType(referencedStruct)
+		structType := names.structType(referencedStruct)
 
 		if alreadyIncluded[structType] == nil {
 			alreadyIncluded[structType] = make(map[string]bool)
@@ -219,7 +267,7 @@ func filterDuplicates(input []MarshalIdentifier, information ServerInformation)
 
 		if !alreadyIncluded[structType][referencedStruct.GetID()] {
 			var data Data
-			err := marshalData(referencedStruct, &data, information)
+			err := marshalData(referencedStruct, &data, information, codec, names)
 			if err != nil {
 				return nil, err
 			}
@@ -232,27 +280,45 @@ func filterDuplicates(input []MarshalIdentifier, information ServerInformation)
 	return includedElements, nil
 }
 
-func marshalData(element MarshalIdentifier, data *Data, information ServerInformation) error {
+func marshalData(element MarshalIdentifier, data *Data, information ServerInformation, codec Codec, names *typeNames) error {
 	refValue := reflect.ValueOf(element)
 	if refValue.Kind() == reflect.Ptr && refValue.IsNil() {
 		return errors.New("MarshalIdentifier must not be nil")
 	}
 
-	attributes, err := json.Marshal(element)
+	// included structs can be wrapped without the codec and type names
+	if tagged, ok := element.(*taggedResource); ok {
+		withOptions := *tagged
+		withOptions.codec = codec
+		withOptions.names = names
+		element = &withOptions
+	}
+
+	info := getTypeInfo(element)
+	attributes, err := marshalAttributes(element, codec)
+	if err != nil {
+		return err
+	}
+
+	attributes, err = memberAttributes(attributes)
 	if err != nil {
 		return err
 	}
 
 	data.Attributes = attributes
 	data.ID = element.GetID()
-	data.Type = getStructType(element)
+	data.Type = names.structType(element)
+
+	// the optional interfaces are not implemented by the adapter of tagged structs
+	source := unwrapTagged(element)
+	sourceInfo := getTypeInfo(source)
 
-	if information != nil {
-		if customLinks, ok := element.(MarshalCustomLinks); ok {
+	if information != nil && sourceInfo.customLinks {
+		if customLinks, ok := source.(MarshalCustomLinks); ok {
 			if data.Links == nil {
 				data.Links = make(Links)
 			}
-			base := getLinkBaseURL(element, information)
+			base := getLinkBaseURL(element, information, names)
 			for k, v := range customLinks.GetCustomLinks(base) {
 				if _, ok := data.Links[k]; !ok {
 					data.Links[k] = v
@@ -261,21 +327,28 @@ func marshalData(element MarshalIdentifier, data *Data, information ServerInform
 		}
 	}
 
-	if casteMetaTarget, ok := element.(MarshalMeta); ok {
-		meta := casteMetaTarget.Meta()
-		data.Meta, err = json.Marshal(meta)
+	if sourceInfo.meta {
+		meta := source.(MarshalMeta).Meta()
+		data.Meta, err = codec.Marshal(meta)
 		if err != nil {
 			return err
 		}
 	}
 
-	if references, ok := element.(MarshalLinkedRelations); ok {
-		data.Relationships = getStructRelationships(references, information)
+	if info.linkedRelations {
+		data.Relationships = getStructRelationships(element.(MarshalLinkedRelations), information, names)
 	}
 
 	return nil
 }
 
+// IsToMany returns true for to-many relationships. Without an explicit
+// Relationship, a reference with a plural name is to-many. Pluralize is used
+// for this, so plurals registered with AddPlural are respected.
+func (r Reference) IsToMany() bool {
+	return isToMany(r.Relationship, r.Name)
+}
+
 func isToMany(relationshipType RelationshipType, name string) bool {
 	if relationshipType == DefaultRelationship {
 		return Pluralize(name) == name
@@ -284,9 +357,9 @@ func isToMany(relationshipType RelationshipType, name string) bool {
 	return relationshipType == ToManyRelationship
 }
 
-func getMetaForRelation(metaSource MarshalCustomRelationshipMeta, name string, information ServerInformation) map[string]interface{} {
+func getMetaForRelation(metaSource MarshalCustomRelationshipMeta, name string, information ServerInformation, names *typeNames) map[string]interface{} {
 	meta := make(map[string]interface{})
-	base := getLinkBaseURL(metaSource, information)
+	base := getLinkBaseURL(metaSource, information, names)
 	if metaMap, ok := metaSource.GetCustomMeta(base)[name]; ok {
 		for k, v := range metaMap {
 			if _, ok := meta[k]; !ok {
@@ -297,7 +370,9 @@ func getMetaForRelation(metaSource MarshalCustomRelationshipMeta, name string, i
 	return meta
 }
 
-func getStructRelationships(relationer MarshalLinkedRelations, information ServerInformation) map[string]Relationship {
+func getStructRelationships(relationer MarshalLinkedRelations, information ServerInformation, names *typeNames) map[string]Relationship {
+	metaSource := unwrapTagged(relationer)
+	info := getTypeInfo(metaSource)
 	referencedIDs := relationer.GetReferencedIDs()
 	sortedResults := map[string][]ReferenceID{}
 	relationships := map[string]Relationship{}
@@ -315,8 +390,6 @@ func getStructRelationships(relationer MarshalLinkedRelations, information Serve
 	}
 
 	for name, referenceIDs := range sortedResults {
-		relationships[name] = Relationship{}
-
 		// if referenceType is plural, we need to use an array for data, otherwise it's just an object
 		container := RelationshipDataContainer{}
 
@@ -337,12 +410,12 @@ func getStructRelationships(relationer MarshalLinkedRelations, information Serve
 		}
 
 		// set URLs if necessary
-		links := getLinksForServerInformation(relationer, name, information)
+		links := getLinksForServerInformation(relationer, name, information, names)
 
 		// get the custom meta for this relationship
 		var meta map[string]interface{}
-		if customMetaSource, ok := relationer.(MarshalCustomRelationshipMeta); ok {
-			meta = getMetaForRelation(customMetaSource, name, information)
+		if info.customRelationshipMeta {
+			meta = getMetaForRelation(metaSource.(MarshalCustomRelationshipMeta), name, information, names)
 		}
 
 		relationship := Relationship{
@@ -351,7 +424,7 @@ func getStructRelationships(relationer MarshalLinkedRelations, information Serve
 			Meta:  meta,
 		}
 
-		relationships[name] = relationship
+		relationships[MemberName(name)] = relationship
 
 		// this marks the reference as already included
 		delete(notIncludedReferences, referenceIDs[0].Name)
@@ -366,12 +439,12 @@ func getStructRelationships(relationer MarshalLinkedRelations, information Serve
 			container.DataArray = []RelationshipData{}
 		}
 
-		links := getLinksForServerInformation(relationer, name, information)
+		links := getLinksForServerInformation(relationer, name, information, names)
 
 		// get the custom meta for this relationship
 		var meta map[string]interface{}
-		if customMetaSource, ok := relationer.(MarshalCustomRelationshipMeta); ok {
-			meta = getMetaForRelation(customMetaSource, name, information)
+		if info.customRelationshipMeta {
+			meta = getMetaForRelation(metaSource.(MarshalCustomRelationshipMeta), name, information, names)
 		}
 
 		relationship := Relationship{
@@ -384,16 +457,16 @@ func getStructRelationships(relationer MarshalLinkedRelations, information Serve
 			relationship.Data = &container
 		}
 
-		relationships[name] = relationship
+		relationships[MemberName(name)] = relationship
 	}
 
 	return relationships
 }
 
-func getLinkBaseURL(element MarshalIdentifier, information ServerInformation) string {
+func getLinkBaseURL(element MarshalIdentifier, information ServerInformation, names *typeNames) string {
 	prefix := strings.Trim(information.GetBaseURL(), "/")
 	namespace := strings.Trim(information.GetPrefix(), "/")
-	structType := getStructType(element)
+	structType := names.structType(element)
 
 	if namespace != "" {
 		prefix += "/" + namespace
@@ -402,24 +475,25 @@ func getLinkBaseURL(element MarshalIdentifier, information ServerInformation) st
 	return fmt.Sprintf("%s/%s/%s", prefix, structType, element.GetID())
 }
 
-func getLinksForServerInformation(relationer MarshalLinkedRelations, name string, information ServerInformation) Links {
+func getLinksForServerInformation(relationer MarshalLinkedRelations, name string, information ServerInformation, names *typeNames) Links {
 	if information == nil {
 		return nil
 	}
 
 	links := make(Links)
-	base := getLinkBaseURL(relationer, information)
+	base := getLinkBaseURL(relationer, information, names)
 
+	name = MemberName(name)
 	links["self"] = Link{Href: fmt.Sprintf("%s/relationships/%s", base, name)}
 	links["related"] = Link{Href: fmt.Sprintf("%s/%s", base, name)}
 
 	return links
 }
 
-func marshalStruct(data MarshalIdentifier, information ServerInformation) (*Document, error) {
+func marshalStruct(data MarshalIdentifier, information ServerInformation, codec Codec, names *typeNames) (*Document, error) {
 	var contentData Data
 
-	err := marshalData(data, &contentData, information)
+	err := marshalData(data, &contentData, information, codec, names)
 	if err != nil {
 		return nil, err
 	}
@@ -432,7 +506,7 @@ func marshalStruct(data MarshalIdentifier, information ServerInformation) (*Docu
 
 	included, ok := data.(MarshalIncludedRelations)
 	if ok {
-		included, err := filterDuplicates(recursivelyEmbedIncludes(included.GetReferencedStructs()), information)
+		included, err := filterDuplicates(recursivelyEmbedIncludes(included.GetReferencedStructs()), information, codec, names)
 		if err != nil {
 			return nil, err
 		}
@@ -446,15 +520,14 @@ func marshalStruct(data MarshalIdentifier, information ServerInformation) (*Docu
 }
 
 func getStructType(data interface{}) string {
-	entityName, ok := data.(EntityNamer)
-	if ok {
-		return entityName.GetName()
-	}
+	return getTypeInfo(data).structType(data)
+}
 
-	reflectType := reflect.TypeOf(data)
-	if reflectType.Kind() == reflect.Ptr {
-		return Pluralize(Jsonify(reflectType.Elem().Name()))
+// structType returns the jsonapi type of data which must be of the cached type
+func (t *typeInfo) structType(data interface{}) string {
+	if t.name == "" {
+		return data.(EntityNamer).GetName()
 	}
 
-	return Pluralize(Jsonify(reflectType.Name()))
+	return t.name
 }
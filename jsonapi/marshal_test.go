@@ -1211,7 +1211,7 @@ var _ = Describe("Marshalling", func() {
 		})
 
 		It("Generates to-one relationships correctly", func() {
-			links := getStructRelationships(post, nil)
+			links := getStructRelationships(post, nil, nil)
 			Expect(links["author"]).To(Equal(Relationship{
 				Data: &RelationshipDataContainer{
 					DataObject: &RelationshipData{
@@ -1223,7 +1223,7 @@ var _ = Describe("Marshalling", func() {
 		})
 
 		It("Generates to-many relationships correctly", func() {
-			links := getStructRelationships(post, nil)
+			links := getStructRelationships(post, nil, nil)
 			Expect(links["comments"]).To(Equal(Relationship{
 				Data: &RelationshipDataContainer{
 					DataArray: []RelationshipData{
@@ -1237,7 +1237,7 @@ var _ = Describe("Marshalling", func() {
 		})
 
 		It("Generates self/related URLs with baseURL and prefix correctly", func() {
-			links := getStructRelationships(post, CompleteServerInformation{})
+			links := getStructRelationships(post, CompleteServerInformation{}, nil)
 			Expect(links["author"]).To(Equal(Relationship{
 				Data: &RelationshipDataContainer{
 					DataObject: &RelationshipData{
@@ -1253,7 +1253,7 @@ var _ = Describe("Marshalling", func() {
 		})
 
 		It("Generates self/related URLs with baseURL correctly", func() {
-			links := getStructRelationships(post, BaseURLServerInformation{})
+			links := getStructRelationships(post, BaseURLServerInformation{}, nil)
 			Expect(links["author"]).To(Equal(Relationship{
 				Data: &RelationshipDataContainer{
 					DataObject: &RelationshipData{
@@ -1269,7 +1269,7 @@ var _ = Describe("Marshalling", func() {
 		})
 
 		It("Generates self/related URLs with prefix correctly", func() {
-			links := getStructRelationships(post, PrefixServerInformation{})
+			links := getStructRelationships(post, PrefixServerInformation{}, nil)
 			Expect(links["author"]).To(Equal(Relationship{
 				Data: &RelationshipDataContainer{
 					DataObject: &RelationshipData{
@@ -1307,7 +1307,7 @@ var _ = Describe("Marshalling", func() {
 		}
 
 		It("should work with default marshalData", func() {
-			actual, err := filterDuplicates(input, nil)
+			actual, err := filterDuplicates(input, nil, StandardCodec, nil)
 			Expect(err).ToNot(HaveOccurred())
 			Expect(len(actual)).To(Equal(len(expected)))
 		})
@@ -0,0 +1,226 @@
+package jsonapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"unicode"
+)
+
+// A MemberNamer translates the names of attributes and relationships into the
+// member names of documents. The names that are passed to it are the `json`
+// tag names of attributes and the names returned by GetReferences.
+//
+// The member namer is applied to marshalling and unmarshalling, to the routes
+// and links of relationships and therefore also to sparse fieldsets. Member
+// names inside of attribute values are not changed.
+type MemberNamer interface {
+	MemberName(name string) string
+}
+
+// MemberNamerFunc can be used to implement a custom MemberNamer with a function
+type MemberNamerFunc func(name string) string
+
+// MemberName calls f(name)
+func (f MemberNamerFunc) MemberName(name string) string {
+	return f(name)
+}
+
+var (
+	// CamelCase names members like `userName`
+	CamelCase MemberNamer = MemberNamerFunc(func(name string) string {
+		words := splitWords(name)
+		for i := 1; i < len(words); i++ {
+			runes := []rune(words[i])
+			runes[0] = unicode.ToUpper(runes[0])
+			words[i] = string(runes)
+		}
+
+		return strings.Join(words, "")
+	})
+
+	// KebabCase names members like `user-name`
+	KebabCase MemberNamer = MemberNamerFunc(func(name string) string {
+		return strings.Join(splitWords(name), "-")
+	})
+
+	// SnakeCase names members like `user_name`
+	SnakeCase MemberNamer = MemberNamerFunc(func(name string) string {
+		return strings.Join(splitWords(name), "_")
+	})
+)
+
+// memberNamerHolder is needed because atomic.Value only accepts one concrete type
+type memberNamerHolder struct {
+	namer MemberNamer
+}
+
+var globalMemberNamer atomic.Value
+
+// SetMemberNamer sets the member namer that is used by this package, passing
+// nil keeps all names as they are, which is the default. It should be called
+// before anything is marshalled and before resources are added to an API,
+// because the relationship routes and the attribute schemas are built with the
+// names at that time.
+func SetMemberNamer(namer MemberNamer) {
+	globalMemberNamer.Store(memberNamerHolder{namer: namer})
+}
+
+// GetMemberNamer returns the member namer that is used by this package, it is
+// nil if the names are not changed.
+func GetMemberNamer() MemberNamer {
+	holder, _ := globalMemberNamer.Load().(memberNamerHolder)
+	return holder.namer
+}
+
+// MemberName returns the member name of an attribute or relationship name
+func MemberName(name string) string {
+	if namer := GetMemberNamer(); namer != nil {
+		return namer.MemberName(name)
+	}
+
+	return name
+}
+
+// splitWords splits camel case, kebab case and snake case names into lower
+// case words. Upper case abbreviations are kept together, including a plural s
+// like in `userIDs`.
+func splitWords(name string) []string {
+	runes := []rune(name)
+	words := []string{}
+	start := 0
+
+	isLower := func(i int) bool {
+		return i < len(runes) && (unicode.IsLower(runes[i]) || unicode.IsDigit(runes[i]))
+	}
+
+	for i := 0; i <= len(runes); i++ {
+		switch {
+		case i == len(runes) || runes[i] == '_' || runes[i] == '-' || runes[i] == ' ':
+			if i > start {
+				words = append(words, strings.ToLower(string(runes[start:i])))
+			}
+			start = i + 1
+		case i > start && unicode.IsUpper(runes[i]):
+			previousLower := isLower(i - 1)
+			// the last upper case rune of an abbreviation starts the next word
+			startsWord := !previousLower && isLower(i+1) && !(runes[i+1] == 's' && !isLower(i+2))
+			if previousLower || startsWord {
+				words = append(words, strings.ToLower(string(runes[start:i])))
+				start = i
+			}
+		}
+	}
+
+	return words
+}
+
+// renameMembers renames all members of the JSON object payload and keeps
+// their order. Payloads that are not objects are returned unchanged.
+func renameMembers(payload []byte, rename func(name string) string) ([]byte, error) {
+	decoder := json.NewDecoder(bytes.NewReader(payload))
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	if token != json.Delim('{') {
+		return payload, nil
+	}
+
+	var buffer bytes.Buffer
+	buffer.WriteByte('{')
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, err
+		}
+
+		var value json.RawMessage
+		if err := decoder.Decode(&value); err != nil {
+			return nil, err
+		}
+
+		name, err := json.Marshal(rename(token.(string)))
+		if err != nil {
+			return nil, err
+		}
+
+		if buffer.Len() > 1 {
+			buffer.WriteByte(',')
+		}
+		buffer.Write(name)
+		buffer.WriteByte(':')
+		buffer.Write(value)
+	}
+	buffer.WriteByte('}')
+
+	return buffer.Bytes(), nil
+}
+
+// memberAttributes renames the attributes of a marshalled struct to their
+// member names
+func memberAttributes(attributes []byte) ([]byte, error) {
+	if GetMemberNamer() == nil || len(attributes) == 0 {
+		return attributes, nil
+	}
+
+	return renameMembers(attributes, MemberName)
+}
+
+// localAttributes renames the attributes of a document to the `json` names of
+// target. Attributes of structs that implement json.Unmarshaler can not be
+// renamed.
+func localAttributes(attributes json.RawMessage, target interface{}) (json.RawMessage, error) {
+	if GetMemberNamer() == nil || len(attributes) == 0 {
+		return attributes, nil
+	}
+
+	known, ok := jsonFieldNames(reflect.TypeOf(target))
+	if !ok {
+		return attributes, nil
+	}
+
+	names := make(map[string]string, len(known))
+	for name := range known {
+		names[MemberName(name)] = name
+	}
+
+	return renameMembers(attributes, func(name string) string {
+		if local, ok := names[name]; ok {
+			return local
+		}
+
+		return name
+	})
+}
+
+// localRelationships renames the relationships of a document to the names that
+// target returns in GetReferences
+func localRelationships(relationships map[string]Relationship, target interface{}) map[string]Relationship {
+	if GetMemberNamer() == nil || len(relationships) == 0 {
+		return relationships
+	}
+
+	references, ok := wrapTagged(target, nil, nil).(MarshalReferences)
+	if !ok {
+		return relationships
+	}
+
+	names := map[string]string{}
+	for _, reference := range references.GetReferences() {
+		names[MemberName(reference.Name)] = reference.Name
+	}
+
+	result := make(map[string]Relationship, len(relationships))
+	for name, relationship := range relationships {
+		if local, ok := names[name]; ok {
+			name = local
+		}
+		result[name] = relationship
+	}
+
+	return result
+}
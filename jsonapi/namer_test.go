@@ -0,0 +1,111 @@
+package jsonapi
+
+import (
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	"github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+)
+
+type NamedMember struct {
+	ID             string   `jsonapi:"primary,members"`
+	UserName       string   `jsonapi:"attr,user_name"`
+	LastLoginIP    string   `jsonapi:"attr,lastLoginIP,omitempty"`
+	BestFriendID   string   `jsonapi:"relation,best_friend,members"`
+	CloseFriendIDs []string `jsonapi:"relation,closeFriends,members"`
+}
+
+var _ = Describe("MemberNamer", func() {
+	AfterEach(func() {
+		SetMemberNamer(nil)
+	})
+
+	table.DescribeTable("converts names",
+		func(name, camel, kebab, snake string) {
+			Expect(CamelCase.MemberName(name)).To(Equal(camel))
+			Expect(KebabCase.MemberName(name)).To(Equal(kebab))
+			Expect(SnakeCase.MemberName(name)).To(Equal(snake))
+		},
+		table.Entry("snake case", "user_name", "userName", "user-name", "user_name"),
+		table.Entry("kebab case", "user-name", "userName", "user-name", "user_name"),
+		table.Entry("camel case", "userName", "userName", "user-name", "user_name"),
+		table.Entry("pascal case", "UserName", "userName", "user-name", "user_name"),
+		table.Entry("abbreviations", "HTTPServer", "httpServer", "http-server", "http_server"),
+		table.Entry("plural abbreviations", "userIDs", "userIds", "user-ids", "user_ids"),
+		table.Entry("digits", "utf8Name", "utf8Name", "utf8-name", "utf8_name"),
+		table.Entry("single words", "ID", "id", "id", "id"),
+	)
+
+	It("keeps the names by default", func() {
+		Expect(GetMemberNamer()).To(BeNil())
+		Expect(MemberName("user_name")).To(Equal("user_name"))
+	})
+
+	Context("with kebab case", func() {
+		member := NamedMember{ID: "1", UserName: "Jane", LastLoginIP: "127.0.0.1", BestFriendID: "2", CloseFriendIDs: []string{"3"}}
+		document := `{
+			"data": {
+				"type": "members",
+				"id": "1",
+				"attributes": {"user-name": "Jane", "last-login-ip": "127.0.0.1"},
+				"relationships": {
+					"best-friend": {"data": {"type": "members", "id": "2"}},
+					"close-friends": {"data": [{"type": "members", "id": "3"}]}
+				}
+			}
+		}`
+
+		BeforeEach(func() {
+			SetMemberNamer(KebabCase)
+		})
+
+		It("marshals attributes, relationships and links", func() {
+			result, err := MarshalToStruct(member, CompleteServerInformation{})
+			Expect(err).ToNot(HaveOccurred())
+			data := result.Data.DataObject
+			Expect(string(data.Attributes)).To(Equal(`{"user-name":"Jane","last-login-ip":"127.0.0.1"}`))
+			Expect(data.Relationships).To(HaveLen(2))
+			Expect(data.Relationships["best-friend"].Data.DataObject).To(Equal(&RelationshipData{Type: "members", ID: "2"}))
+			Expect(data.Relationships["close-friends"].Links).To(Equal(Links{
+				"self":    Link{Href: "http://my.domain/v1/members/1/relationships/close-friends"},
+				"related": Link{Href: "http://my.domain/v1/members/1/close-friends"},
+			}))
+		})
+
+		It("unmarshals the member names", func() {
+			var target NamedMember
+			err := Unmarshal([]byte(document), &target)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(target).To(Equal(member))
+		})
+
+		It("accepts the member names when unmarshalling strictly", func() {
+			var target NamedMember
+			err := UnmarshalStrict([]byte(document), &target)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(target).To(Equal(member))
+
+			err = UnmarshalStrict([]byte(`{
+				"data": {"type": "members", "id": "1", "attributes": {"user_name": "Jane"}}
+			}`), &target)
+			Expect(err).To(Equal(PayloadErrors{
+				{Pointer: "/data/attributes/user_name", Detail: `unknown attribute "user_name"`},
+			}))
+		})
+	})
+
+	It("supports custom member namers", func() {
+		SetMemberNamer(MemberNamerFunc(strings.ToUpper))
+
+		result, err := Marshal(NamedMember{ID: "1", UserName: "Jane"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(result)).To(ContainSubstring(`"attributes":{"USER_NAME":"Jane"}`))
+		Expect(string(result)).To(ContainSubstring(`"BEST_FRIEND":{"data":null}`))
+
+		var target NamedMember
+		err = Unmarshal(result, &target)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(target.UserName).To(Equal("Jane"))
+	})
+})
@@ -0,0 +1,11 @@
+package jsonapi
+
+// Options change the settings of this package for a single call. Nil members
+// use the codec set with SetCodec and the type namer set with SetTypeNamer.
+type Options struct {
+	// Codec encodes and decodes the document
+	Codec Codec
+	// TypeNamer returns the types of structs that do not implement EntityNamer
+	// and of struct tag relations that contain IDs
+	TypeNamer TypeNamer
+}
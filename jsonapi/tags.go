@@ -0,0 +1,819 @@
+package jsonapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Instead of implementing the marshalling interfaces, a struct can describe
+// itself with `jsonapi` struct tags:
+//
+//	type User struct {
+//		ID       string    `jsonapi:"primary,users"`
+//		Name     string    `jsonapi:"attr,name"`
+//		Email    string    `jsonapi:"attr,email,omitempty"`
+//		Posts    []Post    `jsonapi:"relation,posts"`
+//		FriendID string    `jsonapi:"relation,friend,users"`
+//	}
+//
+// The `primary` tag marks the ID field and contains the type name. It can be a
+// string or any integer type. Only fields with an `attr` tag are attributes,
+// `omitempty` skips zero values.
+//
+// A `relation` field can be a struct, a pointer to a struct or a slice of
+// either of them, those are also included in the document. The related
+// structs can again use tags or implement the interfaces. It can also be a
+// string or integer ID or a slice of IDs, then the type of the related
+// resource must be given after the name unless it is the pluralized name.
+// Slices are to-many relationships, everything else is to-one.
+//
+// All interfaces that a tagged struct implements explicitly take precedence
+// over the tags.
+
+const (
+	tagPrimary   = "primary"
+	tagAttribute = "attr"
+	tagRelation  = "relation"
+)
+
+type tagAttributeField struct {
+	index     int
+	name      string
+	omitEmpty bool
+}
+
+type tagRelationField struct {
+	index int
+	name  string
+	// typ is empty without an explicit type, see relationType
+	typ    string
+	toMany bool
+	// structs is true if the field contains structs instead of IDs
+	structs bool
+	// elem is the type of one related struct or ID, it can be a pointer
+	elem reflect.Type
+}
+
+type tagDefinition struct {
+	name       string
+	id         int
+	attributes []tagAttributeField
+	relations  []tagRelationField
+}
+
+// tagDefinitionResult is cached for every struct type, including the error of
+// malformed tags
+type tagDefinitionResult struct {
+	definition *tagDefinition
+	err        error
+}
+
+var (
+	tagDefinitions sync.Map
+	checkedTags    sync.Map
+)
+
+// getTagDefinition returns the tag definition of a struct type or a pointer to
+// it, the result is nil if the struct has no primary tag or malformed tags.
+// Malformed tags are reported by CheckTags.
+func getTagDefinition(t reflect.Type) *tagDefinition {
+	definition, _ := loadTagDefinition(t)
+	return definition
+}
+
+func loadTagDefinition(t reflect.Type) (*tagDefinition, error) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if result, ok := tagDefinitions.Load(t); ok {
+		return result.(tagDefinitionResult).definition, result.(tagDefinitionResult).err
+	}
+
+	definition, err := parseTagDefinition(t)
+	actual, _ := tagDefinitions.LoadOrStore(t, tagDefinitionResult{definition: definition, err: err})
+	return actual.(tagDefinitionResult).definition, actual.(tagDefinitionResult).err
+}
+
+// CheckTags returns an error if the `jsonapi` struct tags of v, which can also
+// be a pointer or a slice, or of any struct in its relations are malformed.
+// Marshal, Unmarshal and api2go.API.AddResource call it for every type.
+func CheckTags(v interface{}) error {
+	if v == nil {
+		return nil
+	}
+
+	t := reflect.TypeOf(v)
+	for t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice {
+		t = t.Elem()
+	}
+
+	if result, ok := checkedTags.Load(t); ok {
+		return result.(tagDefinitionResult).err
+	}
+
+	err := checkRelatedTags(t, map[reflect.Type]bool{})
+	checkedTags.Store(t, tagDefinitionResult{err: err})
+	return err
+}
+
+// checkRelatedTags checks the tags of t and of all structs it relates to
+func checkRelatedTags(t reflect.Type, checked map[reflect.Type]bool) error {
+	if checked[t] {
+		return nil
+	}
+	checked[t] = true
+
+	definition, err := loadTagDefinition(t)
+	if err != nil || definition == nil {
+		return err
+	}
+
+	for _, relation := range definition.relations {
+		if !relation.structs {
+			continue
+		}
+
+		elem := relation.elem
+		if elem.Kind() == reflect.Ptr {
+			elem = elem.Elem()
+		}
+
+		if err := checkRelatedTags(elem, checked); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func parseTagDefinition(t reflect.Type) (*tagDefinition, error) {
+	if t.Kind() != reflect.Struct {
+		return nil, nil
+	}
+
+	definition := &tagDefinition{id: -1}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag, ok := field.Tag.Lookup("jsonapi")
+		if !ok || field.PkgPath != "" {
+			continue
+		}
+
+		values := strings.Split(tag, ",")
+		switch values[0] {
+		case tagPrimary:
+			if len(values) < 2 || values[1] == "" {
+				return nil, fmt.Errorf("jsonapi: primary tag of %s.%s needs a type", t, field.Name)
+			}
+			if !isIDKind(field.Type.Kind()) {
+				return nil, fmt.Errorf("jsonapi: primary field %s.%s must be a string or an integer", t, field.Name)
+			}
+			definition.id = i
+			definition.name = values[1]
+		case tagAttribute:
+			attribute := tagAttributeField{index: i, name: Jsonify(field.Name)}
+			if len(values) > 1 && values[1] != "" {
+				attribute.name = values[1]
+			}
+			attribute.omitEmpty = len(values) > 2 && values[2] == "omitempty"
+			definition.attributes = append(definition.attributes, attribute)
+		case tagRelation:
+			relation, err := parseTagRelation(t, field, i, values)
+			if err != nil {
+				return nil, err
+			}
+			definition.relations = append(definition.relations, relation)
+		default:
+			return nil, fmt.Errorf(`jsonapi: unknown tag "%s" on %s.%s`, values[0], t, field.Name)
+		}
+	}
+
+	if definition.id < 0 {
+		return nil, nil
+	}
+
+	return definition, nil
+}
+
+func parseTagRelation(t reflect.Type, field reflect.StructField, index int, values []string) (tagRelationField, error) {
+	relation := tagRelationField{index: index, name: Jsonify(field.Name)}
+	if len(values) > 1 && values[1] != "" {
+		relation.name = values[1]
+	}
+
+	relation.elem = field.Type
+	if relation.elem.Kind() == reflect.Slice {
+		relation.toMany = true
+		relation.elem = relation.elem.Elem()
+	}
+
+	elem := relation.elem
+	if elem.Kind() == reflect.Ptr {
+		elem = elem.Elem()
+	}
+
+	switch {
+	case elem.Kind() == reflect.Struct:
+		relation.structs = true
+	case isIDKind(elem.Kind()):
+	default:
+		return relation, fmt.Errorf("jsonapi: relation %s.%s must contain structs or IDs", t, field.Name)
+	}
+
+	if len(values) > 2 && values[2] != "" {
+		relation.typ = values[2]
+	}
+
+	return relation, nil
+}
+
+var relationTypes sync.Map
+
+// relationType returns the type of the related resources. Without an explicit
+// type it is the type of the related struct or the type for the name of a
+// relation with IDs. For structs it is determined lazily, because the related
+// struct could be the struct itself.
+func relationType(relation tagRelationField, names *typeNames) string {
+	if relation.typ != "" {
+		return relation.typ
+	}
+
+	if !relation.structs {
+		return names.typeName(relation.name)
+	}
+
+	elem := relation.elem
+	if elem.Kind() == reflect.Ptr {
+		elem = elem.Elem()
+	}
+
+	if names != nil {
+		return names.structType(wrapTagged(reflect.New(elem).Interface(), nil, names))
+	}
+
+	if typ, ok := relationTypes.Load(elem); ok {
+		return typ.(string)
+	}
+
+	typ := getStructType(wrapTagged(reflect.New(elem).Interface(), nil, nil))
+	relationTypes.Store(elem, typ)
+	return typ
+}
+
+func isIDKind(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.String,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	}
+
+	return false
+}
+
+// formatID returns the string representation of a string or integer value
+func formatID(value reflect.Value) string {
+	switch value.Kind() {
+	case reflect.String:
+		return value.String()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(value.Int(), 10)
+	default:
+		return strconv.FormatUint(value.Uint(), 10)
+	}
+}
+
+// parseID sets a string or integer value, an empty ID sets the zero value
+func parseID(value reflect.Value, ID string) error {
+	if ID == "" {
+		value.Set(reflect.Zero(value.Type()))
+		return nil
+	}
+
+	switch value.Kind() {
+	case reflect.String:
+		value.SetString(ID)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		number, err := strconv.ParseInt(ID, 10, value.Type().Bits())
+		if err != nil {
+			return err
+		}
+		value.SetInt(number)
+	default:
+		number, err := strconv.ParseUint(ID, 10, value.Type().Bits())
+		if err != nil {
+			return err
+		}
+		value.SetUint(number)
+	}
+
+	return nil
+}
+
+// WrapTagged returns an adapter that implements all marshalling and
+// unmarshalling interfaces for structs that use `jsonapi` struct tags. All
+// other values are returned unchanged. Marshal and Unmarshal do this
+// automatically, it is only needed to pass tagged structs to functions that
+// expect one of the interfaces. Pass a pointer if the adapter is used to
+// modify the struct.
+func WrapTagged(v interface{}) interface{} {
+	return wrapTagged(v, nil, nil)
+}
+
+// WrapTaggedWithOptions works like WrapTagged but the adapter encodes and
+// decodes attributes with the codec and determines the types of relations
+// with the type namer of options.
+func WrapTaggedWithOptions(v interface{}, options Options) interface{} {
+	return wrapTagged(v, options.Codec, newTypeNames(options.TypeNamer))
+}
+
+func wrapTagged(v interface{}, codec Codec, names *typeNames) interface{} {
+	if v == nil {
+		return v
+	}
+
+	if _, ok := v.(*taggedResource); ok {
+		return v
+	}
+
+	value := reflect.ValueOf(v)
+	if value.Kind() == reflect.Ptr && value.IsNil() {
+		return v
+	}
+
+	definition := getTagDefinition(value.Type())
+	if definition == nil {
+		return v
+	}
+
+	return &taggedResource{original: v, value: reflect.Indirect(value), definition: definition, codec: codec, names: names}
+}
+
+// unwrapTagged returns the struct that was wrapped by WrapTagged
+func unwrapTagged(v interface{}) interface{} {
+	if tagged, ok := v.(*taggedResource); ok {
+		return tagged.original
+	}
+
+	return v
+}
+
+// taggedResource implements the interfaces for a struct with `jsonapi` tags
+type taggedResource struct {
+	original   interface{}
+	value      reflect.Value
+	definition *tagDefinition
+	// codec and names are nil for the codec and TypeNamer of this package
+	codec Codec
+	names *typeNames
+}
+
+func (t *taggedResource) GetID() string {
+	if identifier, ok := t.original.(MarshalIdentifier); ok {
+		return identifier.GetID()
+	}
+
+	return formatID(t.value.Field(t.definition.id))
+}
+
+func (t *taggedResource) SetID(ID string) error {
+	if identifier, ok := t.original.(UnmarshalIdentifier); ok {
+		return identifier.SetID(ID)
+	}
+
+	if err := t.checkSettable(); err != nil {
+		return err
+	}
+
+	return parseID(t.value.Field(t.definition.id), ID)
+}
+
+func (t *taggedResource) GetName() string {
+	if namer, ok := t.original.(EntityNamer); ok {
+		return namer.GetName()
+	}
+
+	return t.definition.name
+}
+
+func (t *taggedResource) GetReferences() []Reference {
+	if references, ok := t.original.(MarshalReferences); ok {
+		return references.GetReferences()
+	}
+
+	result := make([]Reference, 0, len(t.definition.relations))
+	for _, relation := range t.definition.relations {
+		reference := Reference{Type: relationType(relation, t.names), Name: relation.name, Relationship: ToOneRelationship}
+		if relation.toMany {
+			reference.Relationship = ToManyRelationship
+		}
+		result = append(result, reference)
+	}
+
+	return result
+}
+
+func (t *taggedResource) GetReferencedIDs() []ReferenceID {
+	if references, ok := t.original.(MarshalLinkedRelations); ok {
+		return references.GetReferencedIDs()
+	}
+
+	result := []ReferenceID{}
+	for _, relation := range t.definition.relations {
+		relationship := ToOneRelationship
+		if relation.toMany {
+			relationship = ToManyRelationship
+		}
+
+		for _, element := range t.relatedElements(relation) {
+			referenceID := ReferenceID{Type: relationType(relation, t.names), Name: relation.name, Relationship: relationship}
+			if relation.structs {
+				identifier, ok := wrapTagged(element.Interface(), t.codec, t.names).(MarshalIdentifier)
+				if !ok {
+					continue
+				}
+				referenceID.ID = identifier.GetID()
+				if relation.typ == "" {
+					referenceID.Type = t.names.structType(identifier)
+				}
+			} else {
+				referenceID.ID = formatID(element)
+			}
+			result = append(result, referenceID)
+		}
+	}
+
+	return result
+}
+
+func (t *taggedResource) GetReferencedStructs() []MarshalIdentifier {
+	result := []MarshalIdentifier{}
+	if references, ok := t.original.(MarshalIncludedRelations); ok {
+		// the included structs can use tags as well
+		for _, element := range references.GetReferencedStructs() {
+			if identifier, ok := wrapTagged(element, t.codec, t.names).(MarshalIdentifier); ok {
+				result = append(result, identifier)
+			}
+		}
+
+		return result
+	}
+
+	for _, relation := range t.definition.relations {
+		if !relation.structs {
+			continue
+		}
+
+		for _, element := range t.relatedElements(relation) {
+			if identifier, ok := wrapTagged(element.Interface(), t.codec, t.names).(MarshalIdentifier); ok {
+				result = append(result, identifier)
+			}
+		}
+	}
+
+	return result
+}
+
+// relatedElements returns all set elements of a relation, nil pointers and
+// empty to-one relations are skipped
+func (t *taggedResource) relatedElements(relation tagRelationField) []reflect.Value {
+	field := t.value.Field(relation.index)
+	if !relation.toMany {
+		if isEmptyValue(field) {
+			return nil
+		}
+		return []reflect.Value{reflect.Indirect(field)}
+	}
+
+	result := make([]reflect.Value, 0, field.Len())
+	for i := 0; i < field.Len(); i++ {
+		element := field.Index(i)
+		if element.Kind() == reflect.Ptr && element.IsNil() {
+			continue
+		}
+		result = append(result, reflect.Indirect(element))
+	}
+
+	return result
+}
+
+func (t *taggedResource) SetToOneReferenceID(name, ID string) error {
+	if references, ok := t.original.(UnmarshalToOneRelations); ok {
+		return references.SetToOneReferenceID(name, ID)
+	}
+
+	relation, err := t.relation(name, false)
+	if err != nil {
+		return err
+	}
+
+	field := t.value.Field(relation.index)
+	if ID == "" {
+		field.Set(reflect.Zero(field.Type()))
+		return nil
+	}
+
+	element, err := newRelatedElement(relation, ID)
+	if err != nil {
+		return err
+	}
+
+	field.Set(element)
+	return nil
+}
+
+func (t *taggedResource) SetToManyReferenceIDs(name string, IDs []string) error {
+	if references, ok := t.original.(UnmarshalToManyRelations); ok {
+		return references.SetToManyReferenceIDs(name, IDs)
+	}
+
+	relation, err := t.relation(name, true)
+	if err != nil {
+		return err
+	}
+
+	field := t.value.Field(relation.index)
+	elements := reflect.MakeSlice(field.Type(), 0, len(IDs))
+	for _, ID := range IDs {
+		element, err := newRelatedElement(relation, ID)
+		if err != nil {
+			return err
+		}
+		elements = reflect.Append(elements, element)
+	}
+
+	field.Set(elements)
+	return nil
+}
+
+func (t *taggedResource) AddToManyIDs(name string, IDs []string) error {
+	if references, ok := t.original.(EditToManyRelations); ok {
+		return references.AddToManyIDs(name, IDs)
+	}
+
+	relation, err := t.relation(name, true)
+	if err != nil {
+		return err
+	}
+
+	field := t.value.Field(relation.index)
+	for _, ID := range IDs {
+		element, err := newRelatedElement(relation, ID)
+		if err != nil {
+			return err
+		}
+		field.Set(reflect.Append(field, element))
+	}
+
+	return nil
+}
+
+func (t *taggedResource) DeleteToManyIDs(name string, IDs []string) error {
+	if references, ok := t.original.(EditToManyRelations); ok {
+		return references.DeleteToManyIDs(name, IDs)
+	}
+
+	relation, err := t.relation(name, true)
+	if err != nil {
+		return err
+	}
+
+	obsolete := map[string]bool{}
+	for _, ID := range IDs {
+		obsolete[ID] = true
+	}
+
+	field := t.value.Field(relation.index)
+	elements := reflect.MakeSlice(field.Type(), 0, field.Len())
+	for i := 0; i < field.Len(); i++ {
+		element := field.Index(i)
+		if !obsolete[relatedID(relation, element)] {
+			elements = reflect.Append(elements, element)
+		}
+	}
+
+	field.Set(elements)
+	return nil
+}
+
+func (t *taggedResource) NewReferencedStruct(name string) (interface{}, error) {
+	if references, ok := t.original.(UnmarshalIncludedRelations); ok {
+		return references.NewReferencedStruct(name)
+	}
+
+	for _, relation := range t.definition.relations {
+		if relation.name != name {
+			continue
+		}
+
+		// relations with IDs keep the IDs
+		if !relation.structs {
+			return nil, nil
+		}
+
+		elemType := relation.elem
+		if elemType.Kind() == reflect.Ptr {
+			elemType = elemType.Elem()
+		}
+
+		return reflect.New(elemType).Interface(), nil
+	}
+
+	return nil, nil
+}
+
+func (t *taggedResource) SetReferencedStructs(name string, structs []interface{}) error {
+	if references, ok := t.original.(UnmarshalIncludedRelations); ok {
+		return references.SetReferencedStructs(name, structs)
+	}
+
+	for _, relation := range t.definition.relations {
+		if relation.name != name || !relation.structs {
+			continue
+		}
+
+		relation, err := t.relation(name, relation.toMany)
+		if err != nil {
+			return err
+		}
+
+		field := t.value.Field(relation.index)
+		elements := reflect.MakeSlice(reflect.SliceOf(relation.elem), 0, len(structs))
+		for _, element := range structs {
+			value := reflect.ValueOf(element)
+			if relation.elem.Kind() != reflect.Ptr {
+				value = value.Elem()
+			}
+			elements = reflect.Append(elements, value)
+		}
+
+		if relation.toMany {
+			field.Set(elements)
+		} else {
+			field.Set(elements.Index(0))
+		}
+
+		return nil
+	}
+
+	return fmt.Errorf("unknown relationship %s of %s", name, t.value.Type())
+}
+
+// relation returns the relation with the given name, it can only be changed
+// if the adapter wraps a pointer
+func (t *taggedResource) relation(name string, toMany bool) (tagRelationField, error) {
+	if err := t.checkSettable(); err != nil {
+		return tagRelationField{}, err
+	}
+
+	for _, relation := range t.definition.relations {
+		if relation.name == name && relation.toMany == toMany {
+			return relation, nil
+		}
+	}
+
+	if toMany {
+		return tagRelationField{}, fmt.Errorf("unknown to-many relationship %s of %s", name, t.value.Type())
+	}
+
+	return tagRelationField{}, fmt.Errorf("unknown to-one relationship %s of %s", name, t.value.Type())
+}
+
+func (t *taggedResource) checkSettable() error {
+	if !t.value.CanSet() {
+		return fmt.Errorf("%s must be passed as pointer to be modified", t.value.Type())
+	}
+
+	return nil
+}
+
+// newRelatedElement creates a struct with the given ID or the ID itself, the
+// result has the type of one element of the relation field
+func newRelatedElement(relation tagRelationField, ID string) (reflect.Value, error) {
+	elemType := relation.elem
+	if elemType.Kind() == reflect.Ptr {
+		elemType = elemType.Elem()
+	}
+
+	element := reflect.New(elemType)
+	if relation.structs {
+		identifier, ok := wrapTagged(element.Interface(), nil, nil).(UnmarshalIdentifier)
+		if !ok {
+			return element, fmt.Errorf("%s must implement UnmarshalIdentifier", elemType)
+		}
+		if err := identifier.SetID(ID); err != nil {
+			return element, err
+		}
+	} else if err := parseID(element.Elem(), ID); err != nil {
+		return element, err
+	}
+
+	if relation.elem.Kind() == reflect.Ptr {
+		return element, nil
+	}
+
+	return element.Elem(), nil
+}
+
+// relatedID returns the ID of one element of a relation field
+func relatedID(relation tagRelationField, element reflect.Value) string {
+	if element.Kind() == reflect.Ptr {
+		if element.IsNil() {
+			return ""
+		}
+		element = element.Elem()
+	}
+
+	if !relation.structs {
+		return formatID(element)
+	}
+
+	if identifier, ok := wrapTagged(element.Interface(), nil, nil).(MarshalIdentifier); ok {
+		return identifier.GetID()
+	}
+
+	return ""
+}
+
+// MarshalJSON returns the attributes of the struct
+func (t *taggedResource) MarshalJSON() ([]byte, error) {
+	if marshaler, ok := t.original.(json.Marshaler); ok {
+		return marshaler.MarshalJSON()
+	}
+
+	buffer := &bytes.Buffer{}
+	buffer.WriteByte('{')
+	first := true
+	for _, attribute := range t.definition.attributes {
+		field := t.value.Field(attribute.index)
+		if attribute.omitEmpty && isEmptyValue(field) {
+			continue
+		}
+
+		name, err := codecOrDefault(t.codec).Marshal(attribute.name)
+		if err != nil {
+			return nil, err
+		}
+
+		value, err := codecOrDefault(t.codec).Marshal(field.Interface())
+		if err != nil {
+			return nil, err
+		}
+
+		if !first {
+			buffer.WriteByte(',')
+		}
+		first = false
+
+		buffer.Write(name)
+		buffer.WriteByte(':')
+		buffer.Write(value)
+	}
+	buffer.WriteByte('}')
+
+	return buffer.Bytes(), nil
+}
+
+// UnmarshalJSON sets all attributes of the struct that are contained in data
+func (t *taggedResource) UnmarshalJSON(data []byte) error {
+	if unmarshaler, ok := t.original.(json.Unmarshaler); ok {
+		return unmarshaler.UnmarshalJSON(data)
+	}
+
+	if err := t.checkSettable(); err != nil {
+		return err
+	}
+
+	attributes := map[string]json.RawMessage{}
+	if err := codecOrDefault(t.codec).Unmarshal(data, &attributes); err != nil {
+		return err
+	}
+
+	for _, attribute := range t.definition.attributes {
+		value, ok := attributes[attribute.name]
+		if !ok {
+			continue
+		}
+
+		if err := codecOrDefault(t.codec).Unmarshal(value, t.value.Field(attribute.index).Addr().Interface()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func isEmptyValue(value reflect.Value) bool {
+	switch value.Kind() {
+	case reflect.Ptr, reflect.Interface, reflect.Slice, reflect.Map:
+		return value.IsNil() || (value.Kind() != reflect.Ptr && value.Kind() != reflect.Interface && value.Len() == 0)
+	}
+
+	return value.IsZero()
+}
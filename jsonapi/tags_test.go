@@ -0,0 +1,271 @@
+package jsonapi
+
+import (
+	"reflect"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type TaggedAuthor struct {
+	ID   int    `jsonapi:"primary,authors"`
+	Name string `jsonapi:"attr,name"`
+}
+
+type TaggedArticle struct {
+	ID       string        `jsonapi:"primary,articles"`
+	Title    string        `jsonapi:"attr,title"`
+	Draft    bool          `jsonapi:"attr,draft,omitempty"`
+	Internal string        `json:"internal"`
+	Author   *TaggedAuthor `jsonapi:"relation,author"`
+	Comments []Comment     `jsonapi:"relation,comments"`
+	TagIDs   []string      `jsonapi:"relation,tags"`
+	EditorID uint          `jsonapi:"relation,editor,authors"`
+}
+
+type TaggedFriend struct {
+	ID      string         `jsonapi:"primary,friends"`
+	Friends []TaggedFriend `jsonapi:"relation,friends"`
+}
+
+// TaggedExplicit implements some interfaces that take precedence
+type TaggedExplicit struct {
+	ID   string `jsonapi:"primary,explicits"`
+	Name string `jsonapi:"attr,name"`
+}
+
+func (t TaggedExplicit) GetID() string {
+	return "explicit-" + t.ID
+}
+
+func (t TaggedExplicit) GetName() string {
+	return "renamed"
+}
+
+// TaggedIncluding returns its included structs explicitly
+type TaggedIncluding struct {
+	ID     string          `jsonapi:"primary,includings"`
+	Friend *TaggedExplicit `jsonapi:"relation,friend"`
+}
+
+func (t TaggedIncluding) GetReferencedStructs() []MarshalIdentifier {
+	return []MarshalIdentifier{t.Friend}
+}
+
+type TaggedInvalid struct {
+	ID   string `jsonapi:"primary,invalids"`
+	Name string `jsonapi:"attribute,name"`
+}
+
+type TaggedRelatedInvalid struct {
+	ID       string          `jsonapi:"primary,related"`
+	Invalids []TaggedInvalid `jsonapi:"relation,invalids"`
+}
+
+var _ = Describe("Struct tags", func() {
+	var article TaggedArticle
+
+	BeforeEach(func() {
+		article = TaggedArticle{
+			ID:       "1",
+			Title:    "Hello",
+			Internal: "secret",
+			Author:   &TaggedAuthor{ID: 2, Name: "Jane"},
+			Comments: []Comment{{ID: 3, Text: "First!"}},
+			TagIDs:   []string{"go", "json"},
+			EditorID: 4,
+		}
+	})
+
+	It("marshals tagged structs", func() {
+		result, err := MarshalWithURLs(article, CompleteServerInformation{})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result).To(MatchJSON(`{
+			"data": {
+				"type": "articles",
+				"id": "1",
+				"attributes": {"title": "Hello"},
+				"relationships": {
+					"author": {
+						"links": {
+							"self": "http://my.domain/v1/articles/1/relationships/author",
+							"related": "http://my.domain/v1/articles/1/author"
+						},
+						"data": {"type": "authors", "id": "2"}
+					},
+					"comments": {
+						"links": {
+							"self": "http://my.domain/v1/articles/1/relationships/comments",
+							"related": "http://my.domain/v1/articles/1/comments"
+						},
+						"data": [{"type": "comments", "id": "3"}]
+					},
+					"tags": {
+						"links": {
+							"self": "http://my.domain/v1/articles/1/relationships/tags",
+							"related": "http://my.domain/v1/articles/1/tags"
+						},
+						"data": [{"type": "tags", "id": "go"}, {"type": "tags", "id": "json"}]
+					},
+					"editor": {
+						"links": {
+							"self": "http://my.domain/v1/articles/1/relationships/editor",
+							"related": "http://my.domain/v1/articles/1/editor"
+						},
+						"data": {"type": "authors", "id": "4"}
+					}
+				}
+			},
+			"included": [
+				{
+					"type": "authors",
+					"id": "2",
+					"attributes": {"name": "Jane"}
+				},
+				{
+					"type": "comments",
+					"id": "3",
+					"attributes": {"text": "First!"},
+					"relationships": {
+						"comments": {
+							"links": {
+								"self": "http://my.domain/v1/comments/3/relationships/comments",
+								"related": "http://my.domain/v1/comments/3/comments"
+							},
+							"data": []
+						}
+					}
+				}
+			]
+		}`))
+	})
+
+	It("marshals empty relationships and slices of tagged structs", func() {
+		result, err := Marshal([]*TaggedArticle{{ID: "1", Draft: true}})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result).To(MatchJSON(`{
+			"data": [{
+				"type": "articles",
+				"id": "1",
+				"attributes": {"title": "", "draft": true},
+				"relationships": {
+					"author": {"data": null},
+					"comments": {"data": []},
+					"tags": {"data": []},
+					"editor": {"data": null}
+				}
+			}]
+		}`))
+	})
+
+	It("unmarshals tagged structs with included resources", func() {
+		payload, err := Marshal(article)
+		Expect(err).ToNot(HaveOccurred())
+
+		var target TaggedArticle
+		Expect(Unmarshal(payload, &target)).To(Succeed())
+		Expect(target).To(Equal(TaggedArticle{
+			ID:       "1",
+			Title:    "Hello",
+			Author:   &TaggedAuthor{ID: 2, Name: "Jane"},
+			Comments: []Comment{{ID: 3, Text: "First!"}},
+			TagIDs:   []string{"go", "json"},
+			EditorID: 4,
+		}))
+
+		var targets []TaggedArticle
+		Expect(Unmarshal([]byte(`{"data": [{"type": "articles", "id": "1"}, {"type": "articles", "id": "2"}]}`), &targets)).To(Succeed())
+		Expect(targets).To(HaveLen(2))
+		Expect(targets[1].ID).To(Equal("2"))
+	})
+
+	It("rejects a wrong type", func() {
+		var target TaggedArticle
+		err := Unmarshal([]byte(`{"data": {"type": "authors", "id": "1"}}`), &target)
+		Expect(err).To(MatchError("Type authors in JSON does not match target struct type articles"))
+	})
+
+	It("reports unknown attributes and relationships in strict mode", func() {
+		var target TaggedArticle
+		err := UnmarshalStrict([]byte(`{"data": {
+			"type": "articles",
+			"attributes": {"title": "Hello", "internal": "secret"},
+			"relationships": {"author": {"data": null}, "reviewer": {"data": null}}
+		}}`), &target)
+		Expect(err).To(Equal(PayloadErrors{
+			{Pointer: "/data/attributes/internal", Detail: `unknown attribute "internal"`},
+			{Pointer: "/data/relationships/reviewer", Detail: `unknown relationship "reviewer"`},
+		}))
+	})
+
+	It("matches attributes exactly in strict mode like the regular unmarshalling", func() {
+		payload := []byte(`{"data": {"type": "articles", "attributes": {"Title": "Hello"}}}`)
+
+		var target TaggedArticle
+		Expect(Unmarshal(payload, &target)).To(Succeed())
+		Expect(target.Title).To(BeEmpty())
+
+		err := UnmarshalStrict(payload, &target)
+		Expect(err).To(Equal(PayloadErrors{
+			{Pointer: "/data/attributes/Title", Detail: `unknown attribute "Title"`},
+		}))
+	})
+
+	It("edits to-many relationships", func() {
+		tagged := WrapTagged(&article).(EditToManyRelations)
+		Expect(tagged.AddToManyIDs("tags", []string{"api"})).To(Succeed())
+		Expect(tagged.DeleteToManyIDs("tags", []string{"go"})).To(Succeed())
+		Expect(article.TagIDs).To(Equal([]string{"json", "api"}))
+
+		Expect(tagged.AddToManyIDs("comments", []string{"5"})).To(Succeed())
+		Expect(article.Comments).To(Equal([]Comment{{ID: 3, Text: "First!"}, {ID: 5}}))
+		Expect(tagged.AddToManyIDs("author", []string{"5"})).To(HaveOccurred())
+	})
+
+	It("needs a pointer to modify the struct", func() {
+		tagged := WrapTagged(article).(UnmarshalIdentifier)
+		Expect(tagged.SetID("2")).ToNot(Succeed())
+	})
+
+	It("handles self references", func() {
+		result, err := Marshal(TaggedFriend{ID: "1", Friends: []TaggedFriend{{ID: "2"}}})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(result)).To(ContainSubstring(`"data":[{"type":"friends","id":"2"}]`))
+	})
+
+	It("prefers explicitly implemented interfaces", func() {
+		result, err := Marshal(TaggedExplicit{ID: "1", Name: "Test"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result).To(MatchJSON(`{"data": {"type": "renamed", "id": "explicit-1", "attributes": {"name": "Test"}}}`))
+	})
+
+	It("uses the tags of explicitly included structs", func() {
+		result, err := Marshal(TaggedIncluding{ID: "1", Friend: &TaggedExplicit{ID: "2", Name: "Jane"}})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(result)).To(ContainSubstring(`"included":[{"type":"renamed","id":"explicit-2","attributes":{"name":"Jane"}}]`))
+	})
+
+	It("returns other values unchanged", func() {
+		post := SimplePost{ID: "1"}
+		Expect(WrapTagged(post)).To(Equal(post))
+		Expect(WrapTagged(nil)).To(BeNil())
+	})
+
+	It("reports invalid tags", func() {
+		message := `jsonapi: unknown tag "attribute" on jsonapi.TaggedInvalid.Name`
+		Expect(CheckTags(TaggedInvalid{})).To(MatchError(message))
+		Expect(CheckTags(&[]*TaggedRelatedInvalid{})).To(MatchError(message))
+		Expect(CheckTags(TaggedAuthor{})).To(Succeed())
+		Expect(getTagDefinition(reflect.TypeOf(TaggedInvalid{}))).To(BeNil())
+
+		_, err := Marshal(TaggedInvalid{ID: "1"})
+		Expect(err).To(MatchError(message))
+
+		_, err = Marshal([]TaggedRelatedInvalid{{ID: "1"}})
+		Expect(err).To(MatchError(message))
+
+		var target TaggedInvalid
+		err = Unmarshal([]byte(`{"data": {"type": "invalids", "id": "1"}}`), &target)
+		Expect(err).To(MatchError(message))
+	})
+})
@@ -0,0 +1,102 @@
+package jsonapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"sync"
+)
+
+// typeInfo contains the name and the implemented marshal interfaces of a type.
+// It is computed once for every reflect.Type, so marshalling large slices does
+// not need to inspect every single element.
+type typeInfo struct {
+	// name is the jsonapi type, it is empty if the type implements EntityNamer
+	// because then every element decides on its own
+	name                   string
+	customLinks            bool
+	meta                   bool
+	linkedRelations        bool
+	includedRelations      bool
+	customRelationshipMeta bool
+}
+
+var (
+	typeInfos sync.Map
+
+	entityNamerType                   = reflect.TypeOf((*EntityNamer)(nil)).Elem()
+	marshalCustomLinksType            = reflect.TypeOf((*MarshalCustomLinks)(nil)).Elem()
+	marshalMetaType                   = reflect.TypeOf((*MarshalMeta)(nil)).Elem()
+	marshalLinkedRelationsType        = reflect.TypeOf((*MarshalLinkedRelations)(nil)).Elem()
+	marshalIncludedRelationsType      = reflect.TypeOf((*MarshalIncludedRelations)(nil)).Elem()
+	marshalCustomRelationshipMetaType = reflect.TypeOf((*MarshalCustomRelationshipMeta)(nil)).Elem()
+
+	attributeBuffers = sync.Pool{
+		New: func() interface{} {
+			return &bytes.Buffer{}
+		},
+	}
+)
+
+// getTypeInfo returns the cached information for the type of data
+func getTypeInfo(data interface{}) *typeInfo {
+	reflectType := reflect.TypeOf(data)
+	if info, ok := typeInfos.Load(reflectType); ok {
+		return info.(*typeInfo)
+	}
+
+	info := &typeInfo{
+		customLinks:            reflectType.Implements(marshalCustomLinksType),
+		meta:                   reflectType.Implements(marshalMetaType),
+		linkedRelations:        reflectType.Implements(marshalLinkedRelationsType),
+		includedRelations:      reflectType.Implements(marshalIncludedRelationsType),
+		customRelationshipMeta: reflectType.Implements(marshalCustomRelationshipMetaType),
+	}
+
+	if !reflectType.Implements(entityNamerType) {
+		info.name = typeNameOf(reflectType, GetTypeNamer())
+	}
+
+	actual, _ := typeInfos.LoadOrStore(reflectType, info)
+	return actual.(*typeInfo)
+}
+
+// typeNameOf returns the jsonapi type of a struct that does not implement
+// EntityNamer, the type of a primary tag takes precedence over the namer
+func typeNameOf(reflectType reflect.Type, namer TypeNamer) string {
+	if definition := getTagDefinition(reflectType); definition != nil {
+		return definition.name
+	}
+
+	if reflectType.Kind() == reflect.Ptr {
+		return namer.TypeName(reflectType.Elem().Name())
+	}
+
+	return namer.TypeName(reflectType.Name())
+}
+
+// marshalAttributes returns the JSON encoding of element. encoding/json
+// already caches its encoders per type, so for encoding/json only the buffers
+// are pooled and just the result is allocated. Other codecs are called directly.
+func marshalAttributes(element interface{}, codec Codec) ([]byte, error) {
+	if _, ok := codec.(standardCodec); !ok {
+		return codec.Marshal(element)
+	}
+
+	buffer := attributeBuffers.Get().(*bytes.Buffer)
+	defer func() {
+		buffer.Reset()
+		attributeBuffers.Put(buffer)
+	}()
+
+	err := json.NewEncoder(buffer).Encode(element)
+	if err != nil {
+		return nil, err
+	}
+
+	// Encode terminates every value with a newline
+	result := make([]byte, buffer.Len()-1)
+	copy(result, buffer.Bytes())
+
+	return result, nil
+}
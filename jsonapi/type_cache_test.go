@@ -0,0 +1,62 @@
+package jsonapi
+
+import (
+	"encoding/json"
+	"strconv"
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type namedByValue struct {
+	ID   string `json:"-"`
+	Name string `json:"-"`
+}
+
+func (n namedByValue) GetID() string {
+	return n.ID
+}
+
+func (n namedByValue) GetName() string {
+	return n.Name
+}
+
+var _ = Describe("Type cache", func() {
+	It("caches the information per type", func() {
+		info := getTypeInfo(Post{})
+		Expect(getTypeInfo(Post{ID: 2})).To(BeIdenticalTo(info))
+		Expect(getTypeInfo(&Post{})).ToNot(BeIdenticalTo(info))
+		Expect(info.name).To(Equal("posts"))
+		Expect(info.linkedRelations).To(BeTrue())
+		Expect(info.includedRelations).To(BeTrue())
+		Expect(info.meta).To(BeFalse())
+	})
+
+	It("asks every element for its name if it is an EntityNamer", func() {
+		Expect(getStructType(namedByValue{Name: "first"})).To(Equal("first"))
+		Expect(getStructType(namedByValue{Name: "second"})).To(Equal("second"))
+	})
+
+	It("marshals attributes like json.Marshal", func() {
+		post := SimplePost{ID: "1", Title: "<b>Title</b>"}
+		expected, err := json.Marshal(post)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(marshalAttributes(post, StandardCodec)).To(Equal(expected))
+	})
+
+	// BenchmarkMarshalLargeSlice shows about 3 allocations per element, every
+	// additional allocation per element is a regression
+	It("does not allocate more than 3 times per element of a slice", func() {
+		posts := make([]SimplePost, 1000)
+		for i := range posts {
+			posts[i] = SimplePost{ID: strconv.Itoa(i), Title: "Title", Text: "Text", Size: i}
+		}
+
+		allocs := testing.AllocsPerRun(5, func() {
+			_, err := Marshal(posts)
+			Expect(err).ToNot(HaveOccurred())
+		})
+		Expect(allocs).To(BeNumerically("<", 3.5*float64(len(posts))))
+	})
+})
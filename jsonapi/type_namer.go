@@ -0,0 +1,196 @@
+package jsonapi
+
+import (
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"unicode"
+	"unicode/utf8"
+)
+
+// A TypeNamer returns the jsonapi type for the name of a go struct. It is used
+// for all structs that do not implement EntityNamer and for the relations of
+// struct tags that contain IDs, then the name of the relation is passed.
+type TypeNamer interface {
+	TypeName(name string) string
+}
+
+// TypeNamerFunc can be used to implement a custom TypeNamer with a function
+type TypeNamerFunc func(name string) string
+
+// TypeName calls f(name)
+func (f TypeNamerFunc) TypeName(name string) string {
+	return f(name)
+}
+
+var (
+	// PluralTypeNames pluralizes the jsonified struct name, `Post` becomes
+	// `posts`. It is the default.
+	PluralTypeNames TypeNamer = TypeNamerFunc(func(name string) string {
+		return Pluralize(Jsonify(name))
+	})
+
+	// SingularTypeNames only jsonifies the struct name, `Post` becomes `post`
+	SingularTypeNames TypeNamer = TypeNamerFunc(Jsonify)
+)
+
+// typeNamerHolder is needed because atomic.Value only accepts one concrete type
+type typeNamerHolder struct {
+	namer TypeNamer
+}
+
+var globalTypeNamer atomic.Value
+
+// SetTypeNamer replaces the type namer that is used by this package, passing
+// nil restores PluralTypeNames. It should be called before anything is
+// marshalled or any resource is added to an API.
+func SetTypeNamer(namer TypeNamer) {
+	if namer == nil {
+		namer = PluralTypeNames
+	}
+
+	globalTypeNamer.Store(typeNamerHolder{namer: namer})
+	resetTypeCaches()
+}
+
+// GetTypeNamer returns the type namer that is used by this package
+func GetTypeNamer() TypeNamer {
+	if holder, ok := globalTypeNamer.Load().(typeNamerHolder); ok {
+		return holder.namer
+	}
+
+	return PluralTypeNames
+}
+
+// TypeName returns the jsonapi type for the name of a go struct
+func TypeName(name string) string {
+	return GetTypeNamer().TypeName(name)
+}
+
+// typeNames caches the jsonapi types of one TypeNamer by reflect.Type for
+// structs and by relation name for IDs. It is created for every call that is
+// passed a TypeNamer in its Options, a nil *typeNames uses the global
+// TypeNamer and its caches.
+type typeNames struct {
+	namer TypeNamer
+	names sync.Map
+}
+
+// newTypeNames returns the type names of namer or nil for a nil namer
+func newTypeNames(namer TypeNamer) *typeNames {
+	if namer == nil {
+		return nil
+	}
+
+	return &typeNames{namer: namer}
+}
+
+// structType returns the jsonapi type of data like getStructType
+func (n *typeNames) structType(data interface{}) string {
+	if n == nil {
+		return getStructType(data)
+	}
+
+	info := getTypeInfo(data)
+	if info.name == "" {
+		return info.structType(data)
+	}
+
+	return n.lookup(reflect.TypeOf(data), func() string {
+		return typeNameOf(reflect.TypeOf(data), n.namer)
+	})
+}
+
+// typeName returns the jsonapi type for the name of a go struct or relation
+func (n *typeNames) typeName(name string) string {
+	if n == nil {
+		return TypeName(name)
+	}
+
+	return n.lookup(name, func() string {
+		return n.namer.TypeName(name)
+	})
+}
+
+func (n *typeNames) lookup(key interface{}, name func() string) string {
+	if result, ok := n.names.Load(key); ok {
+		return result.(string)
+	}
+
+	result := name()
+	n.names.Store(key, result)
+	return result
+}
+
+var (
+	plurals      = map[string]string{}
+	pluralsMutex sync.RWMutex
+)
+
+// AddPlural registers the plural of a noun that Pluralize gets wrong, for
+// example AddPlural("person", "persons"). The plural is also used for the last
+// word of camel case names like `adminPerson` and for upper case names.
+func AddPlural(singular, plural string) {
+	pluralsMutex.Lock()
+	plurals[strings.ToLower(singular)] = strings.ToLower(plural)
+	pluralsMutex.Unlock()
+
+	resetTypeCaches()
+}
+
+// registeredPlural returns the plural of word if its last word was registered
+// with AddPlural, the longest registered word wins
+func registeredPlural(word string) (string, bool) {
+	pluralsMutex.RLock()
+	defer pluralsMutex.RUnlock()
+
+	result, longest := "", 0
+	for singular, plural := range plurals {
+		for _, known := range []string{singular, plural} {
+			if len(known) <= longest || len(word) < len(known) {
+				continue
+			}
+
+			start := len(word) - len(known)
+			suffix := word[start:]
+			if !strings.EqualFold(suffix, known) {
+				continue
+			}
+
+			first, _ := utf8.DecodeRuneInString(suffix)
+			if start > 0 && !unicode.IsUpper(first) {
+				continue
+			}
+
+			result, longest = word[:start]+matchCase(plural, suffix), len(known)
+		}
+	}
+
+	return result, longest > 0
+}
+
+// matchCase returns word in upper case if example is upper case, with an upper
+// case first rune if the example has one or unchanged otherwise
+func matchCase(word, example string) string {
+	if len(example) > 1 && strings.ToUpper(example) == example {
+		return strings.ToUpper(word)
+	}
+
+	if first, _ := utf8.DecodeRuneInString(example); unicode.IsUpper(first) {
+		first, size := utf8.DecodeRuneInString(word)
+		return string(unicode.ToUpper(first)) + word[size:]
+	}
+
+	return word
+}
+
+// resetTypeCaches drops all cached type names after the naming changed
+func resetTypeCaches() {
+	for _, cache := range []*sync.Map{&typeInfos, &tagDefinitions, &checkedTags, &relationTypes} {
+		cache.Range(func(key, value interface{}) bool {
+			cache.Delete(key)
+			return true
+		})
+	}
+}
@@ -0,0 +1,115 @@
+package jsonapi
+
+import (
+	"bytes"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type Cactus struct {
+	ID   string `json:"-"`
+	Name string `json:"name"`
+}
+
+func (c Cactus) GetID() string {
+	return c.ID
+}
+
+func (c *Cactus) SetID(ID string) error {
+	c.ID = ID
+	return nil
+}
+
+type TaggedPot struct {
+	ID        string   `jsonapi:"primary,pots"`
+	CactusIDs []string `jsonapi:"relation,cactus"`
+}
+
+var _ = Describe("TypeNamer", func() {
+	BeforeEach(func() {
+		AddPlural("cactus", "cactuses")
+	})
+
+	AfterEach(func() {
+		SetTypeNamer(nil)
+	})
+
+	It("uses registered plurals", func() {
+		Expect(Pluralize("cactus")).To(Equal("cactuses"))
+		Expect(Pluralize("cactuses")).To(Equal("cactuses"))
+		Expect(Pluralize("Cactus")).To(Equal("Cactuses"))
+		Expect(Pluralize("CACTUS")).To(Equal("CACTUSES"))
+		Expect(Pluralize("bigCactus")).To(Equal("bigCactuses"))
+		Expect(Pluralize("bigcactus")).To(Equal("bigcacti"))
+
+		Expect(Reference{Name: "cactuses"}.IsToMany()).To(BeTrue())
+		Expect(Reference{Name: "cactus"}.IsToMany()).To(BeFalse())
+		Expect(Reference{Name: "cactus", Relationship: ToManyRelationship}.IsToMany()).To(BeTrue())
+	})
+
+	It("pluralizes type names by default", func() {
+		Expect(GetTypeNamer()).ToNot(BeNil())
+		Expect(TypeName("BigCactus")).To(Equal("bigCactuses"))
+
+		result, err := Marshal(Cactus{ID: "1", Name: "Spiky"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result).To(MatchJSON(`{"data": {"type": "cactuses", "id": "1", "attributes": {"name": "Spiky"}}}`))
+	})
+
+	It("uses the type namer for marshalling, unmarshalling and struct tags", func() {
+		_, err := Marshal(Cactus{ID: "1"})
+		Expect(err).ToNot(HaveOccurred())
+
+		SetTypeNamer(SingularTypeNames)
+
+		result, err := Marshal(Cactus{ID: "1", Name: "Spiky"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result).To(MatchJSON(`{"data": {"type": "cactus", "id": "1", "attributes": {"name": "Spiky"}}}`))
+
+		var cactus Cactus
+		Expect(Unmarshal(result, &cactus)).To(Succeed())
+		Expect(cactus).To(Equal(Cactus{ID: "1", Name: "Spiky"}))
+
+		err = Unmarshal([]byte(`{"data": {"type": "cactuses", "id": "1"}}`), &cactus)
+		Expect(err).To(MatchError("Type cactuses in JSON does not match target struct type cactus"))
+
+		result, err = Marshal(TaggedPot{ID: "1", CactusIDs: []string{"2"}})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(result)).To(ContainSubstring(`"data":[{"type":"cactus","id":"2"}]`))
+	})
+
+	It("supports custom type namers", func() {
+		SetTypeNamer(TypeNamerFunc(func(name string) string {
+			return "plant-" + Jsonify(name)
+		}))
+
+		result, err := Marshal(Cactus{ID: "1"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(result)).To(ContainSubstring(`"type":"plant-cactus"`))
+	})
+
+	It("uses the type namer of the options", func() {
+		options := Options{TypeNamer: SingularTypeNames}
+
+		document, err := MarshalToStructWithOptions([]interface{}{Cactus{ID: "1"}, TaggedPot{ID: "2", CactusIDs: []string{"1"}}}, nil, options)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(document.Data.DataArray[0].Type).To(Equal("cactus"))
+		Expect(document.Data.DataArray[1].Relationships["cactus"].Data.DataArray).To(Equal([]RelationshipData{{Type: "cactus", ID: "1"}}))
+
+		var cactus Cactus
+		Expect(UnmarshalWithOptions([]byte(`{"data": {"type": "cactus", "id": "1"}}`), &cactus, options)).To(Succeed())
+		Expect(Unmarshal([]byte(`{"data": {"type": "cactus", "id": "1"}}`), &cactus)).ToNot(Succeed())
+
+		buffer := &bytes.Buffer{}
+		encoder := NewEncoder(buffer, nil)
+		encoder.SetTypeNamer(SingularTypeNames)
+		Expect(encoder.Encode(Cactus{ID: "1"})).To(Succeed())
+		Expect(encoder.Close()).To(Succeed())
+		Expect(buffer.String()).To(ContainSubstring(`"type":"cactus"`))
+
+		result, err := Marshal(Cactus{ID: "1"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(result)).To(ContainSubstring(`"type":"cactuses"`))
+	})
+})
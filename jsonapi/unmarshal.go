@@ -1,10 +1,13 @@
 package jsonapi
 
 import (
+	"bytes"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"reflect"
+	"sort"
+	"strings"
 )
 
 // The UnmarshalIdentifier interface must be implemented to set the ID during
@@ -82,9 +85,187 @@ type EditToManyRelations interface {
 	DeleteToManyIDs(name string, IDs []string) error
 }
 
+// The UnmarshalIncludedRelations interface can be implemented to receive the
+// fully unmarshalled related structs from the `included` array of a document.
+// Included resources are unmarshalled recursively, so their relationships are
+// populated as well. Every included resource is unmarshalled only once for each
+// type that NewReferencedStruct returns, so cycles result in pointers to the
+// same struct.
+type UnmarshalIncludedRelations interface {
+	// NewReferencedStruct returns a pointer to an empty struct for the
+	// relationship name, the included resource is unmarshalled into it.
+	// Returning nil skips the included resources of the relationship.
+	NewReferencedStruct(name string) (interface{}, error)
+	// SetReferencedStructs is called with the unmarshalled structs of a
+	// relationship that were created by NewReferencedStruct. It is only called
+	// if the relationship has included resources.
+	SetReferencedStructs(name string, structs []interface{}) error
+}
+
+// A PayloadError describes one invalid member of a document that was found by
+// UnmarshalStrict. Pointer is a JSON Pointer to the invalid member, e.g.
+// `/data/attributes/title`.
+type PayloadError struct {
+	Pointer string
+	Detail  string
+}
+
+// Error returns the detail together with the pointer
+func (e PayloadError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Pointer, e.Detail)
+}
+
+// PayloadErrors is returned by UnmarshalStrict and contains all invalid
+// members of a document.
+type PayloadErrors []PayloadError
+
+// Error returns all errors joined together
+func (e PayloadErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Error()
+	}
+
+	return strings.Join(messages, ", ")
+}
+
+// A DocumentError is returned by UnmarshalDocument if the document contains
+// errors instead of data.
+type DocumentError struct {
+	Errors []ErrorObject
+}
+
+// Error returns the detail or title of the first error
+func (e DocumentError) Error() string {
+	message := e.Errors[0].Title
+	if e.Errors[0].Detail != "" {
+		message = e.Errors[0].Detail
+	}
+
+	if len(e.Errors) > 1 {
+		return fmt.Sprintf("document contains errors: %s and %d more errors", message, len(e.Errors)-1)
+	}
+
+	return fmt.Sprintf("document contains errors: %s", message)
+}
+
+// A DocumentResult contains the top-level members of a document besides data
+// and included.
+type DocumentResult struct {
+	Links   Links
+	Meta    Meta
+	JSONAPI *JSONAPIObject
+	Errors  []ErrorObject
+}
+
+var (
+	topLevelMembers = map[string]bool{
+		"data":     true,
+		"errors":   true,
+		"meta":     true,
+		"jsonapi":  true,
+		"links":    true,
+		"included": true,
+	}
+	resourceObjectMembers = map[string]bool{
+		"type":          true,
+		"id":            true,
+		"attributes":    true,
+		"relationships": true,
+		"links":         true,
+		"meta":          true,
+	}
+)
+
 // Unmarshal parses a JSON API compatible JSON and populates the target which
 // must implement the `UnmarshalIdentifier` interface.
 func Unmarshal(data []byte, target interface{}) error {
+	return UnmarshalWithCodec(data, target, nil)
+}
+
+// UnmarshalWithCodec works like Unmarshal but decodes with the given codec
+// instead of the one set with SetCodec.
+func UnmarshalWithCodec(data []byte, target interface{}, codec Codec) error {
+	return UnmarshalWithOptions(data, target, Options{Codec: codec})
+}
+
+// UnmarshalWithOptions works like Unmarshal but uses the codec and the type
+// namer of options instead of the ones of this package.
+func UnmarshalWithOptions(data []byte, target interface{}, options Options) error {
+	if target == nil {
+		return errors.New("target must not be nil")
+	}
+
+	if reflect.TypeOf(target).Kind() != reflect.Ptr {
+		return errors.New("target must be a ptr")
+	}
+
+	return unmarshalDocument(data, target, options.Codec, newTypeNames(options.TypeNamer))
+}
+
+// UnmarshalDocument works like Unmarshal but also returns the top-level links,
+// meta, jsonapi and errors members of the document. If the document contains
+// errors, the result is returned together with a DocumentError and target is
+// not modified.
+func UnmarshalDocument(data []byte, target interface{}) (*DocumentResult, error) {
+	return UnmarshalDocumentWithCodec(data, target, nil)
+}
+
+// UnmarshalDocumentWithCodec works like UnmarshalDocument but decodes with the
+// given codec instead of the one set with SetCodec.
+func UnmarshalDocumentWithCodec(data []byte, target interface{}, codec Codec) (*DocumentResult, error) {
+	return UnmarshalDocumentWithOptions(data, target, Options{Codec: codec})
+}
+
+// UnmarshalDocumentWithOptions works like UnmarshalDocument but uses the codec
+// and the type namer of options instead of the ones of this package.
+func UnmarshalDocumentWithOptions(data []byte, target interface{}, options Options) (*DocumentResult, error) {
+	if target == nil {
+		return nil, errors.New("target must not be nil")
+	}
+
+	if reflect.TypeOf(target).Kind() != reflect.Ptr {
+		return nil, errors.New("target must be a ptr")
+	}
+
+	ctx, err := decodeDocument(data, options.Codec)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &DocumentResult{
+		Links:   ctx.Links,
+		Meta:    ctx.Meta,
+		JSONAPI: ctx.JSONAPI,
+		Errors:  ctx.Errors,
+	}
+
+	if len(ctx.Errors) > 0 {
+		return result, DocumentError{Errors: ctx.Errors}
+	}
+
+	return result, unmarshalData(ctx, target, codecOrDefault(options.Codec), newTypeNames(options.TypeNamer))
+}
+
+// UnmarshalStrict works like Unmarshal but rejects documents that contain
+// unknown top-level members, unknown members inside of resource objects,
+// attributes that do not exist in the target struct or relationships that are
+// not returned by `GetReferences`. All violations are returned as PayloadErrors.
+//
+// Attributes can not be checked for structs that implement json.Unmarshaler.
+func UnmarshalStrict(data []byte, target interface{}) error {
+	return UnmarshalStrictWithCodec(data, target, nil)
+}
+
+// UnmarshalStrictWithCodec works like UnmarshalStrict but decodes with the
+// given codec instead of the one set with SetCodec.
+func UnmarshalStrictWithCodec(data []byte, target interface{}, codec Codec) error {
+	return UnmarshalStrictWithOptions(data, target, Options{Codec: codec})
+}
+
+// UnmarshalStrictWithOptions works like UnmarshalStrict but uses the codec and
+// the type namer of options instead of the ones of this package.
+func UnmarshalStrictWithOptions(data []byte, target interface{}, options Options) error {
 	if target == nil {
 		return errors.New("target must not be nil")
 	}
@@ -93,19 +274,44 @@ func Unmarshal(data []byte, target interface{}) error {
 		return errors.New("target must be a ptr")
 	}
 
-	ctx := &Document{}
+	err := checkStrictDocument(data, reflect.TypeOf(target).Elem(), codecOrDefault(options.Codec))
+	if err != nil {
+		return err
+	}
+
+	return unmarshalDocument(data, target, options.Codec, newTypeNames(options.TypeNamer))
+}
 
-	err := json.Unmarshal(data, ctx)
+// unmarshalDocument decodes data with codec, or with the codec set with
+// SetCodec if it is nil, and populates target
+func unmarshalDocument(data []byte, target interface{}, codec Codec, names *typeNames) error {
+	ctx, err := decodeDocument(data, codec)
 	if err != nil {
 		return err
 	}
 
+	return unmarshalData(ctx, target, codecOrDefault(codec), names)
+}
+
+// unmarshalData populates target with the data and included members of ctx
+func unmarshalData(ctx *Document, target interface{}, codec Codec, names *typeNames) error {
 	if ctx.Data == nil {
 		return errors.New(`Source JSON is empty and has no "attributes" payload object`)
 	}
 
+	if err := CheckTags(target); err != nil {
+		return err
+	}
+
+	resolver := newIncludedResolver(ctx.Included, codec, names)
+
 	if ctx.Data.DataObject != nil {
-		return setDataIntoTarget(ctx.Data.DataObject, target)
+		err := setDataIntoTarget(ctx.Data.DataObject, target, codec, names)
+		if err != nil {
+			return err
+		}
+
+		return resolver.resolve(ctx.Data.DataObject, target)
 	}
 
 	if ctx.Data.DataArray != nil {
@@ -122,25 +328,45 @@ func Unmarshal(data []byte, target interface{}) error {
 			// otherwise create a new target and append
 			var targetRecord, emptyValue reflect.Value
 			for i := 0; i < targetValue.Len(); i++ {
-				marshalCasted, ok := targetValue.Index(i).Interface().(MarshalIdentifier)
+				marshalCasted, ok := wrapTagged(targetValue.Index(i).Interface(), codec, names).(MarshalIdentifier)
 				if !ok {
 					return errors.New("existing structs must implement interface MarshalIdentifier")
 				}
 				if record.ID == marshalCasted.GetID() {
-					targetRecord = targetValue.Index(i).Addr()
+					targetRecord = targetValue.Index(i)
+					if targetType.Kind() != reflect.Ptr {
+						targetRecord = targetRecord.Addr()
+					}
 					break
 				}
 			}
 
 			if targetRecord == emptyValue || targetRecord.IsNil() {
-				targetRecord = reflect.New(targetType)
-				err := setDataIntoTarget(&record, targetRecord.Interface())
+				// slices of pointers get a new pointer, otherwise the new struct is copied
+				if targetType.Kind() == reflect.Ptr {
+					targetRecord = reflect.New(targetType.Elem())
+				} else {
+					targetRecord = reflect.New(targetType)
+				}
+				err := setDataIntoTarget(&record, targetRecord.Interface(), codec, names)
 				if err != nil {
 					return err
 				}
-				targetValue = reflect.Append(targetValue, targetRecord.Elem())
+				err = resolver.resolve(&record, targetRecord.Interface())
+				if err != nil {
+					return err
+				}
+				if targetType.Kind() == reflect.Ptr {
+					targetValue = reflect.Append(targetValue, targetRecord)
+				} else {
+					targetValue = reflect.Append(targetValue, targetRecord.Elem())
+				}
 			} else {
-				err := setDataIntoTarget(&record, targetRecord.Interface())
+				err := setDataIntoTarget(&record, targetRecord.Interface(), codec, names)
+				if err != nil {
+					return err
+				}
+				err = resolver.resolve(&record, targetRecord.Interface())
 				if err != nil {
 					return err
 				}
@@ -153,8 +379,8 @@ func Unmarshal(data []byte, target interface{}) error {
 	return nil
 }
 
-func setDataIntoTarget(data *Data, target interface{}) error {
-	castedTarget, ok := target.(UnmarshalIdentifier)
+func setDataIntoTarget(data *Data, target interface{}, codec Codec, names *typeNames) error {
+	castedTarget, ok := wrapTagged(target, codec, names).(UnmarshalIdentifier)
 	if !ok {
 		return errors.New("target must implement UnmarshalIdentifier interface")
 	}
@@ -163,13 +389,18 @@ func setDataIntoTarget(data *Data, target interface{}) error {
 		return errors.New("invalid record, no type was specified")
 	}
 
-	err := checkType(data.Type, castedTarget)
+	err := checkType(data.Type, castedTarget, names)
 	if err != nil {
 		return err
 	}
 
 	if data.Attributes != nil {
-		err = json.Unmarshal(data.Attributes, castedTarget)
+		attributes, err := localAttributes(data.Attributes, target)
+		if err != nil {
+			return err
+		}
+
+		err = codec.Unmarshal(attributes, castedTarget)
 		if err != nil {
 			return err
 		}
@@ -188,7 +419,7 @@ func setDataIntoTarget(data *Data, target interface{}) error {
 		}
 	}
 
-	return setRelationshipIDs(data.Relationships, castedTarget)
+	return setRelationshipIDs(localRelationships(data.Relationships, target), castedTarget)
 }
 
 // extracts all found relationships and set's them via SetToOneReferenceID or
@@ -241,11 +472,153 @@ func setRelationshipIDs(relationships map[string]Relationship, target UnmarshalI
 	return nil
 }
 
-func checkType(incomingType string, target UnmarshalIdentifier) error {
-	actualType := getStructType(target)
+func checkType(incomingType string, target UnmarshalIdentifier, names *typeNames) error {
+	actualType := names.structType(target)
 	if incomingType != actualType {
 		return fmt.Errorf("Type %s in JSON does not match target struct type %s", incomingType, actualType)
 	}
 
 	return nil
 }
+
+// checkStrictDocument looks for all members of the document that are not
+// allowed or unknown for the given target type
+func checkStrictDocument(data []byte, targetType reflect.Type, codec Codec) error {
+	document := map[string]json.RawMessage{}
+	err := codec.Unmarshal(data, &document)
+	if err != nil {
+		return err
+	}
+
+	var result PayloadErrors
+
+	for name := range document {
+		if !topLevelMembers[name] {
+			result = append(result, PayloadError{
+				Pointer: "/" + escapePointer(name),
+				Detail:  fmt.Sprintf(`unknown top-level member "%s"`, name),
+			})
+		}
+	}
+
+	payload := bytes.TrimSpace(document["data"])
+	if bytes.HasPrefix(payload, objectSuffix) {
+		result = append(result, checkStrictResourceObject(payload, "/data", targetType, codec)...)
+	}
+
+	if bytes.HasPrefix(payload, arraySuffix) {
+		records := []json.RawMessage{}
+		err = codec.Unmarshal(payload, &records)
+		if err != nil {
+			return err
+		}
+
+		if targetType.Kind() == reflect.Slice {
+			targetType = targetType.Elem()
+		}
+
+		for index, record := range records {
+			result = append(result, checkStrictResourceObject(record, fmt.Sprintf("/data/%d", index), targetType, codec)...)
+		}
+	}
+
+	if len(result) == 0 {
+		return nil
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Pointer < result[j].Pointer
+	})
+
+	return result
+}
+
+func checkStrictResourceObject(payload []byte, pointer string, targetType reflect.Type, codec Codec) PayloadErrors {
+	var result PayloadErrors
+
+	members := map[string]json.RawMessage{}
+	if err := codec.Unmarshal(payload, &members); err != nil {
+		// invalid resource objects are reported by the regular unmarshalling
+		return nil
+	}
+
+	for name := range members {
+		if !resourceObjectMembers[name] {
+			result = append(result, PayloadError{
+				Pointer: pointer + "/" + escapePointer(name),
+				Detail:  fmt.Sprintf(`unknown member "%s" in resource object`, name),
+			})
+		}
+	}
+
+	attributes := map[string]json.RawMessage{}
+	if err := codec.Unmarshal(members["attributes"], &attributes); err == nil {
+		if fieldNames, ok := jsonFieldNames(targetType); ok {
+			known := make(map[string]bool, len(fieldNames))
+			for name := range fieldNames {
+				known[MemberName(name)] = true
+			}
+
+			// encoding/json matches the remaining names case-insensitively,
+			// the attributes of jsonapi struct tags are matched exactly
+			folded := getTagDefinition(targetType) == nil
+
+			for name := range attributes {
+				if !known[name] && !(folded && containsFold(fieldNames, name)) {
+					result = append(result, PayloadError{
+						Pointer: pointer + "/attributes/" + escapePointer(name),
+						Detail:  fmt.Sprintf(`unknown attribute "%s"`, name),
+					})
+				}
+			}
+		}
+	}
+
+	relationships := map[string]json.RawMessage{}
+	if err := codec.Unmarshal(members["relationships"], &relationships); err == nil && len(relationships) > 0 {
+		known := map[string]bool{}
+		if references, ok := wrapTagged(newInstance(targetType), codec, nil).(MarshalReferences); ok {
+			for _, reference := range references.GetReferences() {
+				known[MemberName(reference.Name)] = true
+			}
+		}
+
+		for name := range relationships {
+			if !known[name] {
+				result = append(result, PayloadError{
+					Pointer: pointer + "/relationships/" + escapePointer(name),
+					Detail:  fmt.Sprintf(`unknown relationship "%s"`, name),
+				})
+			}
+		}
+	}
+
+	return result
+}
+
+// containsFold checks if name matches one of names under case folding, like
+// encoding/json matches the keys of an object to the fields of a struct
+func containsFold(names map[string]bool, name string) bool {
+	for candidate := range names {
+		if strings.EqualFold(candidate, name) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// newInstance returns a pointer to a new zero value of the struct type t or
+// the struct type that t points to
+func newInstance(t reflect.Type) interface{} {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	return reflect.New(t).Interface()
+}
+
+// escapePointer escapes a member name to be used in a JSON Pointer
+func escapePointer(name string) string {
+	return strings.Replace(strings.Replace(name, "~", "~0", -1), "/", "~1", -1)
+}
@@ -68,6 +68,13 @@ var _ = Describe("Unmarshal", func() {
 			Expect(posts).To(Equal([]SimplePost{firstPost, secondPost}))
 		})
 
+		It("unmarshals multiple objects into a slice of pointers", func() {
+			var posts []*SimplePost
+			err := Unmarshal(multiplePostJSON, &posts)
+			Expect(err).To(BeNil())
+			Expect(posts).To(Equal([]*SimplePost{&firstPost, &secondPost}))
+		})
+
 		It("unmarshals array attributes into array structs", func() {
 			expected := Image{
 				ID: "one",
@@ -571,6 +578,24 @@ var _ = Describe("Unmarshal", func() {
 			Expect(err).To(BeNil())
 			Expect(posts).To(Equal([]Post{{ID: 1, Title: "New Title"}}))
 		})
+
+		It("overrides existing pointer entries", func() {
+			post := &Post{ID: 1, Title: "Old Title"}
+			postJSON := []byte(`{
+				"data": [{
+					"id":   "1",
+					"type": "posts",
+					"attributes": {
+						"title": "New Title"
+					}
+				}]
+			}`)
+			posts := []*Post{post}
+			err := Unmarshal(postJSON, &posts)
+			Expect(err).To(BeNil())
+			Expect(posts).To(HaveLen(1))
+			Expect(post.Title).To(Equal("New Title"))
+		})
 	})
 
 	Context("when unmarshaling with null values", func() {
@@ -805,4 +830,145 @@ var _ = Describe("Unmarshal", func() {
 			Expect(expectedPost.ResourceMetadata["post-count"]).To(Equal(simplePostWithMetadata.ResourceMetadata["post-count"]))
 		})
 	})
+
+	Context("when unmarshalling strictly", func() {
+		It("unmarshals valid documents", func() {
+			var post SimplePost
+			err := UnmarshalStrict([]byte(`{
+				"data": {
+					"id": "1",
+					"type": "simplePosts",
+					"attributes": {
+						"title": "First Post",
+						"text": "Lipsum"
+					}
+				},
+				"meta": {"author": "Dieter"}
+			}`), &post)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(post).To(Equal(SimplePost{ID: "1", Title: "First Post", Text: "Lipsum"}))
+		})
+
+		It("rejects unknown members with pointers", func() {
+			var post Post
+			err := UnmarshalStrict([]byte(`{
+				"data": {
+					"id": "1",
+					"type": "posts",
+					"foo": "bar",
+					"attributes": {
+						"title": "First Post",
+						"ID": 3,
+						"a/b": 1
+					},
+					"relationships": {
+						"comments": {"data": []},
+						"likes": {"data": []}
+					}
+				},
+				"something": true
+			}`), &post)
+			Expect(err).To(HaveOccurred())
+			payloadErrors, ok := err.(PayloadErrors)
+			Expect(ok).To(BeTrue())
+			Expect(payloadErrors).To(Equal(PayloadErrors{
+				{Pointer: "/data/attributes/ID", Detail: `unknown attribute "ID"`},
+				{Pointer: "/data/attributes/a~1b", Detail: `unknown attribute "a/b"`},
+				{Pointer: "/data/foo", Detail: `unknown member "foo" in resource object`},
+				{Pointer: "/data/relationships/likes", Detail: `unknown relationship "likes"`},
+				{Pointer: "/something", Detail: `unknown top-level member "something"`},
+			}))
+			Expect(post.Title).To(BeEmpty())
+		})
+
+		It("matches attributes case-insensitively like the regular unmarshalling", func() {
+			payload := []byte(`{
+				"data": {"id": "1", "type": "simplePosts", "attributes": {"Title": "one", "TEXT": "two"}}
+			}`)
+
+			var post SimplePost
+			Expect(Unmarshal(payload, &post)).To(Succeed())
+
+			var strictPost SimplePost
+			Expect(UnmarshalStrict(payload, &strictPost)).To(Succeed())
+			Expect(strictPost).To(Equal(post))
+			Expect(strictPost.Title).To(Equal("one"))
+			Expect(strictPost.Text).To(Equal("two"))
+		})
+
+		It("checks every element of an array", func() {
+			var posts []SimplePost
+			err := UnmarshalStrict([]byte(`{
+				"data": [
+					{"id": "1", "type": "simplePosts", "attributes": {"title": "one"}},
+					{"id": "2", "type": "simplePosts", "attributes": {"topSecret": "two"}}
+				]
+			}`), &posts)
+			Expect(err).To(Equal(PayloadErrors{
+				{Pointer: "/data/1/attributes/topSecret", Detail: `unknown attribute "topSecret"`},
+			}))
+		})
+
+		It("knows the attributes of embedded structs", func() {
+			var post SimplePostWithMetadata
+			err := UnmarshalStrict([]byte(`{
+				"data": {"id": "1", "type": "simplePostWithMetadatas", "attributes": {"title": "one"}}
+			}`), &post)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(post.Title).To(Equal("one"))
+		})
+
+		It("rejects trailing data", func() {
+			var post SimplePost
+			err := UnmarshalStrict([]byte(`{"data": {"id": "1", "type": "simplePosts"}} garbage`), &post)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Context("when unmarshalling documents", func() {
+		It("returns the top-level members", func() {
+			var posts []SimplePost
+			result, err := UnmarshalDocument([]byte(`{
+				"data": [{"id": "1", "type": "simplePosts", "attributes": {"title": "one"}}],
+				"links": {"self": "/posts?page[number]=1", "next": "/posts?page[number]=2", "prev": null},
+				"meta": {"total": 2},
+				"jsonapi": {"version": "1.0"}
+			}`), &posts)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(posts).To(HaveLen(1))
+			Expect(posts[0].Title).To(Equal("one"))
+			Expect(result.Links["next"].Href).To(Equal("/posts?page[number]=2"))
+			Expect(result.Links["prev"].Empty()).To(BeTrue())
+			Expect(result.Meta).To(Equal(Meta{"total": float64(2)}))
+			Expect(result.JSONAPI).To(Equal(&JSONAPIObject{Version: "1.0"}))
+			Expect(result.Errors).To(BeEmpty())
+		})
+
+		It("returns a DocumentError for error documents", func() {
+			post := SimplePost{Title: "unchanged"}
+			result, err := UnmarshalDocument([]byte(`{
+				"errors": [
+					{"status": "422", "title": "invalid", "detail": "title must not be empty", "source": {"pointer": "/data/attributes/title"}},
+					{"status": "422", "title": "invalid text"}
+				],
+				"meta": {"request": "abc"}
+			}`), &post)
+			Expect(err).To(MatchError("document contains errors: title must not be empty and 1 more errors"))
+			documentError, ok := err.(DocumentError)
+			Expect(ok).To(BeTrue())
+			Expect(documentError.Errors).To(Equal([]ErrorObject{
+				{Status: "422", Title: "invalid", Detail: "title must not be empty", Source: &ErrorSource{Pointer: "/data/attributes/title"}},
+				{Status: "422", Title: "invalid text"},
+			}))
+			Expect(result.Errors).To(Equal(documentError.Errors))
+			Expect(result.Meta).To(Equal(Meta{"request": "abc"}))
+			Expect(post.Title).To(Equal("unchanged"))
+		})
+
+		It("still needs data", func() {
+			var post SimplePost
+			_, err := UnmarshalDocument([]byte(`{"meta": {"total": 0}}`), &post)
+			Expect(err).To(HaveOccurred())
+		})
+	})
 })
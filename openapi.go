@@ -0,0 +1,519 @@
+package api2go
+
+import (
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/manyminds/api2go/jsonapi"
+)
+
+// openAPIVersion is the version of the generated OpenAPI documents
+const openAPIVersion = "3.1.0"
+
+// openAPIObject is used for all objects of the OpenAPI document, so it can be
+// changed freely before it is served
+type openAPIObject = map[string]interface{}
+
+// OpenAPI returns an OpenAPI 3.1 document that describes the routes of all
+// resources of the API. The attribute schemas are derived from the struct
+// fields and tags, the request and response schemas follow the JSON API
+// document structure.
+//
+// A new document is generated for every call, it can be changed before it is
+// served, e.g. to fill in the info object.
+func (api *API) OpenAPI() map[string]interface{} {
+	generator := &openAPIGenerator{
+		schemaGenerator: newSchemaGenerator(),
+		api:             api,
+		paths:           openAPIObject{},
+		schemas:         commonOpenAPISchemas(),
+	}
+
+	for _, res := range api.resources {
+		generator.addResource(res)
+	}
+
+	document := openAPIObject{
+		"openapi": openAPIVersion,
+		"info": openAPIObject{
+			"title":   "api2go",
+			"version": "1.0.0",
+		},
+		"paths": generator.paths,
+		"components": openAPIObject{
+			"schemas":    generator.schemas,
+			"parameters": commonOpenAPIParameters(),
+			"responses": openAPIObject{
+				"Error": openAPIObject{
+					"description": "The errors that occurred",
+					"content":     api.openAPIContent(openAPIRef("ErrorDocument")),
+				},
+			},
+		},
+	}
+
+	if _, ok := api.info.resolver.(RequestAwareURLResolver); !ok && api.info.resolver != nil {
+		if baseURL := api.info.GetBaseURL(); baseURL != "" {
+			document["servers"] = []interface{}{openAPIObject{"url": baseURL}}
+		}
+	}
+
+	return document
+}
+
+// AddOpenAPIRoute adds a GET route below the prefix of the API that serves the
+// document of OpenAPI, e.g. AddOpenAPIRoute("openapi.json"). The document is
+// generated for every request.
+func (api *API) AddOpenAPIRoute(path string) {
+	route := "/" + strings.Trim(path, "/")
+	if prefix := strings.Trim(api.info.prefix, "/"); prefix != "" {
+		route = "/" + prefix + route
+	}
+
+	api.handle(Route{Method: http.MethodGet, Path: route, Operation: OperationOpenAPI}, func(w http.ResponseWriter, r *http.Request, _ map[string]string, context map[string]interface{}) {
+		api.handleRequest(w, r, context, func(c APIContexter) error {
+			result, err := api.jsonCodec().Marshal(api.OpenAPI())
+			if err != nil {
+				return err
+			}
+
+			writeResult(w, result, http.StatusOK, "application/json")
+			return nil
+		})
+	})
+}
+
+// openAPIContent returns the content of a request body or response
+func (api *API) openAPIContent(schema openAPIObject) openAPIObject {
+	return openAPIObject{api.ContentType: openAPIObject{"schema": schema}}
+}
+
+type openAPIGenerator struct {
+	schemaGenerator
+	api     *API
+	paths   openAPIObject
+	schemas openAPIObject
+}
+
+func (g *openAPIGenerator) addResource(res resource) {
+	name := res.name
+	baseURL := "/" + name
+	if prefix := strings.Trim(g.api.info.prefix, "/"); prefix != "" {
+		baseURL = "/" + prefix + baseURL
+	}
+
+	prototype := jsonapi.WrapTaggedWithOptions(newPrototype(res.resourceType), res.api.jsonOptions())
+	var references []jsonapi.Reference
+	if referencer, ok := prototype.(jsonapi.MarshalReferences); ok {
+		references = referencer.GetReferences()
+	}
+
+	g.addResourceSchemas(name, res.resourceType, references)
+
+	collection := openAPIObject{}
+	if openAPIListable(res.source) {
+		collection["get"] = g.operation(name+".list", name, "List all "+name, g.listParameters(res.source), nil, openAPIObject{
+			"200": g.response("The "+name, openAPIRef(name+".CollectionDocument")),
+		})
+	}
+
+	if _, ok := res.source.(ResourceCreator); ok {
+		collection["post"] = g.operation(name+".create", name, "Create a resource of "+name, nil, openAPIRef(name+".CreateRequest"), openAPIObject{
+			"201": g.response("The created resource", openAPIRef(name+".Document")),
+			"202": openAPIObject{"description": "The request was accepted"},
+			"204": openAPIObject{"description": "The resource was created with the given ID"},
+		})
+	}
+
+	if len(collection) > 0 {
+		g.paths[baseURL] = collection
+	}
+
+	single := openAPIObject{}
+	if _, ok := res.source.(ResourceGetter); ok {
+		single["get"] = g.operation(name+".get", name, "Get a resource of "+name, []interface{}{openAPIParameterRef("fields")}, nil, openAPIObject{
+			"200": g.response("The resource", openAPIRef(name+".Document")),
+		})
+	}
+
+	if _, ok := res.source.(ResourceUpdater); ok {
+		single["patch"] = g.operation(name+".update", name, "Update a resource of "+name, nil, openAPIRef(name+".UpdateRequest"), openAPIObject{
+			"200": g.response("The updated resource", openAPIRef(name+".Document")),
+			"202": openAPIObject{"description": "The request was accepted"},
+			"204": openAPIObject{"description": "The resource was updated"},
+		})
+	}
+
+	if _, ok := res.source.(ResourceDeleter); ok {
+		single["delete"] = g.operation(name+".delete", name, "Delete a resource of "+name, nil, nil, openAPIObject{
+			"202": openAPIObject{"description": "The request was accepted"},
+			"204": openAPIObject{"description": "The resource was deleted"},
+		})
+	}
+
+	if len(single) > 0 {
+		single["parameters"] = []interface{}{openAPIParameterRef("id")}
+		g.paths[baseURL+"/{id}"] = single
+	}
+
+	_, editToMany := prototype.(jsonapi.EditToManyRelations)
+	for _, reference := range references {
+		g.addRelationship(res, baseURL, reference, editToMany)
+	}
+}
+
+// addRelationship adds the routes of one relationship of res
+func (g *openAPIGenerator) addRelationship(res resource, baseURL string, reference jsonapi.Reference, editToMany bool) {
+	name := res.name
+	member := jsonapi.MemberName(reference.Name)
+	operationID := name + "." + member
+	toMany := reference.IsToMany()
+
+	relationshipSchema := openAPIRef("ToOneRelationship")
+	if toMany {
+		relationshipSchema = openAPIRef("ToManyRelationship")
+	}
+
+	relationship := openAPIObject{}
+	if _, ok := res.source.(ResourceGetter); ok {
+		relationship["get"] = g.operation(operationID+".relationship.get", name, "Get the "+member+" relationship", nil, nil, openAPIObject{
+			"200": g.response("The relationship", relationshipSchema),
+		})
+	}
+
+	_, updater := res.source.(ResourceUpdater)
+	if updater {
+		relationship["patch"] = g.operation(operationID+".relationship.replace", name, "Replace the "+member+" relationship", nil, relationshipSchema, openAPIObject{
+			"204": openAPIObject{"description": "The relationship was replaced"},
+		})
+	}
+
+	// only to-many relationships can be added to or removed from, like in the
+	// routes of the API
+	if updater && editToMany && toMany {
+		relationship["post"] = g.operation(operationID+".relationship.add", name, "Add to the "+member+" relationship", nil, openAPIRef("ToManyRelationship"), openAPIObject{
+			"204": openAPIObject{"description": "The resources were added"},
+		})
+		relationship["delete"] = g.operation(operationID+".relationship.remove", name, "Remove from the "+member+" relationship", nil, openAPIRef("ToManyRelationship"), openAPIObject{
+			"204": openAPIObject{"description": "The resources were removed"},
+		})
+	}
+
+	if len(relationship) > 0 {
+		relationship["parameters"] = []interface{}{openAPIParameterRef("id")}
+		g.paths[baseURL+"/{id}/relationships/"+member] = relationship
+	}
+
+	// related resources can only be fetched or created if their type is registered
+	related := g.api.resource(reference.Type)
+	if related == nil {
+		return
+	}
+
+	collection := openAPIObject{}
+	if openAPIListable(related.source) {
+		document := openAPIRef(related.name + ".Document")
+		if toMany {
+			document = openAPIRef(related.name + ".CollectionDocument")
+		}
+
+		parameters := append([]interface{}{openAPIParameterRef("id")}, g.listParameters(related.source)...)
+		collection["get"] = g.operation(operationID+".get", name, "Get the "+member+" of a resource of "+name, parameters, nil, openAPIObject{
+			"200": g.response("The related resources", document),
+		})
+	}
+
+	if _, ok := related.source.(ResourceCreator); ok && updater {
+		collection["post"] = g.operation(operationID+".create", name, "Create a resource in the "+member+" of a resource of "+name, []interface{}{openAPIParameterRef("id")}, openAPIRef(related.name+".CreateRequest"), openAPIObject{
+			"201": g.response("The created resource", openAPIRef(related.name+".Document")),
+			"202": openAPIObject{"description": "The request was accepted"},
+			"204": openAPIObject{"description": "The resource was created with the given ID"},
+		})
+	}
+
+	if len(collection) > 0 {
+		g.paths[baseURL+"/{id}/"+member] = collection
+	}
+}
+
+// addResourceSchemas adds the resource, attributes, relationships, request
+// and document schemas of the resource name
+func (g *openAPIGenerator) addResourceSchemas(name string, resourceType reflect.Type, references []jsonapi.Reference) {
+	g.schemas[name+".Attributes"] = g.attributesSchema(resourceType)
+
+	if len(references) > 0 {
+		relationships := openAPIObject{}
+		for _, reference := range references {
+			relationships[jsonapi.MemberName(reference.Name)] = openAPIRef("ToOneRelationship")
+			if reference.IsToMany() {
+				relationships[jsonapi.MemberName(reference.Name)] = openAPIRef("ToManyRelationship")
+			}
+		}
+
+		g.schemas[name+".Relationships"] = openAPIObject{"type": "object", "properties": relationships}
+	}
+
+	// resourceObject returns the schema of the resource object with the given
+	// required members
+	resourceObject := func(required ...string) openAPIObject {
+		properties := openAPIObject{
+			"type":       openAPIObject{"type": "string", "const": name},
+			"id":         openAPIObject{"type": "string"},
+			"attributes": openAPIRef(name + ".Attributes"),
+		}
+
+		if len(references) > 0 {
+			properties["relationships"] = openAPIRef(name + ".Relationships")
+		}
+
+		return openAPIObject{"type": "object", "required": required, "properties": properties}
+	}
+
+	resource := resourceObject("type", "id")
+	resource["properties"].(openAPIObject)["links"] = openAPIRef("Links")
+	resource["properties"].(openAPIObject)["meta"] = openAPIRef("Meta")
+	g.schemas[name+".Resource"] = resource
+
+	g.schemas[name+".CreateRequest"] = openAPIRequestDocument(resourceObject("type"))
+	g.schemas[name+".UpdateRequest"] = openAPIRequestDocument(resourceObject("type", "id"))
+
+	g.schemas[name+".Document"] = openAPIDataDocument(openAPIRef(name + ".Resource"))
+	g.schemas[name+".CollectionDocument"] = openAPIDataDocument(openAPIObject{
+		"type":  "array",
+		"items": openAPIRef(name + ".Resource"),
+	})
+}
+
+// listParameters returns the query parameters for the collection of source
+func (g *openAPIGenerator) listParameters(source interface{}) []interface{} {
+	parameters := []interface{}{openAPIParameterRef("fields")}
+	if _, ok := source.(PaginatedFindAll); ok {
+		for _, name := range []string{"page.number", "page.size", "page.offset", "page.limit"} {
+			parameters = append(parameters, openAPIParameterRef(name))
+		}
+	}
+
+	return parameters
+}
+
+func (g *openAPIGenerator) operation(id, tag, summary string, parameters []interface{}, body openAPIObject, responses openAPIObject) openAPIObject {
+	responses["default"] = openAPIObject{"$ref": "#/components/responses/Error"}
+	operation := openAPIObject{
+		"operationId": id,
+		"summary":     summary,
+		"tags":        []string{tag},
+		"responses":   responses,
+	}
+
+	if len(parameters) > 0 {
+		operation["parameters"] = parameters
+	}
+
+	if body != nil {
+		operation["requestBody"] = openAPIObject{
+			"required": true,
+			"content":  g.api.openAPIContent(body),
+		}
+	}
+
+	return operation
+}
+
+func (g *openAPIGenerator) response(description string, schema openAPIObject) openAPIObject {
+	return openAPIObject{
+		"description": description,
+		"content":     g.api.openAPIContent(schema),
+	}
+}
+
+// openAPIListable returns whether the collection route of source works
+func openAPIListable(source interface{}) bool {
+	switch source.(type) {
+	case FindAll, PaginatedFindAll, StreamingFindAll:
+		return true
+	}
+
+	return false
+}
+
+// newPrototype returns a pointer to a new struct of the resource type
+func newPrototype(resourceType reflect.Type) interface{} {
+	if resourceType.Kind() == reflect.Ptr {
+		return reflect.New(resourceType.Elem()).Interface()
+	}
+
+	return reflect.New(resourceType).Interface()
+}
+
+func openAPIRef(schema string) openAPIObject {
+	return openAPIObject{"$ref": "#/components/schemas/" + schema}
+}
+
+func openAPIParameterRef(parameter string) openAPIObject {
+	return openAPIObject{"$ref": "#/components/parameters/" + parameter}
+}
+
+// openAPINullable allows null in addition to schema
+func openAPINullable(schema openAPIObject) openAPIObject {
+	if typ, ok := schema["type"].(string); ok {
+		schema["type"] = []string{typ, "null"}
+		return schema
+	}
+
+	if len(schema) == 0 {
+		return schema
+	}
+
+	return openAPIObject{"oneOf": []interface{}{schema, openAPIObject{"type": "null"}}}
+}
+
+// openAPIDataDocument returns the schema of a document with the primary data
+func openAPIDataDocument(data openAPIObject) openAPIObject {
+	return openAPIObject{
+		"type":     "object",
+		"required": []string{"data"},
+		"properties": openAPIObject{
+			"data":     data,
+			"included": openAPIObject{"type": "array", "items": openAPIRef("Resource")},
+			"links":    openAPIRef("Links"),
+			"meta":     openAPIRef("Meta"),
+		},
+	}
+}
+
+// openAPIRequestDocument returns the schema of a request payload with data
+func openAPIRequestDocument(data openAPIObject) openAPIObject {
+	return openAPIObject{
+		"type":       "object",
+		"required":   []string{"data"},
+		"properties": openAPIObject{"data": data},
+	}
+}
+
+// commonOpenAPISchemas returns the schemas that are shared by all resources
+func commonOpenAPISchemas() openAPIObject {
+	identifier := openAPIObject{
+		"type":     "object",
+		"required": []string{"type", "id"},
+		"properties": openAPIObject{
+			"type": openAPIObject{"type": "string"},
+			"id":   openAPIObject{"type": "string"},
+		},
+	}
+
+	return openAPIObject{
+		"Meta": openAPIObject{"type": "object"},
+		"Link": openAPIObject{
+			"oneOf": []interface{}{
+				openAPIObject{"type": "string", "format": "uri-reference"},
+				openAPIObject{
+					"type":     "object",
+					"required": []string{"href"},
+					"properties": openAPIObject{
+						"href": openAPIObject{"type": "string", "format": "uri-reference"},
+						"meta": openAPIRef("Meta"),
+					},
+				},
+				openAPIObject{"type": "null"},
+			},
+		},
+		"Links": openAPIObject{
+			"type":                 "object",
+			"description":          "Contains the pagination links first, prev, next and last for paginated collections",
+			"additionalProperties": openAPIRef("Link"),
+		},
+		"ResourceIdentifier": identifier,
+		"Resource": openAPIObject{
+			"type":     "object",
+			"required": []string{"type", "id"},
+			"properties": openAPIObject{
+				"type":          openAPIObject{"type": "string"},
+				"id":            openAPIObject{"type": "string"},
+				"attributes":    openAPIObject{"type": "object"},
+				"relationships": openAPIObject{"type": "object", "additionalProperties": openAPIObject{"type": "object"}},
+				"links":         openAPIRef("Links"),
+				"meta":          openAPIRef("Meta"),
+			},
+		},
+		"ToOneRelationship": openAPIObject{
+			"type": "object",
+			"properties": openAPIObject{
+				"data":  openAPINullable(openAPIRef("ResourceIdentifier")),
+				"links": openAPIRef("Links"),
+				"meta":  openAPIRef("Meta"),
+			},
+		},
+		"ToManyRelationship": openAPIObject{
+			"type": "object",
+			"properties": openAPIObject{
+				"data":  openAPIObject{"type": "array", "items": openAPIRef("ResourceIdentifier")},
+				"links": openAPIRef("Links"),
+				"meta":  openAPIRef("Meta"),
+			},
+		},
+		"Error": openAPIObject{
+			"type": "object",
+			"properties": openAPIObject{
+				"id": openAPIObject{"type": "string"},
+				"links": openAPIObject{
+					"type":       "object",
+					"properties": openAPIObject{"about": openAPIObject{"type": "string"}},
+				},
+				"status": openAPIObject{"type": "string"},
+				"code":   openAPIObject{"type": "string"},
+				"title":  openAPIObject{"type": "string"},
+				"detail": openAPIObject{"type": "string"},
+				"source": openAPIObject{
+					"type": "object",
+					"properties": openAPIObject{
+						"pointer":   openAPIObject{"type": "string"},
+						"parameter": openAPIObject{"type": "string"},
+					},
+				},
+				"meta": openAPIObject{},
+			},
+		},
+		"ErrorDocument": openAPIObject{
+			"type":     "object",
+			"required": []string{"errors"},
+			"properties": openAPIObject{
+				"errors": openAPIObject{"type": "array", "items": openAPIRef("Error")},
+				"meta":   openAPIRef("Meta"),
+			},
+		},
+	}
+}
+
+// commonOpenAPIParameters returns the parameters that are shared by all routes
+func commonOpenAPIParameters() openAPIObject {
+	parameters := openAPIObject{
+		"id": openAPIObject{
+			"name":     "id",
+			"in":       "path",
+			"required": true,
+			"schema":   openAPIObject{"type": "string"},
+		},
+		"fields": openAPIObject{
+			"name":        "fields",
+			"in":          "query",
+			"description": "Sparse fieldsets, e.g. fields[posts]=title,text",
+			"style":       "deepObject",
+			"explode":     true,
+			"schema": openAPIObject{
+				"type":                 "object",
+				"additionalProperties": openAPIObject{"type": "string"},
+			},
+		},
+	}
+
+	for _, name := range []string{"number", "size", "offset", "limit"} {
+		parameters["page."+name] = openAPIObject{
+			"name":   "page[" + name + "]",
+			"in":     "query",
+			"schema": openAPIObject{"type": "integer", "minimum": 0},
+		}
+	}
+
+	return parameters
+}
@@ -9,4 +9,15 @@ type Request struct {
 	Pagination   map[string]string
 	Header       http.Header
 	Context      APIContexter
+	// Parent is set if an object is created in a related collection
+	Parent *ParentReference
+}
+
+// ParentReference identifies the resource in whose related collection an object
+// is created. For `POST /v1/users/1/posts` Type is users, ID is 1 and
+// Relationship is the name of the reference, posts.
+type ParentReference struct {
+	Type         string
+	ID           string
+	Relationship string
 }
@@ -0,0 +1,77 @@
+package api2go
+
+import (
+	"net/http"
+
+	"github.com/manyminds/api2go/routing"
+)
+
+// Operation describes what a generated route does
+type Operation string
+
+// The operations of the generated routes
+const (
+	OperationOptions                Operation = "options"
+	OperationList                   Operation = "list"
+	OperationGet                    Operation = "get"
+	OperationCreate                 Operation = "create"
+	OperationUpdate                 Operation = "update"
+	OperationDelete                 Operation = "delete"
+	OperationGetRelationship        Operation = "getRelationship"
+	OperationGetRelated             Operation = "getRelated"
+	OperationCreateRelated          Operation = "createRelated"
+	OperationReplaceRelationship    Operation = "replaceRelationship"
+	OperationAddToRelationship      Operation = "addToRelationship"
+	OperationRemoveFromRelationship Operation = "removeFromRelationship"
+	OperationOpenAPI                Operation = "openAPI"
+)
+
+// Route describes a route that was registered by the API. Path is the
+// template that was passed to the router, e.g. `/v1/posts/:id`. Resource is
+// the name of the resource and empty for routes like the OpenAPI document,
+// Relationship is the member name for relationship routes.
+type Route struct {
+	Method       string    `json:"method"`
+	Path         string    `json:"path"`
+	Resource     string    `json:"resource,omitempty"`
+	Operation    Operation `json:"operation"`
+	Relationship string    `json:"relationship,omitempty"`
+}
+
+// Routes returns all routes that were registered by this API in the order of
+// registration
+func (api *API) Routes() []Route {
+	return append([]Route(nil), api.routes...)
+}
+
+// RoutesHandler returns a handler that serves Routes as JSON. It is not added
+// to the API, mount it wherever your debug endpoints live, e.g.
+// `http.Handle("/debug/routes", api.RoutesHandler())`.
+func (api *API) RoutesHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		result, err := api.jsonCodec().Marshal(api.Routes())
+		if err != nil {
+			api.handleError(err, w, r)
+			return
+		}
+
+		writeResult(w, result, http.StatusOK, "application/json")
+	})
+}
+
+// handle registers handler at the router and records the route
+func (api *API) handle(route Route, handler routing.HandlerFunc) {
+	api.routes = append(api.routes, route)
+	api.router.Handle(route.Method, route.Path, handler)
+}
+
+// hasRoute returns true if route was already registered
+func (api *API) hasRoute(route Route) bool {
+	for _, registered := range api.routes {
+		if registered == route {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,40 @@
+package routing_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/mux"
+	"github.com/manyminds/api2go"
+	"github.com/manyminds/api2go/examples/model"
+	"github.com/manyminds/api2go/examples/resource"
+	"github.com/manyminds/api2go/examples/storage"
+	"github.com/manyminds/api2go/routing"
+	ginrouter "github.com/manyminds/api2go/routing/gin"
+	"github.com/manyminds/api2go/routing/gorilla"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("router adapters", func() {
+	It("can be used together in one binary", func() {
+		gin.SetMode(gin.ReleaseMode)
+		engine := gin.New()
+		router := mux.NewRouter()
+
+		for _, adapter := range []routing.Routeable{ginrouter.New(engine), gorilla.New(router)} {
+			api := api2go.NewAPIWithRouting("api", api2go.NewStaticResolver("/"), adapter)
+			api.AddResource(model.Chocolate{}, resource.ChocolateResource{ChocStorage: storage.NewChocolateStorage()})
+		}
+
+		for _, handler := range []http.Handler{engine, router} {
+			rec := httptest.NewRecorder()
+			req, err := http.NewRequest("GET", "/api/chocolates", nil)
+			Expect(err).ToNot(HaveOccurred())
+			handler.ServeHTTP(rec, req)
+			Expect(rec.Code).To(Equal(http.StatusOK))
+		}
+	})
+})
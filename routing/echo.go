@@ -1,38 +1,17 @@
-//go:build echo && !gorillamux && !gingonic
-// +build echo,!gorillamux,!gingonic
+//go:build echo
+// +build echo
 
 package routing
 
 import (
-	"net/http"
-
 	"github.com/labstack/echo"
+	echorouter "github.com/manyminds/api2go/routing/echo"
 )
 
-type echoRouter struct {
-	echo *echo.Echo
-}
-
-func (e echoRouter) Handler() http.Handler {
-	return e.echo
-}
-
-func (e echoRouter) Handle(protocol, route string, handler HandlerFunc) {
-	echoHandlerFunc := func(c echo.Context) error {
-		params := map[string]string{}
-
-		for i, p := range c.ParamNames() {
-			params[p] = c.ParamValues()[i]
-		}
-
-		handler(c.Response(), c.Request(), params, make(map[string]interface{}))
-
-		return nil
-	}
-	e.echo.Add(protocol, route, echoHandlerFunc)
-}
-
 // Echo created a new api2go router to use with the echo framework
+//
+// Deprecated: use New of the package github.com/manyminds/api2go/routing/echo,
+// it does not need a build tag.
 func Echo(e *echo.Echo) Routeable {
-	return &echoRouter{echo: e}
+	return echorouter.New(e)
 }
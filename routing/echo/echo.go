@@ -0,0 +1,59 @@
+// Package echo contains the api2go router adapter for the echo framework
+package echo
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/labstack/echo"
+	"github.com/manyminds/api2go/routing/internal/router"
+)
+
+type echoRouter struct {
+	echo *echo.Echo
+}
+
+func (e echoRouter) Handler() http.Handler {
+	return e.echo
+}
+
+func (e echoRouter) Handle(protocol, route string, handler router.HandlerFunc) {
+	echoHandlerFunc := func(c echo.Context) error {
+		params := map[string]string{}
+
+		for i, p := range c.ParamNames() {
+			params[p] = c.ParamValues()[i]
+		}
+
+		r := c.Request()
+		r = r.WithContext(echoValues{Context: r.Context(), echo: c})
+		handler(c.Response(), r, params, make(map[string]interface{}))
+
+		return nil
+	}
+	e.echo.Add(protocol, route, echoHandlerFunc)
+}
+
+// echoValues makes the values of the echo context available through the
+// context of the request. Echo has no way to list all values, so they can not
+// be copied.
+type echoValues struct {
+	context.Context
+	echo echo.Context
+}
+
+func (v echoValues) Value(key interface{}) interface{} {
+	if name, ok := key.(string); ok {
+		if value := v.echo.Get(name); value != nil {
+			return value
+		}
+	}
+
+	return v.Context.Value(key)
+}
+
+// New creates a new api2go router to use with the echo framework, it
+// implements routing.Routeable
+func New(e *echo.Echo) router.Routeable {
+	return &echoRouter{echo: e}
+}
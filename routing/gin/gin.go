@@ -0,0 +1,36 @@
+// Package gin contains the api2go router adapter for the gin framework
+package gin
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/manyminds/api2go/routing/internal/router"
+)
+
+type ginRouter struct {
+	engine *gin.Engine
+}
+
+func (g ginRouter) Handler() http.Handler {
+	return g.engine
+}
+
+func (g ginRouter) Handle(protocol, route string, handler router.HandlerFunc) {
+	wrappedCallback := func(c *gin.Context) {
+		params := map[string]string{}
+		for _, p := range c.Params {
+			params[p.Key] = p.Value
+		}
+
+		handler(c.Writer, c.Request, params, c.Keys)
+	}
+
+	g.engine.Handle(protocol, route, wrappedCallback)
+}
+
+// New creates a new api2go router to use with the gin framework, it implements
+// routing.Routeable
+func New(g *gin.Engine) router.Routeable {
+	return &ginRouter{engine: g}
+}
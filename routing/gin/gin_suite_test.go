@@ -0,0 +1,13 @@
+package gin_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestGin(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Gin Router Suite")
+}
@@ -0,0 +1,162 @@
+package gin_test
+
+import (
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"unsafe"
+
+	"github.com/gin-gonic/gin"
+	"github.com/manyminds/api2go"
+	"github.com/manyminds/api2go/examples/model"
+	"github.com/manyminds/api2go/examples/resource"
+	"github.com/manyminds/api2go/examples/storage"
+	ginrouter "github.com/manyminds/api2go/routing/gin"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("api2go with gingonic router adapter", func() {
+	var (
+		gg           *gin.Engine
+		rec          *httptest.ResponseRecorder
+		contextKey   = "userID"
+		contextValue *string
+		apiContext   api2go.APIContext
+		userStorage  *storage.UserStorage
+	)
+
+	BeforeEach(func() {
+		log.SetOutput(ioutil.Discard)
+		gin.SetMode(gin.ReleaseMode)
+		gg = gin.New()
+		api := api2go.NewAPIWithRouting(
+			"api",
+			api2go.NewStaticResolver("/"),
+			ginrouter.New(gg),
+		)
+
+		// Define the ApiContext to allow for access.
+		apiContext = api2go.APIContext{}
+		api.SetContextAllocator(func(*api2go.API) api2go.APIContexter {
+			return &apiContext
+		})
+
+		contextValue = nil
+		gg.Use(func(c *gin.Context) {
+			if contextValue != nil {
+				c.Set(contextKey, *contextValue)
+			}
+		})
+
+		userStorage = storage.NewUserStorage()
+		chocStorage := storage.NewChocolateStorage()
+		api.AddResource(model.User{}, resource.UserResource{ChocStorage: chocStorage, UserStorage: userStorage})
+		api.AddResource(model.Chocolate{}, resource.ChocolateResource{ChocStorage: chocStorage, UserStorage: userStorage})
+	})
+
+	send := func(method, url, body string) {
+		rec = httptest.NewRecorder()
+		req, err := http.NewRequest(method, url, strings.NewReader(body))
+		Expect(err).ToNot(HaveOccurred())
+		gg.ServeHTTP(rec, req)
+	}
+
+	// contextKeys returns the unexported keys of the APIContext
+	contextKeys := func() map[string]interface{} {
+		rawKeys := reflect.ValueOf(&apiContext).Elem().Field(0)
+		return reflect.NewAt(rawKeys.Type(), unsafe.Pointer(rawKeys.UnsafeAddr())).Elem().Interface().(map[string]interface{})
+	}
+
+	It("handles the CRUD routes", func() {
+		send("POST", "/api/users", `{"data": {"attributes": {"user-name": "Sansa Stark"}, "id": "1", "type": "users"}}`)
+		Expect(rec.Code).To(Equal(http.StatusCreated))
+
+		send("GET", "/api/users/1", "")
+		Expect(rec.Code).To(Equal(http.StatusOK))
+		Expect(rec.Body.String()).To(MatchJSON(`{
+			"data": {
+				"attributes": {"user-name": "Sansa Stark"},
+				"id": "1",
+				"relationships": {
+					"sweets": {
+						"data": [],
+						"links": {"related": "/api/users/1/sweets", "self": "/api/users/1/relationships/sweets"}
+					}
+				},
+				"type": "users"
+			},
+			"meta": {"author": "The api2go examples crew", "license": "wtfpl", "license-url": "http://www.wtfpl.net"}
+		}`))
+
+		send("PATCH", "/api/users/1", `{"data": {"id": "1", "attributes": {"user-name": "Alayne"}, "type": "users"}}`)
+		Expect(rec.Code).To(Equal(http.StatusNoContent))
+
+		send("GET", "/api/users/1", "")
+		Expect(rec.Code).To(Equal(http.StatusOK))
+		Expect(rec.Body.String()).To(ContainSubstring(`"user-name":"Alayne"`))
+
+		send("DELETE", "/api/users/1", "")
+		Expect(rec.Code).To(Equal(http.StatusNoContent))
+
+		send("GET", "/api/users/1", "")
+		Expect(rec.Code).To(Equal(http.StatusNotFound))
+		Expect(rec.Body.String()).To(MatchJSON(`{"errors":[{"status":"404","title":"http error (404) User for id 1 not found and 0 more errors, User for id 1 not found"}]}`))
+	})
+
+	It("will create links data without double slashes", func() {
+		userStorage.Insert(model.User{Username: "Bender Bending Rodriguez"})
+		userStorage.Insert(model.User{Username: "Calculon"})
+
+		send("GET", "/api/users?page[offset]=0&page[limit]=1", "")
+		Expect(rec.Body.String()).To(MatchJSON(`
+		{
+			"links": {
+				"last": "/api/users?page[limit]=1&page[offset]=1",
+				"next": "/api/users?page[limit]=1&page[offset]=1"
+			},
+			"data": [
+				{
+					"type": "users",
+					"id": "1",
+					"attributes": {
+						"user-name": "Bender Bending Rodriguez"
+					},
+					"relationships": {
+						"sweets": {
+							"links": {
+								"related": "/api/users/1/sweets",
+								"self": "/api/users/1/relationships/sweets"
+							},
+							"data": []
+						}
+					}
+				}
+			],
+			"meta": {
+				"author": "The api2go examples crew",
+				"license": "wtfpl",
+				"license-url": "http://www.wtfpl.net"
+			}
+		}`))
+	})
+
+	It("copies the keys of the gin context", func() {
+		tempVal := "1"
+		contextValue = &tempVal
+		send("GET", "/api/chocolates", "")
+		Expect(rec.Code).To(Equal(http.StatusOK))
+		Expect(rec.Body.String()).To(MatchJSON(`{"data":[],"meta":{"author": "The api2go examples crew", "license": "wtfpl", "license-url": "http://www.wtfpl.net"}}`))
+		Expect(contextKeys()).To(Equal(map[string]interface{}{contextKey: tempVal}))
+	})
+
+	It("does not add keys without values in the gin context", func() {
+		send("GET", "/api/chocolates", "")
+		Expect(rec.Code).To(Equal(http.StatusOK))
+		Expect(contextKeys()).To(BeNil())
+	})
+})
@@ -1,36 +1,17 @@
-//go:build gingonic && !gorillamux && !echo
-// +build gingonic,!gorillamux,!echo
+//go:build gingonic
+// +build gingonic
 
 package routing
 
 import (
-	"net/http"
-
 	"github.com/gin-gonic/gin"
+	ginrouter "github.com/manyminds/api2go/routing/gin"
 )
 
-type ginRouter struct {
-	router *gin.Engine
-}
-
-func (g ginRouter) Handler() http.Handler {
-	return g.router
-}
-
-func (g ginRouter) Handle(protocol, route string, handler HandlerFunc) {
-	wrappedCallback := func(c *gin.Context) {
-		params := map[string]string{}
-		for _, p := range c.Params {
-			params[p.Key] = p.Value
-		}
-
-		handler(c.Writer, c.Request, params, c.Keys)
-	}
-
-	g.router.Handle(protocol, route, wrappedCallback)
-}
-
-//Gin creates a new api2go router to use with the gin framework
+// Gin creates a new api2go router to use with the gin framework
+//
+// Deprecated: use New of the package github.com/manyminds/api2go/routing/gin,
+// it does not need a build tag.
 func Gin(g *gin.Engine) Routeable {
-	return &ginRouter{router: g}
+	return ginrouter.New(g)
 }
@@ -0,0 +1,13 @@
+package gorilla_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestGorilla(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Gorilla Router Suite")
+}
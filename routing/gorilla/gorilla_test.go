@@ -0,0 +1,112 @@
+package gorilla_test
+
+import (
+	"context"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/manyminds/api2go"
+	"github.com/manyminds/api2go/examples/model"
+	"github.com/manyminds/api2go/examples/resource"
+	"github.com/manyminds/api2go/examples/storage"
+	"github.com/manyminds/api2go/routing/gorilla"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("api2go with gorillamux router adapter", func() {
+	var (
+		api    *api2go.API
+		router *mux.Router
+		rec    *httptest.ResponseRecorder
+	)
+
+	BeforeEach(func() {
+		log.SetOutput(ioutil.Discard)
+		router = mux.NewRouter()
+		api = api2go.NewAPIWithRouting("api", api2go.NewStaticResolver("/"), gorilla.New(router))
+
+		userStorage := storage.NewUserStorage()
+		chocStorage := storage.NewChocolateStorage()
+		api.AddResource(model.User{}, resource.UserResource{ChocStorage: chocStorage, UserStorage: userStorage})
+		api.AddResource(model.Chocolate{}, resource.ChocolateResource{ChocStorage: chocStorage, UserStorage: userStorage})
+
+		rec = httptest.NewRecorder()
+	})
+
+	send := func(method, url, body string) {
+		rec = httptest.NewRecorder()
+		req, err := http.NewRequest(method, url, strings.NewReader(body))
+		Expect(err).ToNot(HaveOccurred())
+		router.ServeHTTP(rec, req)
+	}
+
+	It("handles the CRUD routes", func() {
+		send("POST", "/api/users", `{"data": {"attributes": {"user-name": "Sansa Stark"}, "id": "1", "type": "users"}}`)
+		Expect(rec.Code).To(Equal(http.StatusCreated))
+
+		send("GET", "/api/users/1", "")
+		Expect(rec.Code).To(Equal(http.StatusOK))
+		Expect(rec.Body.String()).To(MatchJSON(`{
+			"data": {
+				"attributes": {"user-name": "Sansa Stark"},
+				"id": "1",
+				"relationships": {
+					"sweets": {
+						"data": [],
+						"links": {"related": "/api/users/1/sweets", "self": "/api/users/1/relationships/sweets"}
+					}
+				},
+				"type": "users"
+			},
+			"meta": {"author": "The api2go examples crew", "license": "wtfpl", "license-url": "http://www.wtfpl.net"}
+		}`))
+
+		send("PATCH", "/api/users/1", `{"data": {"id": "1", "attributes": {"user-name": "Alayne"}, "type": "users"}}`)
+		Expect(rec.Code).To(Equal(http.StatusNoContent))
+
+		send("DELETE", "/api/users/1", "")
+		Expect(rec.Code).To(Equal(http.StatusNoContent))
+
+		send("GET", "/api/users/1", "")
+		Expect(rec.Code).To(Equal(http.StatusNotFound))
+	})
+
+	It("passes the params of relationship routes", func() {
+		send("POST", "/api/chocolates", `{"data": {"attributes": {"name": "Ritter Sport", "taste": "Very Good"}, "type": "chocolates"}}`)
+		Expect(rec.Code).To(Equal(http.StatusCreated))
+
+		send("POST", "/api/users", `{"data": {"attributes": {"user-name": "Arya"}, "type": "users",
+			"relationships": {"sweets": {"data": [{"type": "chocolates", "id": "1"}]}}}}`)
+		Expect(rec.Code).To(Equal(http.StatusCreated))
+
+		send("GET", "/api/users/1/relationships/sweets", "")
+		Expect(rec.Code).To(Equal(http.StatusOK))
+		Expect(rec.Body.String()).To(ContainSubstring(`"data":[{"type":"chocolates","id":"1"}]`))
+
+		send("GET", "/api/users/1/sweets", "")
+		Expect(rec.Code).To(Equal(http.StatusOK))
+		Expect(rec.Body.String()).To(ContainSubstring(`"name":"Ritter Sport"`))
+	})
+
+	It("passes the values of framework middlewares to the api context", func() {
+		router.Use(func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), "tenant", "acme")))
+			})
+		})
+		api.UseMiddleware(func(c api2go.APIContexter, w http.ResponseWriter, r *http.Request) {
+			tenant, _ := c.Get("tenant")
+			w.Header().Set("x-tenant", tenant.(string))
+		})
+
+		send("GET", "/api/chocolates", "")
+		Expect(rec.Code).To(Equal(http.StatusOK))
+		Expect(rec.Header().Get("x-tenant")).To(Equal("acme"))
+	})
+})
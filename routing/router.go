@@ -1,22 +1,13 @@
 package routing
 
-import "net/http"
+import "github.com/manyminds/api2go/routing/internal/router"
 
 // HandlerFunc must contain all params from the route
 // in the form key,value
-type HandlerFunc func(w http.ResponseWriter, r *http.Request, params map[string]string, context map[string]interface{})
+type HandlerFunc = router.HandlerFunc
 
 // Routeable allows drop in replacement for api2go's router
 // by default, we are using julienschmidt/httprouter
 // but you can use any router that has similiar features
-// e.g. gin
-type Routeable interface {
-	// Handler should return the routers main handler, often this is the router itself
-	Handler() http.Handler
-	// Handle must be implemented to register api2go's default routines
-	// to your used router.
-	// protocol will be PATCH,OPTIONS,GET,POST,PUT
-	// route will be the request route /items/:id where :id means dynamically filled params
-	// handler is the handler that will answer to this specific route
-	Handle(protocol, route string, handler HandlerFunc)
-}
+// e.g. gin, see the packages below routing for the adapters.
+type Routeable = router.Routeable
@@ -0,0 +1,48 @@
+package routing
+
+import (
+	"net/http"
+	"strings"
+)
+
+type serveMuxRouter struct {
+	mux *http.ServeMux
+}
+
+func (s serveMuxRouter) Handler() http.Handler {
+	return s.mux
+}
+
+func (s serveMuxRouter) Handle(protocol, route string, handler HandlerFunc) {
+	// The request path will have parameterized segments indicated as :name.
+	// Convert that notation to the {name} wildcards of http.ServeMux.
+	segments := strings.Split(route, "/")
+	var names []string
+	for i, segment := range segments {
+		if len(segment) > 0 && segment[0] == ':' {
+			names = append(names, segment[1:])
+			segments[i] = "{" + segment[1:] + "}"
+		}
+	}
+
+	// a trailing slash would match all paths below the route, {$} prevents that
+	pattern := strings.Join(segments, "/")
+	if strings.HasSuffix(pattern, "/") {
+		pattern += "{$}"
+	}
+
+	s.mux.HandleFunc(protocol+" "+pattern, func(w http.ResponseWriter, r *http.Request) {
+		params := make(map[string]string, len(names))
+		for _, name := range names {
+			params[name] = r.PathValue(name)
+		}
+
+		handler(w, r, params, make(map[string]interface{}))
+	})
+}
+
+// ServeMux creates a new api2go router to use with the http.ServeMux of the
+// standard library. It requires the method and wildcard patterns of go 1.22.
+func ServeMux(mux *http.ServeMux) Routeable {
+	return &serveMuxRouter{mux: mux}
+}
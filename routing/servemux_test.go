@@ -0,0 +1,101 @@
+package routing_test
+
+import (
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/manyminds/api2go"
+	"github.com/manyminds/api2go/examples/model"
+	"github.com/manyminds/api2go/examples/resource"
+	"github.com/manyminds/api2go/examples/storage"
+	"github.com/manyminds/api2go/routing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("api2go with http.ServeMux router adapter", func() {
+	var (
+		mux *http.ServeMux
+		rec *httptest.ResponseRecorder
+	)
+
+	BeforeEach(func() {
+		log.SetOutput(ioutil.Discard)
+		mux = http.NewServeMux()
+		api := api2go.NewAPIWithRouting("api", api2go.NewStaticResolver("/"), routing.ServeMux(mux))
+
+		userStorage := storage.NewUserStorage()
+		chocStorage := storage.NewChocolateStorage()
+		api.AddResource(model.User{}, resource.UserResource{ChocStorage: chocStorage, UserStorage: userStorage})
+		api.AddResource(model.Chocolate{}, resource.ChocolateResource{ChocStorage: chocStorage, UserStorage: userStorage})
+
+		rec = httptest.NewRecorder()
+	})
+
+	send := func(method, url, body string) {
+		rec = httptest.NewRecorder()
+		req, err := http.NewRequest(method, url, strings.NewReader(body))
+		Expect(err).ToNot(HaveOccurred())
+		mux.ServeHTTP(rec, req)
+	}
+
+	It("handles the CRUD routes", func() {
+		send("POST", "/api/users", `{"data": {"attributes": {"user-name": "Sansa Stark"}, "id": "1", "type": "users"}}`)
+		Expect(rec.Code).To(Equal(http.StatusCreated))
+
+		send("GET", "/api/users/1", "")
+		Expect(rec.Code).To(Equal(http.StatusOK))
+		Expect(rec.Body.String()).To(MatchJSON(`{
+			"data": {
+				"attributes": {"user-name": "Sansa Stark"},
+				"id": "1",
+				"relationships": {
+					"sweets": {
+						"data": [],
+						"links": {"related": "/api/users/1/sweets", "self": "/api/users/1/relationships/sweets"}
+					}
+				},
+				"type": "users"
+			},
+			"meta": {"author": "The api2go examples crew", "license": "wtfpl", "license-url": "http://www.wtfpl.net"}
+		}`))
+
+		send("PATCH", "/api/users/1", `{"data": {"id": "1", "attributes": {"user-name": "Alayne"}, "type": "users"}}`)
+		Expect(rec.Code).To(Equal(http.StatusNoContent))
+
+		send("DELETE", "/api/users/1", "")
+		Expect(rec.Code).To(Equal(http.StatusNoContent))
+
+		send("GET", "/api/users/1", "")
+		Expect(rec.Code).To(Equal(http.StatusNotFound))
+	})
+
+	It("passes the params of relationship routes", func() {
+		send("POST", "/api/chocolates", `{"data": {"attributes": {"name": "Ritter Sport", "taste": "Very Good"}, "type": "chocolates"}}`)
+		Expect(rec.Code).To(Equal(http.StatusCreated))
+
+		send("POST", "/api/users", `{"data": {"attributes": {"user-name": "Arya"}, "type": "users",
+			"relationships": {"sweets": {"data": [{"type": "chocolates", "id": "1"}]}}}}`)
+		Expect(rec.Code).To(Equal(http.StatusCreated))
+
+		send("GET", "/api/users/1/relationships/sweets", "")
+		Expect(rec.Code).To(Equal(http.StatusOK))
+		Expect(rec.Body.String()).To(ContainSubstring(`"data":[{"type":"chocolates","id":"1"}]`))
+
+		send("GET", "/api/users/1/sweets", "")
+		Expect(rec.Code).To(Equal(http.StatusOK))
+		Expect(rec.Body.String()).To(ContainSubstring(`"name":"Ritter Sport"`))
+	})
+
+	It("only matches the registered methods and paths", func() {
+		send("PUT", "/api/users/1", "")
+		Expect(rec.Code).To(Equal(http.StatusMethodNotAllowed))
+
+		send("GET", "/api/users/1/unknown", "")
+		Expect(rec.Code).To(Equal(http.StatusNotFound))
+	})
+})
@@ -0,0 +1,602 @@
+package api2go
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"net/mail"
+	"net/url"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/manyminds/api2go/jsonapi"
+)
+
+const codeInvalidAttribute = "API2GO_INVALID_ATTRIBUTE"
+
+var (
+	timeType            = reflect.TypeOf(time.Time{})
+	jsonMarshalerType   = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	jsonUnmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+	textMarshalerType   = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+	uuidRegex           = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+	// schemaPatterns caches the compiled pattern of the jsonschema tags
+	schemaPatterns sync.Map
+)
+
+// schemaGenerator derives JSON schemas from go types like encoding/json
+// encodes them. The schemas are used to validate request payloads and for the
+// attributes of OpenAPI documents.
+//
+// The `jsonschema` struct tag adds constraints to an attribute, e.g.
+// `jsonschema:"required,enum=draft,enum=published"`. For slices all
+// constraints except minItems and maxItems apply to the elements.
+type schemaGenerator struct {
+	// visiting contains the struct types whose schema is generated right now,
+	// recursive types end in an empty schema
+	visiting map[reflect.Type]bool
+}
+
+func newSchemaGenerator() schemaGenerator {
+	return schemaGenerator{visiting: map[reflect.Type]bool{}}
+}
+
+// attributesSchema returns the schema of the attributes of a resource type
+func (g schemaGenerator) attributesSchema(resourceType reflect.Type) openAPIObject {
+	fields, ok := jsonapi.AttributeFields(resourceType)
+	if !ok {
+		return openAPIObject{"type": "object"}
+	}
+
+	return g.fieldsSchema(fields, jsonapi.MemberName)
+}
+
+// fieldsSchema returns the schema of an object with the given fields, the
+// names of the properties are converted with name
+func (g schemaGenerator) fieldsSchema(fields []jsonapi.AttributeField, name func(string) string) openAPIObject {
+	properties := openAPIObject{}
+	required := []string{}
+	for _, field := range fields {
+		schema := g.typeSchema(field.Type)
+		if field.String && stringOptionApplies(field.Type) {
+			schema = stringOptionSchema(field.Type)
+		}
+		if applySchemaTag(schema, field) {
+			required = append(required, name(field.Name))
+		}
+		properties[name(field.Name)] = schema
+	}
+
+	result := openAPIObject{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		result["required"] = required
+	}
+
+	return result
+}
+
+// typeSchema returns the JSON schema of a go type like encoding/json encodes it
+func (g schemaGenerator) typeSchema(t reflect.Type) openAPIObject {
+	if t.Kind() == reflect.Ptr {
+		return openAPINullable(g.typeSchema(t.Elem()))
+	}
+
+	// custom encodings may accept anything, types that are only text encoded in
+	// one direction are encoded or decoded according to their kind otherwise
+	textMarshaler := implements(t, textMarshalerType)
+	textUnmarshaler := implements(t, textUnmarshalerType)
+	switch {
+	case t == timeType:
+		return openAPIObject{"type": "string", "format": "date-time"}
+	case implements(t, jsonMarshalerType) || implements(t, jsonUnmarshalerType):
+		return openAPIObject{}
+	case textMarshaler && textUnmarshaler:
+		return openAPIObject{"type": "string"}
+	case textMarshaler || textUnmarshaler:
+		return openAPIObject{}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return openAPIObject{"type": "boolean"}
+	case reflect.Int8:
+		return openAPIObject{"type": "integer", "format": "int32", "minimum": math.MinInt8, "maximum": math.MaxInt8}
+	case reflect.Int16:
+		return openAPIObject{"type": "integer", "format": "int32", "minimum": math.MinInt16, "maximum": math.MaxInt16}
+	case reflect.Int32:
+		return openAPIObject{"type": "integer", "format": "int32"}
+	case reflect.Int, reflect.Int64:
+		return openAPIObject{"type": "integer", "format": "int64"}
+	case reflect.Uint8:
+		return openAPIObject{"type": "integer", "format": "int32", "minimum": 0, "maximum": math.MaxUint8}
+	case reflect.Uint16:
+		return openAPIObject{"type": "integer", "format": "int32", "minimum": 0, "maximum": math.MaxUint16}
+	case reflect.Uint32:
+		return openAPIObject{"type": "integer", "format": "int64", "minimum": 0, "maximum": int64(math.MaxUint32)}
+	case reflect.Uint, reflect.Uint64:
+		return openAPIObject{"type": "integer", "format": "int64", "minimum": 0}
+	case reflect.Float32:
+		return openAPIObject{"type": "number", "format": "float"}
+	case reflect.Float64:
+		return openAPIObject{"type": "number", "format": "double"}
+	case reflect.String:
+		return openAPIObject{"type": "string"}
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return openAPIObject{"type": "string", "contentEncoding": "base64"}
+		}
+		return openAPINullable(openAPIObject{"type": "array", "items": g.typeSchema(t.Elem())})
+	case reflect.Array:
+		return openAPIObject{"type": "array", "items": g.typeSchema(t.Elem()), "minItems": t.Len(), "maxItems": t.Len()}
+	case reflect.Map:
+		return openAPINullable(openAPIObject{"type": "object", "additionalProperties": g.typeSchema(t.Elem())})
+	case reflect.Struct:
+		if g.visiting[t] {
+			return openAPIObject{}
+		}
+
+		g.visiting[t] = true
+		defer delete(g.visiting, t)
+
+		fields, ok := jsonapi.AttributeFields(t)
+		if !ok {
+			return openAPIObject{}
+		}
+
+		// member names are only converted for the attributes, not inside of them
+		return g.fieldsSchema(fields, func(name string) string { return name })
+	}
+
+	return openAPIObject{}
+}
+
+// implements returns whether t or a pointer to t implements the interface
+func implements(t, interfaceType reflect.Type) bool {
+	return t.Implements(interfaceType) || reflect.PtrTo(t).Implements(interfaceType)
+}
+
+// stringOptionApplies returns whether encoding/json encodes t as a string if
+// the field has the `,string` option. It is ignored for all other types.
+func stringOptionApplies(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.Bool, reflect.String, reflect.Float32, reflect.Float64,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return true
+	}
+
+	return false
+}
+
+// stringOptionSchema returns the schema of a field of type t with the
+// `,string` option, the value is a JSON string that contains the encoding
+func stringOptionSchema(t reflect.Type) openAPIObject {
+	if t.Kind() == reflect.Ptr {
+		return openAPINullable(openAPIObject{"type": "string"})
+	}
+
+	return openAPIObject{"type": "string"}
+}
+
+// applySchemaTag adds the constraints of the jsonschema tag of field to its
+// schema and returns whether the field is required. Invalid tags panic, like
+// all other errors in resource definitions.
+func applySchemaTag(schema openAPIObject, field jsonapi.AttributeField) (required bool) {
+	tag, ok := field.Tag.Lookup("jsonschema")
+	if !ok {
+		return false
+	}
+
+	invalid := func(err error) {
+		panic(fmt.Sprintf("invalid jsonschema tag %q of attribute %s: %v", tag, field.Name, err))
+	}
+
+	target := schema
+	if items, ok := schema["items"].(openAPIObject); ok {
+		target = items
+	}
+
+	for _, option := range strings.Split(tag, ",") {
+		key, value, _ := strings.Cut(option, "=")
+		switch key {
+		case "":
+		case "required":
+			required = true
+		case "format":
+			target["format"] = value
+		case "pattern":
+			if _, err := schemaPattern(value); err != nil {
+				invalid(err)
+			}
+			target["pattern"] = value
+		case "enum":
+			enumValue, err := parseSchemaValue(target, value)
+			if err != nil {
+				invalid(err)
+			}
+			enum, _ := target["enum"].([]interface{})
+			target["enum"] = append(enum, enumValue)
+		case "minimum", "maximum":
+			number, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				invalid(err)
+			}
+			target[key] = number
+		case "minLength", "maxLength", "minItems", "maxItems":
+			length, err := strconv.Atoi(value)
+			if err != nil {
+				invalid(err)
+			}
+			if key == "minItems" || key == "maxItems" {
+				schema[key] = length
+			} else {
+				target[key] = length
+			}
+		default:
+			invalid(fmt.Errorf("unknown option %s", key))
+		}
+	}
+
+	if enum, ok := target["enum"].([]interface{}); ok && hasSchemaType(target, "null") {
+		target["enum"] = append(enum, nil)
+	}
+
+	return required
+}
+
+// parseSchemaValue parses the value of a jsonschema tag for the type of schema
+func parseSchemaValue(schema openAPIObject, value string) (interface{}, error) {
+	switch {
+	case hasSchemaType(schema, "integer"):
+		return strconv.ParseInt(value, 10, 64)
+	case hasSchemaType(schema, "number"):
+		return strconv.ParseFloat(value, 64)
+	case hasSchemaType(schema, "boolean"):
+		return strconv.ParseBool(value)
+	}
+
+	return value, nil
+}
+
+// schemaTypes returns the allowed JSON types of schema, none means all types
+func schemaTypes(schema openAPIObject) []string {
+	switch typ := schema["type"].(type) {
+	case string:
+		return []string{typ}
+	case []string:
+		return typ
+	}
+
+	return nil
+}
+
+func hasSchemaType(schema openAPIObject, typ string) bool {
+	for _, candidate := range schemaTypes(schema) {
+		if candidate == typ {
+			return true
+		}
+	}
+
+	return false
+}
+
+// validateSchema returns an error for every violation of schema by value.
+// Numbers in value must be decoded as json.Number.
+func validateSchema(schema openAPIObject, value interface{}, pointer string) jsonapi.PayloadErrors {
+	var result jsonapi.PayloadErrors
+	invalid := func(format string, args ...interface{}) {
+		result = append(result, jsonapi.PayloadError{Pointer: pointer, Detail: fmt.Sprintf(format, args...)})
+	}
+
+	if alternatives, ok := schema["oneOf"].([]interface{}); ok {
+		for _, alternative := range alternatives {
+			if len(validateSchema(alternative.(openAPIObject), value, pointer)) == 0 {
+				return nil
+			}
+		}
+
+		invalid("does not match any of the allowed schemas")
+		return result
+	}
+
+	if types := schemaTypes(schema); len(types) > 0 && !matchesSchemaType(types, value) {
+		invalid("must be of type %s", strings.Join(types, " or "))
+		return result
+	}
+
+	if enum, ok := schema["enum"].([]interface{}); ok && !schemaEnumContains(enum, value) {
+		allowed, _ := json.Marshal(enum)
+		invalid("must be one of %s", allowed)
+		return result
+	}
+
+	switch value := value.(type) {
+	case string:
+		if length, ok := schemaNumber(schema["minLength"]); ok && float64(utf8.RuneCountInString(value)) < length {
+			invalid("must be at least %v characters long", length)
+		}
+		if length, ok := schemaNumber(schema["maxLength"]); ok && float64(utf8.RuneCountInString(value)) > length {
+			invalid("must be at most %v characters long", length)
+		}
+		if pattern, ok := schema["pattern"].(string); ok {
+			if regex, err := schemaPattern(pattern); err == nil && !regex.MatchString(value) {
+				invalid("must match the pattern %s", pattern)
+			}
+		}
+		if format, ok := schema["format"].(string); ok && !validSchemaFormat(format, value) {
+			invalid("must be a valid %s", format)
+		}
+		if schema["contentEncoding"] == "base64" {
+			if _, err := base64.StdEncoding.DecodeString(value); err != nil {
+				invalid("must be base64 encoded")
+			}
+		}
+	case json.Number:
+		if format, ok := schema["format"].(string); ok && !validSchemaFormat(format, value.String()) {
+			invalid("is out of range for %s", format)
+		}
+		number, err := value.Float64()
+		if err != nil {
+			break
+		}
+		if minimum, ok := schemaNumber(schema["minimum"]); ok && number < minimum {
+			invalid("must be at least %v", minimum)
+		}
+		if maximum, ok := schemaNumber(schema["maximum"]); ok && number > maximum {
+			invalid("must be at most %v", maximum)
+		}
+	case []interface{}:
+		if length, ok := schemaNumber(schema["minItems"]); ok && float64(len(value)) < length {
+			invalid("must contain at least %v elements", length)
+		}
+		if length, ok := schemaNumber(schema["maxItems"]); ok && float64(len(value)) > length {
+			invalid("must contain at most %v elements", length)
+		}
+		if items, ok := schema["items"].(openAPIObject); ok {
+			for i, item := range value {
+				result = append(result, validateSchema(items, item, pointer+"/"+strconv.Itoa(i))...)
+			}
+		}
+	case map[string]interface{}:
+		required, _ := schema["required"].([]string)
+		for _, name := range required {
+			if _, ok := value[name]; !ok {
+				invalid("%s is required", name)
+			}
+		}
+
+		names := make([]string, 0, len(value))
+		for name := range value {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		properties, _ := schema["properties"].(openAPIObject)
+		for _, name := range names {
+			property, ok := properties[name].(openAPIObject)
+			if !ok {
+				property, ok = schema["additionalProperties"].(openAPIObject)
+			}
+			if ok {
+				result = append(result, validateSchema(property, value[name], pointer+"/"+escapeSchemaPointer(name))...)
+			}
+		}
+	}
+
+	return result
+}
+
+// matchesSchemaType returns whether value is of one of the JSON types
+func matchesSchemaType(types []string, value interface{}) bool {
+	for _, typ := range types {
+		switch value := value.(type) {
+		case nil:
+			if typ == "null" {
+				return true
+			}
+		case bool:
+			if typ == "boolean" {
+				return true
+			}
+		case string:
+			if typ == "string" {
+				return true
+			}
+		case json.Number:
+			if typ == "number" || (typ == "integer" && isSchemaInteger(value)) {
+				return true
+			}
+		case []interface{}:
+			if typ == "array" {
+				return true
+			}
+		case map[string]interface{}:
+			if typ == "object" {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// isSchemaInteger returns whether encoding/json can decode number into an
+// integer field
+func isSchemaInteger(number json.Number) bool {
+	if _, err := strconv.ParseInt(number.String(), 10, 64); err == nil {
+		return true
+	}
+
+	_, err := strconv.ParseUint(number.String(), 10, 64)
+	return err == nil
+}
+
+func schemaEnumContains(enum []interface{}, value interface{}) bool {
+	for _, candidate := range enum {
+		if number, ok := value.(json.Number); ok {
+			expected, isNumber := schemaNumber(candidate)
+			actual, err := number.Float64()
+			if isNumber && err == nil && expected == actual {
+				return true
+			}
+			continue
+		}
+
+		if reflect.DeepEqual(candidate, value) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// schemaNumber returns value as float64 if it is a number
+func schemaNumber(value interface{}) (float64, bool) {
+	switch value := value.(type) {
+	case int:
+		return float64(value), true
+	case int64:
+		return float64(value), true
+	case float64:
+		return value, true
+	case json.Number:
+		number, err := value.Float64()
+		return number, err == nil
+	}
+
+	return 0, false
+}
+
+// validSchemaFormat checks the formats that encoding/json or common tags use,
+// other formats are only annotations
+func validSchemaFormat(format, value string) bool {
+	var err error
+	switch format {
+	case "date-time":
+		_, err = time.Parse(time.RFC3339, value)
+	case "date":
+		_, err = time.Parse("2006-01-02", value)
+	case "email":
+		var address *mail.Address
+		address, err = mail.ParseAddress(value)
+		return err == nil && address.Address == value
+	case "uri":
+		var parsed *url.URL
+		parsed, err = url.Parse(value)
+		return err == nil && parsed.IsAbs()
+	case "uuid":
+		return uuidRegex.MatchString(value)
+	case "ipv4":
+		ip := net.ParseIP(value)
+		return ip != nil && ip.To4() != nil && !strings.Contains(value, ":")
+	case "ipv6":
+		ip := net.ParseIP(value)
+		return ip != nil && strings.Contains(value, ":")
+	case "int32":
+		_, err = strconv.ParseInt(value, 10, 32)
+	case "int64":
+		if _, err = strconv.ParseInt(value, 10, 64); err != nil {
+			_, err = strconv.ParseUint(value, 10, 64)
+		}
+	}
+
+	return err == nil
+}
+
+func schemaPattern(pattern string) (*regexp.Regexp, error) {
+	if regex, ok := schemaPatterns.Load(pattern); ok {
+		return regex.(*regexp.Regexp), nil
+	}
+
+	regex, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	schemaPatterns.Store(pattern, regex)
+	return regex, nil
+}
+
+// escapeSchemaPointer escapes a member name for a JSON Pointer
+func escapeSchemaPointer(name string) string {
+	return strings.Replace(strings.Replace(name, "~", "~0", -1), "/", "~1", -1)
+}
+
+// validatePayload validates the attributes of a POST or PATCH payload against
+// the schema of the resource. Required attributes are only checked for new
+// resources, because PATCH requests may contain a subset of the attributes.
+//
+// Numbers must be decoded as json.Number, so encoding/json is used instead of
+// the codec of the API.
+func (res *resource) validatePayload(body []byte, create bool) error {
+	if !res.api.schemaValidation || res.schema == nil {
+		return nil
+	}
+
+	var payload map[string]interface{}
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	if err := decoder.Decode(&payload); err != nil {
+		// invalid documents are reported by Unmarshal
+		return nil
+	}
+
+	data, ok := payload["data"].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+
+	schema := res.schema
+	if !create {
+		schema = openAPIObject{}
+		for key, value := range res.schema {
+			if key != "required" {
+				schema[key] = value
+			}
+		}
+	}
+
+	pointer := "/data/attributes"
+	attributes, ok := data["attributes"]
+	if !ok {
+		pointer = "/data"
+		attributes = map[string]interface{}{}
+	}
+
+	violations := validateSchema(schema, attributes, pointer)
+	if len(violations) == 0 {
+		return nil
+	}
+
+	httpError := NewHTTPError(violations, "Invalid attributes", http.StatusUnprocessableEntity)
+	for _, violation := range violations {
+		httpError.Errors = append(httpError.Errors, Error{
+			Status: strconv.Itoa(http.StatusUnprocessableEntity),
+			Code:   codeInvalidAttribute,
+			Title:  "Invalid attribute",
+			Detail: violation.Detail,
+			Source: &ErrorSource{
+				Pointer: violation.Pointer,
+			},
+		})
+	}
+
+	return httpError
+}
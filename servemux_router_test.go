@@ -0,0 +1,22 @@
+//go:build servemux && !gingonic && !gorillamux && !echo
+// +build servemux,!gingonic,!gorillamux,!echo
+
+package api2go
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/manyminds/api2go/routing"
+)
+
+func newTestRouter() routing.Routeable {
+	mux := http.NewServeMux()
+	// requests that match no route end here, like with the NoRoute handler of gin
+	mux.Handle("/", notAllowedHandler{})
+	return routing.ServeMux(mux)
+}
+
+func init() {
+	log.Println("Testing with http.ServeMux router")
+}
@@ -0,0 +1,232 @@
+package api2go
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+
+	"github.com/manyminds/api2go/jsonapi"
+)
+
+// The TypedCRUD interface is the generic counterpart of CRUD and FindAll. Sources
+// work with their model type T directly instead of type asserting interface{}
+// values and building a Responder for every result.
+//
+// T can either be a struct or a pointer to a struct, api2go will always pass the
+// same kind to Create and Update that it got from FindOne.
+//
+// Besides TypedPaginatedFindAll, a typed source can implement the untyped
+// StreamingFindAll, ObjectInitializer and IDValidator interfaces.
+type TypedCRUD[T any] interface {
+	// FindAll returns all objects
+	FindAll(req Request) ([]T, error)
+	// FindOne returns an object by its ID
+	FindOne(ID string, req Request) (T, error)
+	// Create a new object and return it, the result is sent with 201 Created
+	Create(obj T, req Request) (T, error)
+	// Update an object and return it, the result is sent with 200 OK
+	Update(obj T, req Request) (T, error)
+	// Delete an object, the response is 204 No Content
+	Delete(ID string, req Request) error
+}
+
+// The TypedPaginatedFindAll interface can be optionally implemented by a TypedCRUD
+// source, it works like PaginatedFindAll.
+type TypedPaginatedFindAll[T any] interface {
+	PaginatedFindAll(req Request) (totalCount uint, result []T, err error)
+}
+
+// TypedResponse is the generic counterpart of Response. It is used for the results
+// of typed sources and can be used in untyped sources as well.
+type TypedResponse[T any] struct {
+	Res        T
+	Code       int
+	Meta       map[string]interface{}
+	Pagination Pagination
+}
+
+// NewTypedResponse returns a TypedResponse for result with the given status code
+func NewTypedResponse[T any](result T, code int) TypedResponse[T] {
+	return TypedResponse[T]{Res: result, Code: code}
+}
+
+// Metadata returns additional meta data
+func (r TypedResponse[T]) Metadata() map[string]interface{} {
+	return r.Meta
+}
+
+// Result returns the actual payload
+func (r TypedResponse[T]) Result() interface{} {
+	return r.Res
+}
+
+// StatusCode sets the return status code
+func (r TypedResponse[T]) StatusCode() int {
+	return r.Code
+}
+
+// Links returns a jsonapi.Links object to include in the top-level response
+func (r TypedResponse[T]) Links(req *http.Request, baseURL string) jsonapi.Links {
+	return Response{Pagination: r.Pagination}.Links(req, baseURL)
+}
+
+// typedSource adapts a TypedCRUD to the untyped source interfaces
+type typedSource[T any] struct {
+	source TypedCRUD[T]
+}
+
+// typedPaginatedSource additionally implements PaginatedFindAll
+type typedPaginatedSource[T any] struct {
+	typedSource[T]
+	paginated TypedPaginatedFindAll[T]
+}
+
+// typedStreamingSource additionally implements StreamingFindAll
+type typedStreamingSource[T any] struct {
+	typedSource[T]
+	StreamingFindAll
+}
+
+// typedPaginatedStreamingSource implements PaginatedFindAll and StreamingFindAll
+type typedPaginatedStreamingSource[T any] struct {
+	typedPaginatedSource[T]
+	StreamingFindAll
+}
+
+func (s typedSource[T]) FindAll(req Request) (Responder, error) {
+	result, err := s.source.FindAll(req)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewTypedResponse(result, http.StatusOK), nil
+}
+
+func (s typedSource[T]) FindOne(ID string, req Request) (Responder, error) {
+	result, err := s.source.FindOne(ID, req)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewTypedResponse(result, http.StatusOK), nil
+}
+
+func (s typedSource[T]) Create(obj interface{}, req Request) (Responder, error) {
+	typed, err := s.typed(obj)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := s.source.Create(typed, req)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewTypedResponse(result, http.StatusCreated), nil
+}
+
+func (s typedSource[T]) Update(obj interface{}, req Request) (Responder, error) {
+	typed, err := s.typed(obj)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := s.source.Update(typed, req)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewTypedResponse(result, http.StatusOK), nil
+}
+
+func (s typedSource[T]) Delete(ID string, req Request) (Responder, error) {
+	err := s.source.Delete(ID, req)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Response{Code: http.StatusNoContent}, nil
+}
+
+// typed converts the values that api2go passes to Create and Update
+func (s typedSource[T]) typed(obj interface{}) (T, error) {
+	typed, ok := obj.(T)
+	if !ok {
+		var zero T
+		return zero, fmt.Errorf("expected %T, got %T", zero, obj)
+	}
+
+	return typed, nil
+}
+
+// InitializeObject forwards to the ObjectInitializer of the typed source
+func (s typedSource[T]) InitializeObject(obj interface{}) {
+	if initializer, ok := s.source.(ObjectInitializer); ok {
+		initializer.InitializeObject(obj)
+	}
+}
+
+// ValidID forwards to the IDValidator of the typed source, all IDs are valid
+// if it does not implement one
+func (s typedSource[T]) ValidID(id string) bool {
+	if validator, ok := s.source.(IDValidator); ok {
+		return validator.ValidID(id)
+	}
+
+	return true
+}
+
+func (s typedPaginatedSource[T]) PaginatedFindAll(req Request) (uint, Responder, error) {
+	count, result, err := s.paginated.PaginatedFindAll(req)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	return count, NewTypedResponse(result, http.StatusOK), nil
+}
+
+// AddTypedResource registers a typed data source for the model type T, the type
+// name and routes are the same as for AddResource with an empty T as prototype.
+// Use AddTypedResource[*Model] if the source works with pointers.
+func AddTypedResource[T any](api *API, source TypedCRUD[T]) {
+	api.AddResource(typedPrototype[T](), newTypedSource(source))
+}
+
+// typedPrototype returns an empty T, for pointer types it points to an empty struct
+func typedPrototype[T any]() interface{} {
+	var prototype T
+	prototypeType := reflect.TypeOf(prototype)
+	if prototypeType == nil {
+		panic("AddTypedResource needs a struct or struct pointer type, not an interface")
+	}
+
+	if prototypeType.Kind() == reflect.Ptr {
+		return reflect.New(prototypeType.Elem()).Interface()
+	}
+
+	return prototype
+}
+
+// newTypedSource wraps source so that the optional interfaces are preserved.
+// ObjectInitializer and IDValidator are always implemented by the adapter,
+// the collection interfaces change the behavior and need an adapter for every
+// combination.
+func newTypedSource[T any](source TypedCRUD[T]) interface{} {
+	adapter := typedSource[T]{source: source}
+	paginated, isPaginated := source.(TypedPaginatedFindAll[T])
+	streaming, isStreaming := source.(StreamingFindAll)
+
+	switch {
+	case isPaginated && isStreaming:
+		return typedPaginatedStreamingSource[T]{
+			typedPaginatedSource: typedPaginatedSource[T]{typedSource: adapter, paginated: paginated},
+			StreamingFindAll:     streaming,
+		}
+	case isPaginated:
+		return typedPaginatedSource[T]{typedSource: adapter, paginated: paginated}
+	case isStreaming:
+		return typedStreamingSource[T]{typedSource: adapter, StreamingFindAll: streaming}
+	}
+
+	return adapter
+}